@@ -9,6 +9,7 @@ import (
 	"foodDelivery/usecase"
 	"github.com/dgrijalva/jwt-go"
 	"golang.org/x/crypto/bcrypt"
+	"log"
 	"net/http"
 	"os"
 	"regexp"
@@ -17,16 +18,18 @@ import (
 )
 
 type AuthHandler struct {
-	userUseCase usecase.UserUseCase
+	userUseCase     usecase.UserUseCase
+	referralUseCase usecase.ReferralUseCase
 }
 
 var (
 	ErrNotFound = errors.New("not found")
 )
 
-func NewAuthHandler(userUseCase usecase.UserUseCase) *AuthHandler {
+func NewAuthHandler(userUseCase usecase.UserUseCase, referralUseCase usecase.ReferralUseCase) *AuthHandler {
 	return &AuthHandler{
-		userUseCase: userUseCase,
+		userUseCase:     userUseCase,
+		referralUseCase: referralUseCase,
 	}
 }
 
@@ -35,13 +38,18 @@ func isPasswordValid(inputPassword string, hashedPassword string) bool {
 	return err == nil
 }
 
+// Register signs up a user, optionally with the invite code of a friend.
 func (ah *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
-	var user domain.User
-	err := json.NewDecoder(r.Body).Decode(&user)
+	var registerRequest struct {
+		domain.User
+		ReferralCode string `json:"referral_code"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&registerRequest)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	user := registerRequest.User
 
 	if !isValidEmail(user.Email) {
 		http.Error(w, "email is not valid", http.StatusForbidden)
@@ -59,6 +67,19 @@ func (ah *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	var referrer *domain.User
+	if registerRequest.ReferralCode != "" {
+		referrer, err = ah.referralUseCase.GetReferrer(registerRequest.ReferralCode)
+		if err != nil {
+			if errors.Is(err, usecase.ErrUnknownReferralCode) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
 	if err != nil {
 		http.Error(w, "Failed to hash password", http.StatusInternalServerError)
@@ -71,6 +92,14 @@ func (ah *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	// The account exists at this point, failing the request would only make the retry
+	// collide with it, so a referral that could not be recorded is left for support.
+	if referrer != nil {
+		_, err = ah.referralUseCase.AcceptReferral(referrer, &user)
+		if err != nil {
+			log.Printf("Failed to record the referral of user %d by user %d: %v", user.ID, referrer.ID, err)
+		}
+	}
 
 	response := []byte(`{"message": "User registered successfully"}`)
 	w.Header().Set("Content-Type", "application/json")
@@ -0,0 +1,162 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"foodDelivery/domain"
+	"foodDelivery/repository"
+	"foodDelivery/usecase"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strconv"
+)
+
+type CartHandler struct {
+	cartUseCase usecase.CartUseCase
+}
+
+func NewCartHandler(cartUseCase usecase.CartUseCase) *CartHandler {
+	return &CartHandler{
+		cartUseCase: cartUseCase,
+	}
+}
+
+func (ch *CartHandler) GetCart(w http.ResponseWriter, r *http.Request) {
+	userID := 7
+
+	cart, err := ch.cartUseCase.GetCart(int64(userID))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeCart(w, http.StatusOK, cart)
+}
+
+func (ch *CartHandler) AddItem(w http.ResponseWriter, r *http.Request) {
+	var item domain.CartItem
+	err := json.NewDecoder(r.Body).Decode(&item)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	userID := 7
+
+	cart, err := ch.cartUseCase.AddItem(int64(userID), &item)
+	if err != nil {
+		writeCartError(w, err)
+		return
+	}
+
+	writeCart(w, http.StatusCreated, cart)
+}
+
+func (ch *CartHandler) UpdateItem(w http.ResponseWriter, r *http.Request) {
+	itemID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid cart item ID", http.StatusBadRequest)
+		return
+	}
+
+	var request struct {
+		Quantity int8 `json:"quantity"`
+	}
+	err = json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	userID := 7
+
+	cart, err := ch.cartUseCase.UpdateItem(int64(userID), itemID, request.Quantity)
+	if err != nil {
+		writeCartError(w, err)
+		return
+	}
+
+	writeCart(w, http.StatusOK, cart)
+}
+
+func (ch *CartHandler) RemoveItem(w http.ResponseWriter, r *http.Request) {
+	itemID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid cart item ID", http.StatusBadRequest)
+		return
+	}
+	userID := 7
+
+	cart, err := ch.cartUseCase.RemoveItem(int64(userID), itemID)
+	if err != nil {
+		writeCartError(w, err)
+		return
+	}
+
+	writeCart(w, http.StatusOK, cart)
+}
+
+func (ch *CartHandler) ClearCart(w http.ResponseWriter, r *http.Request) {
+	userID := 7
+
+	err := ch.cartUseCase.ClearCart(int64(userID))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	response := []byte(`{"message": "Cart cleared successfully"}`)
+	_, _ = w.Write(response)
+}
+
+func (ch *CartHandler) Checkout(w http.ResponseWriter, r *http.Request) {
+	var checkout domain.Checkout
+	err := json.NewDecoder(r.Body).Decode(&checkout)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	userID := 7
+
+	order, err := ch.cartUseCase.Checkout(int64(userID), &checkout)
+	if err != nil {
+		writeCartError(w, err)
+		return
+	}
+
+	response, err := json.Marshal(order)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_, _ = w.Write(response)
+}
+
+func writeCart(w http.ResponseWriter, status int, cart *domain.Cart) {
+	response, err := json.Marshal(cart)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(response)
+}
+
+func writeCartError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidQuantity), errors.Is(err, usecase.ErrCartEmpty),
+		errors.Is(err, usecase.ErrQuantityTooLarge):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, usecase.ErrFoodNotFound), errors.Is(err, repository.ErrCartItemNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, usecase.ErrCartSupplierMismatch), errors.Is(err, usecase.ErrCartUnavailableItems):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		writeOrderError(w, err)
+	}
+}
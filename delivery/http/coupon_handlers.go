@@ -0,0 +1,155 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"foodDelivery/domain"
+	"foodDelivery/repository"
+	"foodDelivery/usecase"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strconv"
+)
+
+type CouponHandler struct {
+	couponUseCase usecase.CouponUseCase
+}
+
+func NewCouponHandler(couponUseCase usecase.CouponUseCase) *CouponHandler {
+	return &CouponHandler{
+		couponUseCase: couponUseCase,
+	}
+}
+
+func (ch *CouponHandler) GetAllCoupons(w http.ResponseWriter, r *http.Request) {
+	coupons, err := ch.couponUseCase.GetAllCoupons()
+	if err != nil {
+		writeCouponError(w, err)
+		return
+	}
+
+	writeCouponJSON(w, http.StatusOK, coupons)
+}
+
+func (ch *CouponHandler) GetCouponByID(w http.ResponseWriter, r *http.Request) {
+	couponID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid coupon ID", http.StatusBadRequest)
+		return
+	}
+
+	coupon, err := ch.couponUseCase.GetCouponByID(couponID)
+	if err != nil {
+		writeCouponError(w, err)
+		return
+	}
+
+	writeCouponJSON(w, http.StatusOK, coupon)
+}
+
+func (ch *CouponHandler) CreateCoupon(w http.ResponseWriter, r *http.Request) {
+	var coupon domain.Coupon
+	err := json.NewDecoder(r.Body).Decode(&coupon)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	err = ch.couponUseCase.CreateCoupon(&coupon)
+	if err != nil {
+		writeCouponError(w, err)
+		return
+	}
+
+	writeCouponJSON(w, http.StatusCreated, coupon)
+}
+
+func (ch *CouponHandler) UpdateCoupon(w http.ResponseWriter, r *http.Request) {
+	couponID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid coupon ID", http.StatusBadRequest)
+		return
+	}
+
+	var coupon domain.Coupon
+	err = json.NewDecoder(r.Body).Decode(&coupon)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	coupon.ID = couponID
+
+	err = ch.couponUseCase.UpdateCoupon(&coupon)
+	if err != nil {
+		writeCouponError(w, err)
+		return
+	}
+
+	writeCouponJSON(w, http.StatusOK, coupon)
+}
+
+func (ch *CouponHandler) DeleteCoupon(w http.ResponseWriter, r *http.Request) {
+	couponID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid coupon ID", http.StatusBadRequest)
+		return
+	}
+
+	err = ch.couponUseCase.DeleteCoupon(couponID)
+	if err != nil {
+		writeCouponError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	response := []byte(`{"message": "Coupon deleted successfully"}`)
+	_, _ = w.Write(response)
+}
+
+// ValidateCoupon takes the order being checked out and returns the discount its coupon
+// code gives, without using the coupon up.
+func (ch *CouponHandler) ValidateCoupon(w http.ResponseWriter, r *http.Request) {
+	var order domain.Order
+	err := json.NewDecoder(r.Body).Decode(&order)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	userID := 7
+	order.UserID = int64(userID)
+
+	validation, err := ch.couponUseCase.ValidateCoupon(&order)
+	if err != nil {
+		// The order is quoted, so any checkout error can come back.
+		writeOrderError(w, err)
+		return
+	}
+
+	writeCouponJSON(w, http.StatusOK, validation)
+}
+
+func writeCouponJSON(w http.ResponseWriter, status int, value interface{}) {
+	response, err := json.Marshal(value)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(response)
+}
+
+func writeCouponError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidCoupon):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, repository.ErrCouponNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, repository.ErrCouponExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,326 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"foodDelivery/domain"
+	"foodDelivery/repository"
+	"foodDelivery/usecase"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type CourierHandler struct {
+	courierUseCase  usecase.CourierUseCase
+	deliveryUseCase usecase.DeliveryUseCase
+}
+
+func NewCourierHandler(courierUseCase usecase.CourierUseCase, deliveryUseCase usecase.DeliveryUseCase) *CourierHandler {
+	return &CourierHandler{
+		courierUseCase:  courierUseCase,
+		deliveryUseCase: deliveryUseCase,
+	}
+}
+
+func (ch *CourierHandler) GetAllCouriers(w http.ResponseWriter, r *http.Request) {
+	couriers, err := ch.courierUseCase.GetAllCouriers()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeCourierJSON(w, http.StatusOK, couriers)
+}
+
+func (ch *CourierHandler) GetCourierByID(w http.ResponseWriter, r *http.Request) {
+	courierID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid courier ID", http.StatusBadRequest)
+		return
+	}
+
+	courier, err := ch.courierUseCase.GetCourierByID(courierID)
+	if err != nil {
+		writeCourierError(w, err)
+		return
+	}
+	writeCourierJSON(w, http.StatusOK, courier)
+}
+
+func (ch *CourierHandler) CreateCourier(w http.ResponseWriter, r *http.Request) {
+	var courier domain.Courier
+	err := json.NewDecoder(r.Body).Decode(&courier)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	err = ch.courierUseCase.CreateCourier(&courier)
+	if err != nil {
+		writeCourierError(w, err)
+		return
+	}
+	writeCourierJSON(w, http.StatusCreated, courier)
+}
+
+func (ch *CourierHandler) UpdateCourier(w http.ResponseWriter, r *http.Request) {
+	courierID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid courier ID", http.StatusBadRequest)
+		return
+	}
+
+	var courier domain.Courier
+	err = json.NewDecoder(r.Body).Decode(&courier)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	courier.ID = courierID
+
+	err = ch.courierUseCase.UpdateCourier(&courier)
+	if err != nil {
+		writeCourierError(w, err)
+		return
+	}
+	writeCourierJSON(w, http.StatusOK, courier)
+}
+
+func (ch *CourierHandler) GetCourierShifts(w http.ResponseWriter, r *http.Request) {
+	courierID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid courier ID", http.StatusBadRequest)
+		return
+	}
+
+	shifts, err := ch.courierUseCase.GetCourierShifts(courierID)
+	if err != nil {
+		writeCourierError(w, err)
+		return
+	}
+	writeCourierJSON(w, http.StatusOK, shifts)
+}
+
+func (ch *CourierHandler) CreateCourierShift(w http.ResponseWriter, r *http.Request) {
+	courierID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid courier ID", http.StatusBadRequest)
+		return
+	}
+
+	var shift domain.CourierShift
+	err = json.NewDecoder(r.Body).Decode(&shift)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	shift.CourierID = courierID
+
+	err = ch.courierUseCase.CreateCourierShift(&shift)
+	if err != nil {
+		writeCourierError(w, err)
+		return
+	}
+	writeCourierJSON(w, http.StatusCreated, shift)
+}
+
+func (ch *CourierHandler) DeleteCourierShift(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	courierID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid courier ID", http.StatusBadRequest)
+		return
+	}
+	shiftID, err := strconv.ParseInt(vars["shift_id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid shift ID", http.StatusBadRequest)
+		return
+	}
+
+	err = ch.courierUseCase.DeleteCourierShift(courierID, shiftID)
+	if err != nil {
+		writeCourierError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	response := []byte(`{"message": "Shift deleted successfully"}`)
+	_, _ = w.Write(response)
+}
+
+// AssignOrder handles POST /api/orders/{id}/assign. Without a courier_id the
+// order goes to the nearest available courier.
+func (ch *CourierHandler) AssignOrder(w http.ResponseWriter, r *http.Request) {
+	orderID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid order ID", http.StatusBadRequest)
+		return
+	}
+
+	var assignment domain.CourierAssignment
+	if r.ContentLength != 0 {
+		err = json.NewDecoder(r.Body).Decode(&assignment)
+		if err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	order, err := ch.deliveryUseCase.AssignOrder(orderID, assignment.CourierID)
+	writeDeliveryOrder(w, order, err)
+}
+
+func (ch *CourierHandler) MarkOrderReady(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	supplierID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid supplier ID", http.StatusBadRequest)
+		return
+	}
+	orderID, err := strconv.ParseInt(vars["order_id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid order ID", http.StatusBadRequest)
+		return
+	}
+
+	order, err := ch.deliveryUseCase.MarkOrderReady(supplierID, orderID)
+	writeDeliveryOrder(w, order, err)
+}
+
+// HandOverOrder completes a ready pickup or dine-in order given to the customer at the supplier.
+func (ch *CourierHandler) HandOverOrder(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	supplierID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid supplier ID", http.StatusBadRequest)
+		return
+	}
+	orderID, err := strconv.ParseInt(vars["order_id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid order ID", http.StatusBadRequest)
+		return
+	}
+
+	var handover domain.OrderHandover
+	if r.ContentLength != 0 {
+		err = json.NewDecoder(r.Body).Decode(&handover)
+		if err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	order, err := ch.deliveryUseCase.HandOverOrder(supplierID, orderID, &handover)
+	writeDeliveryOrder(w, order, err)
+}
+
+func (ch *CourierHandler) GetCourierOrders(w http.ResponseWriter, r *http.Request) {
+	userID := 7
+
+	orders, err := ch.deliveryUseCase.GetCourierOrders(int64(userID), r.URL.Query().Get("status"))
+	if err != nil {
+		writeCourierError(w, err)
+		return
+	}
+	writeCourierJSON(w, http.StatusOK, orders)
+}
+
+func (ch *CourierHandler) AcceptOrder(w http.ResponseWriter, r *http.Request) {
+	ch.courierAction(w, r, ch.deliveryUseCase.AcceptOrder)
+}
+
+func (ch *CourierHandler) DeclineOrder(w http.ResponseWriter, r *http.Request) {
+	ch.courierAction(w, r, ch.deliveryUseCase.DeclineOrder)
+}
+
+func (ch *CourierHandler) PickUpOrder(w http.ResponseWriter, r *http.Request) {
+	ch.courierAction(w, r, ch.deliveryUseCase.PickUpOrder)
+}
+
+// DeliverOrder completes a delivery with a proof. A photo proof is sent as a multipart
+// form with a "photo" file, the other methods as JSON.
+func (ch *CourierHandler) DeliverOrder(w http.ResponseWriter, r *http.Request) {
+	orderID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid order ID", http.StatusBadRequest)
+		return
+	}
+	userID := 7
+
+	var confirmation domain.DeliveryConfirmation
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		photo, _, err := r.FormFile("photo")
+		if err != nil {
+			http.Error(w, "Invalid photo upload", http.StatusBadRequest)
+			return
+		}
+		defer photo.Close()
+		confirmation.Method = domain.ProofMethodPhoto
+		confirmation.Photo = photo
+	} else {
+		err = json.NewDecoder(r.Body).Decode(&confirmation)
+		if err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	order, err := ch.deliveryUseCase.DeliverOrder(int64(userID), orderID, &confirmation)
+	writeDeliveryOrder(w, order, err)
+}
+
+// courierAction runs one step of the delivery flow for the signed in courier.
+func (ch *CourierHandler) courierAction(w http.ResponseWriter, r *http.Request,
+	action func(userID int64, orderID int64) (*domain.Order, error)) {
+	orderID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid order ID", http.StatusBadRequest)
+		return
+	}
+	userID := 7
+
+	order, err := action(int64(userID), orderID)
+	writeDeliveryOrder(w, order, err)
+}
+
+func writeDeliveryOrder(w http.ResponseWriter, order *domain.Order, err error) {
+	if err != nil {
+		writeCourierError(w, err)
+		return
+	}
+	writeCourierJSON(w, http.StatusOK, order)
+}
+
+func writeCourierJSON(w http.ResponseWriter, status int, value interface{}) {
+	response, err := json.Marshal(value)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(response)
+}
+
+func writeCourierError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidCourier), errors.Is(err, usecase.ErrInvalidShift),
+		errors.Is(err, usecase.ErrInvalidLocation), errors.Is(err, usecase.ErrInvalidProofMethod),
+		errors.Is(err, usecase.ErrInvalidDeliveryPin), errors.Is(err, usecase.ErrInvalidProofPhoto),
+		errors.Is(err, usecase.ErrOutsideGeofence), errors.Is(err, usecase.ErrInvalidPickupCode):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, repository.ErrCourierNotFound), errors.Is(err, repository.ErrShiftNotFound),
+		errors.Is(err, repository.ErrOrderNotFound), errors.Is(err, repository.ErrSupplierNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, repository.ErrInvalidStatusTransition), errors.Is(err, usecase.ErrNoCourierAvailable),
+		errors.Is(err, usecase.ErrCourierInactive), errors.Is(err, usecase.ErrDeliveryPinLocked),
+		errors.Is(err, usecase.ErrDeliveryPinUnavailable),
+		errors.Is(err, usecase.ErrGeofenceUnavailable):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
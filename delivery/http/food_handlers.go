@@ -61,7 +61,8 @@ func (fh *FoodHandler) CreateFood(w http.ResponseWriter, r *http.Request) {
 
 	err = fh.foodUseCase.CreateFood(&food)
 	if err != nil {
-		if errors.Is(err, usecase.ErrFoodNameRequired) || errors.Is(err, usecase.ErrCategoryRequired) || errors.Is(err, usecase.ErrSupplierRequired) {
+		if errors.Is(err, usecase.ErrFoodNameRequired) || errors.Is(err, usecase.ErrCategoryRequired) || errors.Is(err, usecase.ErrSupplierRequired) ||
+			errors.Is(err, usecase.ErrInvalidModifierGroup) {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		} else if errors.Is(err, usecase.ErrCategoryNotFound) || errors.Is(err, usecase.ErrSupplierNotFound) {
@@ -107,6 +108,9 @@ func (fh *FoodHandler) UpdateFood(w http.ResponseWriter, r *http.Request) {
 		} else if errors.Is(err, usecase.ErrCategoryNotFound) {
 			http.Error(w, "Category not found", http.StatusBadRequest)
 			return
+		} else if errors.Is(err, usecase.ErrInvalidModifierGroup) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
 		}
 
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -152,8 +156,12 @@ func (fh *FoodHandler) DeleteFood(w http.ResponseWriter, r *http.Request) {
 }
 
 func (fh *FoodHandler) GetAllFoodsWithImages(w http.ResponseWriter, r *http.Request) {
-	foods, err := fh.foodUseCase.GetAllFoodsWithImages()
+	foods, err := fh.foodUseCase.GetAllFoodsWithImages(r.URL.Query().Get("sort"))
 	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidSort) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -0,0 +1,77 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"foodDelivery/domain"
+	"foodDelivery/repository"
+	"foodDelivery/usecase"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strconv"
+)
+
+type FulfilmentHandler struct {
+	fulfilmentUseCase usecase.FulfilmentUseCase
+}
+
+func NewFulfilmentHandler(fulfilmentUseCase usecase.FulfilmentUseCase) *FulfilmentHandler {
+	return &FulfilmentHandler{
+		fulfilmentUseCase: fulfilmentUseCase,
+	}
+}
+
+// AcceptOrder confirms a pending order of the supplier. The body lists the lines it cannot
+// make, and may be left out when everything is available.
+func (fh *FulfilmentHandler) AcceptOrder(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	supplierID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid supplier ID", http.StatusBadRequest)
+		return
+	}
+	orderID, err := strconv.ParseInt(vars["order_id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid order ID", http.StatusBadRequest)
+		return
+	}
+	var acceptance domain.OrderAcceptance
+	if r.ContentLength != 0 {
+		err = json.NewDecoder(r.Body).Decode(&acceptance)
+		if err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	order, err := fh.fulfilmentUseCase.AcceptOrder(supplierID, orderID, &acceptance)
+	if err != nil {
+		writeFulfilmentError(w, err)
+		return
+	}
+
+	response, err := json.Marshal(order)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(response)
+}
+
+func writeFulfilmentError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrUnknownOrderItem), errors.Is(err, usecase.ErrInvalidReplacement):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, repository.ErrOrderNotFound), errors.Is(err, usecase.ErrFoodNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, repository.ErrInvalidStatusTransition), errors.Is(err, repository.ErrOutOfStock):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, usecase.ErrPaymentFailed):
+		http.Error(w, err.Error(), http.StatusBadGateway)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
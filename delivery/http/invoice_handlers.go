@@ -0,0 +1,67 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"foodDelivery/repository"
+	"foodDelivery/usecase"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strconv"
+)
+
+type InvoiceHandler struct {
+	invoiceUseCase usecase.InvoiceUseCase
+}
+
+func NewInvoiceHandler(invoiceUseCase usecase.InvoiceUseCase) *InvoiceHandler {
+	return &InvoiceHandler{
+		invoiceUseCase: invoiceUseCase,
+	}
+}
+
+// GetOrderInvoice returns the invoice of a delivered order as an HTML page, or as a
+// PDF file with ?format=pdf.
+func (ih *InvoiceHandler) GetOrderInvoice(w http.ResponseWriter, r *http.Request) {
+	orderID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid order ID", http.StatusBadRequest)
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = usecase.InvoiceFormatHTML
+	}
+	userID := 7
+
+	invoice, err := ih.invoiceUseCase.GetInvoice(int64(userID), orderID)
+	if err != nil {
+		writeInvoiceError(w, err)
+		return
+	}
+	content, contentType, err := ih.invoiceUseCase.RenderInvoice(invoice, format)
+	if err != nil {
+		writeInvoiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	if format == usecase.InvoiceFormatPDF {
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.pdf"`, invoice.Number))
+	}
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(content)
+}
+
+func writeInvoiceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidInvoiceFormat):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, repository.ErrOrderNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, usecase.ErrInvoiceNotAvailable):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,57 @@
+package http
+
+import (
+	"encoding/json"
+	"foodDelivery/usecase"
+	"net/http"
+)
+
+type LoyaltyHandler struct {
+	loyaltyUseCase usecase.LoyaltyUseCase
+}
+
+func NewLoyaltyHandler(loyaltyUseCase usecase.LoyaltyUseCase) *LoyaltyHandler {
+	return &LoyaltyHandler{
+		loyaltyUseCase: loyaltyUseCase,
+	}
+}
+
+func (lh *LoyaltyHandler) GetAccount(w http.ResponseWriter, r *http.Request) {
+	userID := 7
+
+	account, err := lh.loyaltyUseCase.GetAccount(int64(userID))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeLoyaltyJSON(w, http.StatusOK, account)
+}
+
+func (lh *LoyaltyHandler) GetTransactions(w http.ResponseWriter, r *http.Request) {
+	userID := 7
+
+	transactions, err := lh.loyaltyUseCase.GetTransactions(int64(userID))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeLoyaltyJSON(w, http.StatusOK, transactions)
+}
+
+func (lh *LoyaltyHandler) GetTiers(w http.ResponseWriter, r *http.Request) {
+	writeLoyaltyJSON(w, http.StatusOK, lh.loyaltyUseCase.GetTiers())
+}
+
+func writeLoyaltyJSON(w http.ResponseWriter, status int, value interface{}) {
+	response, err := json.Marshal(value)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(response)
+}
@@ -2,7 +2,9 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
 	"foodDelivery/domain"
+	"foodDelivery/repository"
 	"foodDelivery/usecase"
 	"github.com/gorilla/mux"
 	"net/http"
@@ -10,12 +12,14 @@ import (
 )
 
 type OrderHandler struct {
-	orderUseCase usecase.OrderUseCase
+	orderUseCase   usecase.OrderUseCase
+	reorderUseCase usecase.ReorderUseCase
 }
 
-func NewOrderHandler(orderUseCase usecase.OrderUseCase) *OrderHandler {
+func NewOrderHandler(orderUseCase usecase.OrderUseCase, reorderUseCase usecase.ReorderUseCase) *OrderHandler {
 	return &OrderHandler{
-		orderUseCase: orderUseCase,
+		orderUseCase:   orderUseCase,
+		reorderUseCase: reorderUseCase,
 	}
 }
 
@@ -26,9 +30,12 @@ func (oh *OrderHandler) SubmitOrder(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	userID := 7
+	order.UserID = int64(userID)
+
 	err = oh.orderUseCase.SubmitOrder(&order)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeOrderError(w, err)
 		return
 	}
 
@@ -38,6 +45,37 @@ func (oh *OrderHandler) SubmitOrder(w http.ResponseWriter, r *http.Request) {
 	_, _ = w.Write(response)
 }
 
+// QuoteOrder returns the price breakdown of an order without placing it.
+func (oh *OrderHandler) QuoteOrder(w http.ResponseWriter, r *http.Request) {
+	var order domain.Order
+	err := json.NewDecoder(r.Body).Decode(&order)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	userID := 7
+	order.UserID = int64(userID)
+
+	breakdown, err := oh.orderUseCase.QuoteOrder(&order)
+	if err != nil {
+		writeOrderError(w, err)
+		return
+	}
+
+	response, err := json.Marshal(map[string]interface{}{
+		"items":     order.Items,
+		"breakdown": breakdown,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(response)
+}
+
 func (oh *OrderHandler) GetUserOrders(w http.ResponseWriter, r *http.Request) {
 	userID := 7
 	orders, err := oh.orderUseCase.GetUserOrders(int64(userID))
@@ -59,11 +97,19 @@ func (oh *OrderHandler) GetUserOrders(w http.ResponseWriter, r *http.Request) {
 }
 
 func (oh *OrderHandler) GetOrderWithItems(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	orderIDStr := vars["id"]
-	orderID, _ := strconv.ParseInt(orderIDStr, 10, 64)
-	order, err := oh.orderUseCase.GetOrderWithItems(orderID)
+	orderID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
 	if err != nil {
+		http.Error(w, "Invalid order ID", http.StatusBadRequest)
+		return
+	}
+	userID := 7
+
+	order, err := oh.orderUseCase.GetOrderWithItems(int64(userID), orderID)
+	if err != nil {
+		if errors.Is(err, repository.ErrOrderNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -79,3 +125,155 @@ func (oh *OrderHandler) GetOrderWithItems(w http.ResponseWriter, r *http.Request
 	w.WriteHeader(http.StatusOK)
 	_, _ = w.Write(response)
 }
+
+func (oh *OrderHandler) Reorder(w http.ResponseWriter, r *http.Request) {
+	orderID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid order ID", http.StatusBadRequest)
+		return
+	}
+
+	var request domain.ReorderRequest
+	if r.ContentLength != 0 {
+		err = json.NewDecoder(r.Body).Decode(&request)
+		if err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+	userID := 7
+
+	result, err := oh.reorderUseCase.Reorder(int64(userID), orderID, &request)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrOrderNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, usecase.ErrInvalidReorderMode):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, usecase.ErrNothingToReorder), errors.Is(err, usecase.ErrCartNotEmpty):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			writeOrderError(w, err)
+		}
+		return
+	}
+
+	response, err := json.Marshal(result)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	status := http.StatusOK
+	if result.Order != nil {
+		status = http.StatusCreated
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(response)
+}
+
+// ModifyOrder changes the items or the delivery address of an order the supplier has not
+// accepted yet.
+func (oh *OrderHandler) ModifyOrder(w http.ResponseWriter, r *http.Request) {
+	orderID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid order ID", http.StatusBadRequest)
+		return
+	}
+
+	var modification domain.OrderModification
+	err = json.NewDecoder(r.Body).Decode(&modification)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	userID := 7
+
+	order, err := oh.orderUseCase.ModifyOrder(int64(userID), orderID, &modification)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrOrderNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, usecase.ErrOrderNotModifiable), errors.Is(err, repository.ErrInvalidStatusTransition),
+			errors.Is(err, repository.ErrOrderChanged):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, usecase.ErrNothingToModify), errors.Is(err, usecase.ErrAddressNotNeeded):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			writeOrderError(w, err)
+		}
+		return
+	}
+
+	response, err := json.Marshal(order)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(response)
+}
+
+func (oh *OrderHandler) GetOrderHistory(w http.ResponseWriter, r *http.Request) {
+	orderID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid order ID", http.StatusBadRequest)
+		return
+	}
+	userID := 7
+
+	history, err := oh.orderUseCase.GetOrderHistory(int64(userID), orderID)
+	if err != nil {
+		if errors.Is(err, repository.ErrOrderNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response, err := json.Marshal(history)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(response)
+}
+
+// writeOrderError maps the errors of placing an order to a response the app can show.
+func writeOrderError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrDeliveryTimeTooSoon), errors.Is(err, usecase.ErrDeliveryTimeTooFar),
+		errors.Is(err, usecase.ErrUnknownModifier), errors.Is(err, usecase.ErrModifierSelection),
+		errors.Is(err, usecase.ErrNoteTooLong), errors.Is(err, usecase.ErrInvalidQuantity),
+		errors.Is(err, usecase.ErrBelowMinimumOrder), errors.Is(err, usecase.ErrTooManyItems),
+		errors.Is(err, usecase.ErrFoodQuantityLimit), errors.Is(err, repository.ErrItemsNotFound),
+		errors.Is(err, usecase.ErrInvalidTip), errors.Is(err, usecase.ErrPaymentMethodRequired),
+		errors.Is(err, usecase.ErrInvalidWalletAmount), errors.Is(err, usecase.ErrUnknownCoupon),
+		errors.Is(err, repository.ErrCouponNotFound),
+		errors.Is(err, usecase.ErrCouponExpired), errors.Is(err, usecase.ErrCouponNotApplicable),
+		errors.Is(err, repository.ErrNotFirstOrder), errors.Is(err, usecase.ErrInvalidLoyaltyPoints),
+		errors.Is(err, usecase.ErrTooManyPoints), errors.Is(err, usecase.ErrInvalidSubstitution),
+		errors.Is(err, usecase.ErrInvalidFulfilmentType), errors.Is(err, usecase.ErrTableNumberRequired):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, usecase.ErrPaymentDeclined):
+		http.Error(w, err.Error(), http.StatusPaymentRequired)
+	case errors.Is(err, usecase.ErrPaymentFailed):
+		http.Error(w, err.Error(), http.StatusBadGateway)
+	case errors.Is(err, repository.ErrSupplierNotFound), errors.Is(err, usecase.ErrFoodNotFound),
+		errors.Is(err, repository.ErrAddressNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, usecase.ErrSupplierClosed), errors.Is(err, repository.ErrOutOfStock),
+		errors.Is(err, usecase.ErrAddressOutsideZone), errors.Is(err, repository.ErrInsufficientBalance),
+		errors.Is(err, repository.ErrCouponUsedUp), errors.Is(err, repository.ErrInsufficientPoints):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
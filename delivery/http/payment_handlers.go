@@ -0,0 +1,128 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"foodDelivery/domain"
+	"foodDelivery/payment"
+	"foodDelivery/repository"
+	"foodDelivery/usecase"
+	"github.com/gorilla/mux"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// maxWebhookSize bounds the body of a gateway callback.
+const maxWebhookSize = 1 << 20
+
+type PaymentHandler struct {
+	paymentUseCase usecase.PaymentUseCase
+}
+
+func NewPaymentHandler(paymentUseCase usecase.PaymentUseCase) *PaymentHandler {
+	return &PaymentHandler{
+		paymentUseCase: paymentUseCase,
+	}
+}
+
+// Webhook receives the signed callbacks of the payment gateway. Events already
+// processed are acknowledged again so the gateway stops retrying them.
+func (ph *PaymentHandler) Webhook(w http.ResponseWriter, r *http.Request) {
+	payload, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookSize))
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	err = ph.paymentUseCase.HandleWebhook(payload, r.Header.Get("X-Payment-Signature"))
+	if err != nil {
+		writePaymentError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (ph *PaymentHandler) GetOrderPayments(w http.ResponseWriter, r *http.Request) {
+	orderID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid order ID", http.StatusBadRequest)
+		return
+	}
+
+	payments, err := ph.paymentUseCase.GetOrderPayments(orderID)
+	if err != nil {
+		writePaymentError(w, err)
+		return
+	}
+
+	writePaymentJSON(w, payments)
+}
+
+func (ph *PaymentHandler) RefundPayment(w http.ResponseWriter, r *http.Request) {
+	paymentID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid payment ID", http.StatusBadRequest)
+		return
+	}
+	var request domain.RefundRequest
+	err = json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	record, err := ph.paymentUseCase.RefundPayment(paymentID, &request)
+	if err != nil {
+		writePaymentError(w, err)
+		return
+	}
+
+	writePaymentJSON(w, record)
+}
+
+func (ph *PaymentHandler) VoidPayment(w http.ResponseWriter, r *http.Request) {
+	paymentID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid payment ID", http.StatusBadRequest)
+		return
+	}
+
+	record, err := ph.paymentUseCase.VoidPayment(paymentID)
+	if err != nil {
+		writePaymentError(w, err)
+		return
+	}
+
+	writePaymentJSON(w, record)
+}
+
+func writePaymentJSON(w http.ResponseWriter, value interface{}) {
+	response, err := json.Marshal(value)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(response)
+}
+
+func writePaymentError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, payment.ErrInvalidSignature):
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+	case errors.Is(err, payment.ErrInvalidEvent), errors.Is(err, usecase.ErrInvalidRefund):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, repository.ErrPaymentNotFound), errors.Is(err, repository.ErrOrderNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, usecase.ErrPaymentNotVoidable):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, usecase.ErrPaymentFailed):
+		http.Error(w, err.Error(), http.StatusBadGateway)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,38 @@
+package http
+
+import (
+	"encoding/json"
+	"foodDelivery/usecase"
+	"net/http"
+)
+
+type ReferralHandler struct {
+	referralUseCase usecase.ReferralUseCase
+}
+
+func NewReferralHandler(referralUseCase usecase.ReferralUseCase) *ReferralHandler {
+	return &ReferralHandler{
+		referralUseCase: referralUseCase,
+	}
+}
+
+// GetReferrals returns the invite code of the signed in user and the friends they invited.
+func (rh *ReferralHandler) GetReferrals(w http.ResponseWriter, r *http.Request) {
+	userID := 7
+
+	summary, err := rh.referralUseCase.GetSummary(int64(userID))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response, err := json.Marshal(summary)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(response)
+}
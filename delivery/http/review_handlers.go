@@ -0,0 +1,148 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"foodDelivery/domain"
+	"foodDelivery/repository"
+	"foodDelivery/usecase"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strconv"
+)
+
+type ReviewHandler struct {
+	reviewUseCase usecase.ReviewUseCase
+}
+
+func NewReviewHandler(reviewUseCase usecase.ReviewUseCase) *ReviewHandler {
+	return &ReviewHandler{
+		reviewUseCase: reviewUseCase,
+	}
+}
+
+func (rh *ReviewHandler) CreateReview(w http.ResponseWriter, r *http.Request) {
+	orderID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid order ID", http.StatusBadRequest)
+		return
+	}
+	var review domain.Review
+	err = json.NewDecoder(r.Body).Decode(&review)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	userID := 7
+
+	err = rh.reviewUseCase.CreateReview(int64(userID), orderID, &review)
+	if err != nil {
+		writeReviewError(w, err)
+		return
+	}
+
+	writeReviewJSON(w, http.StatusCreated, review)
+}
+
+func (rh *ReviewHandler) GetOrderReview(w http.ResponseWriter, r *http.Request) {
+	orderID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid order ID", http.StatusBadRequest)
+		return
+	}
+
+	review, err := rh.reviewUseCase.GetOrderReview(orderID)
+	if err != nil {
+		writeReviewError(w, err)
+		return
+	}
+
+	writeReviewJSON(w, http.StatusOK, review)
+}
+
+func (rh *ReviewHandler) GetSupplierReviews(w http.ResponseWriter, r *http.Request) {
+	supplierID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid supplier ID", http.StatusBadRequest)
+		return
+	}
+
+	reviews, err := rh.reviewUseCase.GetSupplierReviews(supplierID)
+	if err != nil {
+		writeReviewError(w, err)
+		return
+	}
+
+	writeReviewJSON(w, http.StatusOK, reviews)
+}
+
+func (rh *ReviewHandler) GetFoodReviews(w http.ResponseWriter, r *http.Request) {
+	foodID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid food ID", http.StatusBadRequest)
+		return
+	}
+
+	reviews, err := rh.reviewUseCase.GetFoodReviews(foodID)
+	if err != nil {
+		writeReviewError(w, err)
+		return
+	}
+
+	writeReviewJSON(w, http.StatusOK, reviews)
+}
+
+// ReplyToReview posts the single public reply of a supplier to one of its reviews.
+func (rh *ReviewHandler) ReplyToReview(w http.ResponseWriter, r *http.Request) {
+	supplierID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid supplier ID", http.StatusBadRequest)
+		return
+	}
+	reviewID, err := strconv.ParseInt(mux.Vars(r)["review_id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid review ID", http.StatusBadRequest)
+		return
+	}
+	var reply domain.ReviewReply
+	err = json.NewDecoder(r.Body).Decode(&reply)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	err = rh.reviewUseCase.ReplyToReview(supplierID, reviewID, reply.Reply)
+	if err != nil {
+		writeReviewError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeReviewJSON(w http.ResponseWriter, status int, value interface{}) {
+	response, err := json.Marshal(value)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(response)
+}
+
+func writeReviewError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidRating), errors.Is(err, usecase.ErrInvalidFoodReview),
+		errors.Is(err, usecase.ErrCommentTooLong), errors.Is(err, usecase.ErrInvalidReply):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, repository.ErrOrderNotFound), errors.Is(err, repository.ErrReviewNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, usecase.ErrOrderNotDelivered), errors.Is(err, repository.ErrReviewExists),
+		errors.Is(err, repository.ErrReplyExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,262 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"foodDelivery/domain"
+	"foodDelivery/repository"
+	"foodDelivery/usecase"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strconv"
+)
+
+type SettlementHandler struct {
+	settlementUseCase usecase.SettlementUseCase
+}
+
+func NewSettlementHandler(settlementUseCase usecase.SettlementUseCase) *SettlementHandler {
+	return &SettlementHandler{
+		settlementUseCase: settlementUseCase,
+	}
+}
+
+func (sh *SettlementHandler) GetCommissionRates(w http.ResponseWriter, r *http.Request) {
+	supplierID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid supplier ID", http.StatusBadRequest)
+		return
+	}
+
+	rates, err := sh.settlementUseCase.GetCommissionRates(supplierID)
+	if err != nil {
+		writeSettlementError(w, err)
+		return
+	}
+
+	writeSettlementJSON(w, http.StatusOK, rates)
+}
+
+func (sh *SettlementHandler) CreateCommissionRate(w http.ResponseWriter, r *http.Request) {
+	supplierID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid supplier ID", http.StatusBadRequest)
+		return
+	}
+	var rate domain.CommissionRate
+	err = json.NewDecoder(r.Body).Decode(&rate)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	rate.SupplierID = supplierID
+
+	err = sh.settlementUseCase.CreateCommissionRate(&rate)
+	if err != nil {
+		writeSettlementError(w, err)
+		return
+	}
+
+	writeSettlementJSON(w, http.StatusCreated, rate)
+}
+
+func (sh *SettlementHandler) DeleteCommissionRate(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	supplierID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid supplier ID", http.StatusBadRequest)
+		return
+	}
+	rateID, err := strconv.ParseInt(vars["rate_id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid commission rate ID", http.StatusBadRequest)
+		return
+	}
+
+	err = sh.settlementUseCase.DeleteCommissionRate(supplierID, rateID)
+	if err != nil {
+		writeSettlementError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	response := []byte(`{"message": "Commission rate deleted successfully"}`)
+	_, _ = w.Write(response)
+}
+
+func (sh *SettlementHandler) GetAdjustments(w http.ResponseWriter, r *http.Request) {
+	supplierID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid supplier ID", http.StatusBadRequest)
+		return
+	}
+
+	adjustments, err := sh.settlementUseCase.GetAdjustments(supplierID)
+	if err != nil {
+		writeSettlementError(w, err)
+		return
+	}
+
+	writeSettlementJSON(w, http.StatusOK, adjustments)
+}
+
+func (sh *SettlementHandler) CreateAdjustment(w http.ResponseWriter, r *http.Request) {
+	supplierID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid supplier ID", http.StatusBadRequest)
+		return
+	}
+	var adjustment domain.SettlementAdjustment
+	err = json.NewDecoder(r.Body).Decode(&adjustment)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	adjustment.SupplierID = supplierID
+
+	err = sh.settlementUseCase.CreateAdjustment(&adjustment)
+	if err != nil {
+		writeSettlementError(w, err)
+		return
+	}
+
+	writeSettlementJSON(w, http.StatusCreated, adjustment)
+}
+
+// GenerateSettlements settles every supplier for the days from and to, both included.
+func (sh *SettlementHandler) GenerateSettlements(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parsePeriod(r)
+	if err != nil {
+		http.Error(w, "Invalid period, use from and to as YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+
+	settlements, err := sh.settlementUseCase.GenerateSettlements(from, to)
+	if err != nil {
+		writeSettlementError(w, err)
+		return
+	}
+
+	writeSettlementJSON(w, http.StatusCreated, settlements)
+}
+
+func (sh *SettlementHandler) GetSettlements(w http.ResponseWriter, r *http.Request) {
+	var supplierID int64
+	if value := r.URL.Query().Get("supplier_id"); value != "" {
+		var err error
+		supplierID, err = strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid supplier ID", http.StatusBadRequest)
+			return
+		}
+	}
+
+	settlements, err := sh.settlementUseCase.GetSettlements(supplierID, r.URL.Query().Get("status"))
+	if err != nil {
+		writeSettlementError(w, err)
+		return
+	}
+
+	writeSettlementJSON(w, http.StatusOK, settlements)
+}
+
+func (sh *SettlementHandler) GetSupplierSettlements(w http.ResponseWriter, r *http.Request) {
+	supplierID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid supplier ID", http.StatusBadRequest)
+		return
+	}
+
+	settlements, err := sh.settlementUseCase.GetSettlements(supplierID, r.URL.Query().Get("status"))
+	if err != nil {
+		writeSettlementError(w, err)
+		return
+	}
+
+	writeSettlementJSON(w, http.StatusOK, settlements)
+}
+
+// GetSettlement returns the statement of a settlement as JSON, or as a file with
+// ?format=csv or ?format=pdf.
+func (sh *SettlementHandler) GetSettlement(w http.ResponseWriter, r *http.Request) {
+	settlementID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid settlement ID", http.StatusBadRequest)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" {
+		content, contentType, err := sh.settlementUseCase.RenderStatement(settlementID, format)
+		if err != nil {
+			writeSettlementError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="settlement-%d.%s"`, settlementID, format))
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(content)
+		return
+	}
+
+	settlement, err := sh.settlementUseCase.GetSettlement(settlementID)
+	if err != nil {
+		writeSettlementError(w, err)
+		return
+	}
+
+	writeSettlementJSON(w, http.StatusOK, settlement)
+}
+
+func (sh *SettlementHandler) UpdatePayout(w http.ResponseWriter, r *http.Request) {
+	settlementID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid settlement ID", http.StatusBadRequest)
+		return
+	}
+	var update domain.PayoutUpdate
+	err = json.NewDecoder(r.Body).Decode(&update)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	settlement, err := sh.settlementUseCase.UpdatePayout(settlementID, &update)
+	if err != nil {
+		writeSettlementError(w, err)
+		return
+	}
+
+	writeSettlementJSON(w, http.StatusOK, settlement)
+}
+
+func writeSettlementJSON(w http.ResponseWriter, status int, value interface{}) {
+	response, err := json.Marshal(value)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(response)
+}
+
+func writeSettlementError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidCommissionRate), errors.Is(err, usecase.ErrInvalidAdjustment),
+		errors.Is(err, usecase.ErrInvalidPayoutStatus), errors.Is(err, usecase.ErrInvalidPeriod),
+		errors.Is(err, usecase.ErrInvalidStatementFormat):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, repository.ErrSupplierNotFound), errors.Is(err, repository.ErrCommissionRateNotFound),
+		errors.Is(err, repository.ErrSettlementNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, repository.ErrCommissionRateOverlap), errors.Is(err, repository.ErrPayoutTransition),
+		errors.Is(err, repository.ErrAlreadySettled):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
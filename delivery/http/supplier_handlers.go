@@ -2,7 +2,9 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
 	"foodDelivery/domain"
+	"foodDelivery/repository"
 	"foodDelivery/usecase"
 	"github.com/gorilla/mux"
 	"net/http"
@@ -13,14 +15,16 @@ type SupplierHandler struct {
 	supplierUseCase usecase.SupplierUseCase
 	categoryUseCase usecase.CategoryUseCase
 	foodUseCase     usecase.FoodUseCase
+	orderUseCase    usecase.OrderUseCase
 }
 
 func NewSupplierHandler(supplierUseCase usecase.SupplierUseCase, categoryUseCase usecase.CategoryUseCase,
-	foodUseCase usecase.FoodUseCase) *SupplierHandler {
+	foodUseCase usecase.FoodUseCase, orderUseCase usecase.OrderUseCase) *SupplierHandler {
 	return &SupplierHandler{
 		supplierUseCase: supplierUseCase,
 		categoryUseCase: categoryUseCase,
 		foodUseCase:     foodUseCase,
+		orderUseCase:    orderUseCase,
 	}
 }
 
@@ -32,6 +36,10 @@ func (sh *SupplierHandler) GetSupplierByID(w http.ResponseWriter, r *http.Reques
 	supplier, err := sh.supplierUseCase.GetSupplierById(SupplierID)
 
 	if err != nil {
+		if errors.Is(err, repository.ErrSupplierNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -52,6 +60,10 @@ func (sh *SupplierHandler) CreateSupplier(w http.ResponseWriter, r *http.Request
 
 	err = sh.supplierUseCase.CreateSupplier(&supplier)
 	if err != nil {
+		if isSupplierValidationError(err) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -77,6 +89,10 @@ func (sh *SupplierHandler) UpdateSupplier(w http.ResponseWriter, r *http.Request
 	err = sh.supplierUseCase.UpdateSupplier(&supplier)
 
 	if err != nil {
+		if isSupplierValidationError(err) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -105,8 +121,34 @@ func (sh *SupplierHandler) DeleteSupplier(w http.ResponseWriter, r *http.Request
 }
 
 func (sh *SupplierHandler) GetAllSuppliers(w http.ResponseWriter, r *http.Request) {
-	suppliers, err := sh.supplierUseCase.GetAllSuppliers()
+	var filter domain.SupplierFilter
+	if openNow := r.URL.Query().Get("open_now"); openNow != "" {
+		value, err := strconv.ParseBool(openNow)
+		if err != nil {
+			http.Error(w, "Invalid open_now value", http.StatusBadRequest)
+			return
+		}
+		filter.OpenNow = value
+	}
+	if addressID := r.URL.Query().Get("address_id"); addressID != "" {
+		value, err := strconv.ParseInt(addressID, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid address_id value", http.StatusBadRequest)
+			return
+		}
+		filter.AddressID = value
+	}
+	filter.Sort = r.URL.Query().Get("sort")
+
+	suppliers, err := sh.supplierUseCase.GetAllSuppliers(filter)
 	if err != nil {
+		if errors.Is(err, repository.ErrAddressNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		} else if errors.Is(err, usecase.ErrInvalidSort) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -123,6 +165,65 @@ func (sh *SupplierHandler) GetAllSuppliers(w http.ResponseWriter, r *http.Reques
 	_, _ = w.Write(response)
 }
 
+// GetNearbySuppliers handles GET /api/suppliers/nearby?lat=&lng=&radius=&page=&limit=.
+func (sh *SupplierHandler) GetNearbySuppliers(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	var query domain.NearbyQuery
+	var err error
+
+	query.Point.Lat, err = strconv.ParseFloat(params.Get("lat"), 64)
+	if err != nil {
+		http.Error(w, "Invalid lat value", http.StatusBadRequest)
+		return
+	}
+	query.Point.Lng, err = strconv.ParseFloat(params.Get("lng"), 64)
+	if err != nil {
+		http.Error(w, "Invalid lng value", http.StatusBadRequest)
+		return
+	}
+	if radius := params.Get("radius"); radius != "" {
+		query.RadiusKm, err = strconv.ParseFloat(radius, 64)
+		if err != nil {
+			http.Error(w, "Invalid radius value", http.StatusBadRequest)
+			return
+		}
+	}
+	if page := params.Get("page"); page != "" {
+		query.Page, err = strconv.Atoi(page)
+		if err != nil {
+			http.Error(w, "Invalid page value", http.StatusBadRequest)
+			return
+		}
+	}
+	if limit := params.Get("limit"); limit != "" {
+		query.Limit, err = strconv.Atoi(limit)
+		if err != nil {
+			http.Error(w, "Invalid limit value", http.StatusBadRequest)
+			return
+		}
+	}
+
+	page, err := sh.supplierUseCase.GetNearbySuppliers(query)
+	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidLocation) || errors.Is(err, usecase.ErrInvalidNearbyQuery) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response, err := json.Marshal(page)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(response)
+}
+
 func (sh *SupplierHandler) GetSupplierCategories(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	supplierIDStr := vars["id"]
@@ -166,8 +267,12 @@ func (sh *SupplierHandler) GetFoodsByCategoryAndSupplier(w http.ResponseWriter,
 		return
 	}
 
-	foods, err := sh.foodUseCase.GetFoodsByCategoryAndSupplier(categoryID, supplierID)
+	foods, err := sh.foodUseCase.GetFoodsByCategoryAndSupplier(categoryID, supplierID, r.URL.Query().Get("sort"))
 	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidSort) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -183,3 +288,124 @@ func (sh *SupplierHandler) GetFoodsByCategoryAndSupplier(w http.ResponseWriter,
 	w.WriteHeader(http.StatusOK)
 	_, _ = w.Write(response)
 }
+
+// GetSupplierOrders lists the orders of a supplier with their lines, chosen modifiers and notes.
+func (sh *SupplierHandler) GetSupplierOrders(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	supplierID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid supplier ID", http.StatusBadRequest)
+		return
+	}
+
+	orders, err := sh.orderUseCase.GetSupplierOrders(supplierID, r.URL.Query().Get("status"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response, err := json.Marshal(orders)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(response)
+}
+
+func (sh *SupplierHandler) GetSupplierHolidays(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	supplierID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid supplier ID", http.StatusBadRequest)
+		return
+	}
+
+	holidays, err := sh.supplierUseCase.GetSupplierHolidays(supplierID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response, err := json.Marshal(holidays)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(response)
+}
+
+func (sh *SupplierHandler) CreateSupplierHoliday(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	supplierID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid supplier ID", http.StatusBadRequest)
+		return
+	}
+
+	var holiday domain.Holiday
+	err = json.NewDecoder(r.Body).Decode(&holiday)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	holiday.SupplierID = supplierID
+
+	err = sh.supplierUseCase.CreateSupplierHoliday(&holiday)
+	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidHolidayDate) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		} else if errors.Is(err, repository.ErrSupplierNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response, _ := json.Marshal(holiday)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_, _ = w.Write(response)
+}
+
+func (sh *SupplierHandler) DeleteSupplierHoliday(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	supplierID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid supplier ID", http.StatusBadRequest)
+		return
+	}
+	holidayID, err := strconv.ParseInt(vars["holiday_id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid holiday ID", http.StatusBadRequest)
+		return
+	}
+
+	err = sh.supplierUseCase.DeleteSupplierHoliday(supplierID, holidayID)
+	if err != nil {
+		if errors.Is(err, repository.ErrHolidayNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	response := []byte(`{"message": "Holiday deleted successfully"}`)
+	_, _ = w.Write(response)
+}
+
+func isSupplierValidationError(err error) bool {
+	return errors.Is(err, usecase.ErrInvalidOpeningHours) || errors.Is(err, usecase.ErrInvalidTimeZone) ||
+		errors.Is(err, usecase.ErrInvalidDeliveryFee) || errors.Is(err, usecase.ErrInvalidOrderRules) ||
+		errors.Is(err, usecase.ErrInvalidDeliveryZone) || errors.Is(err, usecase.ErrInvalidLocation)
+}
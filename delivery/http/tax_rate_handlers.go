@@ -0,0 +1,117 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"foodDelivery/domain"
+	"foodDelivery/repository"
+	"foodDelivery/usecase"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strconv"
+)
+
+type TaxRateHandler struct {
+	taxRateUseCase usecase.TaxRateUseCase
+}
+
+func NewTaxRateHandler(taxRateUseCase usecase.TaxRateUseCase) *TaxRateHandler {
+	return &TaxRateHandler{
+		taxRateUseCase: taxRateUseCase,
+	}
+}
+
+func (th *TaxRateHandler) GetAllTaxRates(w http.ResponseWriter, r *http.Request) {
+	taxRates, err := th.taxRateUseCase.GetAllTaxRates()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response, err := json.Marshal(taxRates)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(response)
+}
+
+func (th *TaxRateHandler) CreateTaxRate(w http.ResponseWriter, r *http.Request) {
+	var taxRate domain.TaxRate
+	err := json.NewDecoder(r.Body).Decode(&taxRate)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = th.taxRateUseCase.CreateTaxRate(&taxRate)
+	if err != nil {
+		writeTaxRateError(w, err)
+		return
+	}
+
+	response, _ := json.Marshal(taxRate)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_, _ = w.Write(response)
+}
+
+func (th *TaxRateHandler) UpdateTaxRate(w http.ResponseWriter, r *http.Request) {
+	taxRateID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid tax rate ID", http.StatusBadRequest)
+		return
+	}
+
+	var taxRate domain.TaxRate
+	err = json.NewDecoder(r.Body).Decode(&taxRate)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	taxRate.ID = taxRateID
+
+	err = th.taxRateUseCase.UpdateTaxRate(&taxRate)
+	if err != nil {
+		writeTaxRateError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	response := []byte(`{"message": "Tax rate updated successfully"}`)
+	_, _ = w.Write(response)
+}
+
+func (th *TaxRateHandler) DeleteTaxRate(w http.ResponseWriter, r *http.Request) {
+	taxRateID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid tax rate ID", http.StatusBadRequest)
+		return
+	}
+
+	err = th.taxRateUseCase.DeleteTaxRate(taxRateID)
+	if err != nil {
+		writeTaxRateError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	response := []byte(`{"message": "Tax rate deleted successfully"}`)
+	_, _ = w.Write(response)
+}
+
+func writeTaxRateError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidTaxRate):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, repository.ErrTaxRateNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
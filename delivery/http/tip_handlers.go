@@ -0,0 +1,137 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"foodDelivery/domain"
+	"foodDelivery/repository"
+	"foodDelivery/usecase"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type TipHandler struct {
+	tipUseCase usecase.TipUseCase
+}
+
+func NewTipHandler(tipUseCase usecase.TipUseCase) *TipHandler {
+	return &TipHandler{
+		tipUseCase: tipUseCase,
+	}
+}
+
+// AddTips tips a delivered order of the signed in user.
+func (th *TipHandler) AddTips(w http.ResponseWriter, r *http.Request) {
+	orderID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid order ID", http.StatusBadRequest)
+		return
+	}
+	var request domain.TipRequest
+	err = json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	userID := 7
+
+	order, err := th.tipUseCase.AddTips(int64(userID), orderID, request.Tips)
+	if err != nil {
+		writeTipError(w, err)
+		return
+	}
+
+	writeTipJSON(w, order)
+}
+
+// GetCourierEarnings reports the earnings of the signed in courier.
+func (th *TipHandler) GetCourierEarnings(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parsePeriod(r)
+	if err != nil {
+		http.Error(w, "Invalid period, use from and to as YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+	userID := 7
+
+	earnings, err := th.tipUseCase.GetCourierEarnings(int64(userID), from, to)
+	if err != nil {
+		writeTipError(w, err)
+		return
+	}
+
+	writeTipJSON(w, earnings)
+}
+
+func (th *TipHandler) GetSupplierEarnings(w http.ResponseWriter, r *http.Request) {
+	supplierID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid supplier ID", http.StatusBadRequest)
+		return
+	}
+	from, to, err := parsePeriod(r)
+	if err != nil {
+		http.Error(w, "Invalid period, use from and to as YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+
+	earnings, err := th.tipUseCase.GetSupplierEarnings(supplierID, from, to)
+	if err != nil {
+		writeTipError(w, err)
+		return
+	}
+
+	writeTipJSON(w, earnings)
+}
+
+// parsePeriod reads the optional from and to days of a report, both included.
+func parsePeriod(r *http.Request) (time.Time, time.Time, error) {
+	var from, to time.Time
+	var err error
+	if value := r.URL.Query().Get("from"); value != "" {
+		from, err = time.Parse("2006-01-02", value)
+		if err != nil {
+			return from, to, err
+		}
+	}
+	if value := r.URL.Query().Get("to"); value != "" {
+		to, err = time.Parse("2006-01-02", value)
+		if err != nil {
+			return from, to, err
+		}
+		to = to.AddDate(0, 0, 1)
+	}
+	return from, to, nil
+}
+
+func writeTipJSON(w http.ResponseWriter, value interface{}) {
+	response, err := json.Marshal(value)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(response)
+}
+
+func writeTipError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidTip), errors.Is(err, usecase.ErrInvalidPeriod):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, repository.ErrOrderNotFound), errors.Is(err, repository.ErrCourierNotFound),
+		errors.Is(err, repository.ErrSupplierNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, usecase.ErrTipNotAllowed), errors.Is(err, repository.ErrTipExists),
+		errors.Is(err, repository.ErrPaymentNotFound), errors.Is(err, repository.ErrInsufficientBalance):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, usecase.ErrPaymentDeclined):
+		http.Error(w, err.Error(), http.StatusPaymentRequired)
+	case errors.Is(err, usecase.ErrPaymentFailed):
+		http.Error(w, err.Error(), http.StatusBadGateway)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,78 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"foodDelivery/domain"
+	"foodDelivery/repository"
+	"foodDelivery/usecase"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strconv"
+)
+
+type TrackingHandler struct {
+	trackingUseCase usecase.TrackingUseCase
+}
+
+func NewTrackingHandler(trackingUseCase usecase.TrackingUseCase) *TrackingHandler {
+	return &TrackingHandler{
+		trackingUseCase: trackingUseCase,
+	}
+}
+
+// RecordLocation handles the GPS pings of the signed in courier.
+func (th *TrackingHandler) RecordLocation(w http.ResponseWriter, r *http.Request) {
+	var ping domain.LocationPing
+	err := json.NewDecoder(r.Body).Decode(&ping)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	userID := 7
+
+	err = th.trackingUseCase.RecordLocation(int64(userID), &ping)
+	if err != nil {
+		switch {
+		case errors.Is(err, usecase.ErrInvalidLocation), errors.Is(err, usecase.ErrPingTooOld):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, repository.ErrCourierNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, usecase.ErrCourierInactive):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (th *TrackingHandler) GetOrderTracking(w http.ResponseWriter, r *http.Request) {
+	orderID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid order ID", http.StatusBadRequest)
+		return
+	}
+
+	tracking, err := th.trackingUseCase.GetOrderTracking(orderID)
+	if err != nil {
+		if errors.Is(err, repository.ErrOrderNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response, err := json.Marshal(tracking)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(response)
+}
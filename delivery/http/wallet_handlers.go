@@ -0,0 +1,114 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"foodDelivery/domain"
+	"foodDelivery/repository"
+	"foodDelivery/usecase"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strconv"
+)
+
+type WalletHandler struct {
+	walletUseCase usecase.WalletUseCase
+}
+
+func NewWalletHandler(walletUseCase usecase.WalletUseCase) *WalletHandler {
+	return &WalletHandler{
+		walletUseCase: walletUseCase,
+	}
+}
+
+func (wh *WalletHandler) GetWallet(w http.ResponseWriter, r *http.Request) {
+	userID := 7
+
+	wallet, err := wh.walletUseCase.GetWallet(int64(userID))
+	if err != nil {
+		writeWalletError(w, err)
+		return
+	}
+
+	writeWalletJSON(w, http.StatusOK, wallet)
+}
+
+func (wh *WalletHandler) GetTransactions(w http.ResponseWriter, r *http.Request) {
+	userID := 7
+
+	transactions, err := wh.walletUseCase.GetTransactions(int64(userID))
+	if err != nil {
+		writeWalletError(w, err)
+		return
+	}
+
+	writeWalletJSON(w, http.StatusOK, transactions)
+}
+
+func (wh *WalletHandler) TopUp(w http.ResponseWriter, r *http.Request) {
+	var request domain.TopUpRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	userID := 7
+
+	transaction, err := wh.walletUseCase.TopUp(int64(userID), &request)
+	if err != nil {
+		writeWalletError(w, err)
+		return
+	}
+
+	writeWalletJSON(w, http.StatusCreated, transaction)
+}
+
+// CreditWallet lets support give a user a refund or goodwill credit.
+func (wh *WalletHandler) CreditWallet(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+	var credit domain.WalletCredit
+	err = json.NewDecoder(r.Body).Decode(&credit)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	transaction, err := wh.walletUseCase.CreditWallet(userID, &credit)
+	if err != nil {
+		writeWalletError(w, err)
+		return
+	}
+
+	writeWalletJSON(w, http.StatusCreated, transaction)
+}
+
+func writeWalletJSON(w http.ResponseWriter, status int, value interface{}) {
+	response, err := json.Marshal(value)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(response)
+}
+
+func writeWalletError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidTopUp), errors.Is(err, usecase.ErrInvalidWalletCredit):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, usecase.ErrPaymentDeclined):
+		http.Error(w, err.Error(), http.StatusPaymentRequired)
+	case errors.Is(err, repository.ErrUserNotFound), errors.Is(err, repository.ErrOrderNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, usecase.ErrPaymentFailed):
+		http.Error(w, err.Error(), http.StatusBadGateway)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
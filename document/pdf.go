@@ -0,0 +1,148 @@
+// Package document renders the printable documents of the platform, like settlement
+// statements, without any external dependency.
+package document
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+const (
+	pageWidth  = 595.0
+	pageHeight = 842.0
+	margin     = 50.0
+)
+
+// Fonts of the standard PDF set that every reader has.
+const (
+	FontRegular = "F1"
+	FontBold    = "F2"
+	FontMono    = "F3"
+)
+
+var fontNames = map[string]string{
+	FontRegular: "Helvetica",
+	FontBold:    "Helvetica-Bold",
+	FontMono:    "Courier",
+}
+
+// PDF builds a text document of A4 pages, adding a page whenever the current one is full.
+// Text is encoded in WinAnsi, characters outside of it print as '?'.
+type PDF struct {
+	pages [][]byte
+	page  *bytes.Buffer
+	y     float64
+}
+
+func NewPDF() *PDF {
+	pdf := &PDF{}
+	pdf.newPage()
+	return pdf
+}
+
+// Title writes a large bold line.
+func (p *PDF) Title(text string) {
+	p.write(FontBold, 16, text)
+}
+
+// Heading writes a bold line.
+func (p *PDF) Heading(text string) {
+	p.write(FontBold, 11, text)
+}
+
+// Text writes a regular line.
+func (p *PDF) Text(text string) {
+	p.write(FontRegular, 10, text)
+}
+
+// Mono writes a line in a fixed width font, for columns aligned with spaces.
+func (p *PDF) Mono(text string) {
+	p.write(FontMono, 9, text)
+}
+
+// Space leaves an empty line.
+func (p *PDF) Space() {
+	p.advance(10)
+}
+
+// Bytes returns the finished document.
+func (p *PDF) Bytes() []byte {
+	p.pages = append(p.pages, p.page.Bytes())
+	p.page = nil
+
+	var out bytes.Buffer
+	offsets := make([]int, 0)
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n")
+	// Objects 1 and 2 are the catalog and the page tree, 3 to 5 the fonts and every page
+	// takes two more objects for itself and its content.
+	firstPage := 6
+	kids := make([]string, len(p.pages))
+	for i := range p.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+i*2)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(p.pages)))
+	for _, font := range []string{FontRegular, FontBold, FontMono} {
+		object(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", fontNames[font]))
+	}
+	for i, content := range p.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] "+
+			"/Resources << /Font << /F1 3 0 R /F2 4 0 R /F3 5 0 R >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, firstPage+i*2+1))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", len(content), content))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return out.Bytes()
+}
+
+func (p *PDF) write(font string, size float64, text string) {
+	p.advance(size * 1.4)
+	fmt.Fprintf(p.page, "BT /%s %.0f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, margin, p.y, escape(text))
+}
+
+// advance moves down by the height of a line, starting a new page when it does not fit.
+func (p *PDF) advance(height float64) {
+	if p.y-height < margin {
+		p.pages = append(p.pages, p.page.Bytes())
+		p.newPage()
+	}
+	p.y -= height
+}
+
+func (p *PDF) newPage() {
+	p.page = &bytes.Buffer{}
+	p.y = pageHeight - margin
+}
+
+// escape encodes text as a PDF string in WinAnsi.
+func escape(text string) string {
+	var out strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			out.WriteByte('\\')
+			out.WriteRune(r)
+		case r >= 32 && r < 127:
+			out.WriteRune(r)
+		case r >= 160 && r < 256:
+			fmt.Fprintf(&out, "\\%03o", r)
+		case r == '€':
+			out.WriteString("\\200")
+		default:
+			out.WriteByte('?')
+		}
+	}
+	return out.String()
+}
@@ -1,24 +1,79 @@
 package domain
 
+import "time"
+
+// An order goes awaiting_payment -> scheduled -> pending -> ready -> assigned -> accepted -> picked_up
+// -> delivered. Once the payment is authorized, immediate orders skip scheduled. A courier declining
+// an assigned order puts it back to ready. Orders whose payment fails or is voided stop there.
+// The supplier may confirm a pending order, without the items it cannot make, before it is ready.
+// Pickup and dine-in orders have no courier: they go from ready to delivered when the supplier
+// hands them over at the counter or serves them at the table.
+const (
+	OrderStatusAwaitingPayment = "awaiting_payment"
+	OrderStatusPaymentFailed   = "payment_failed"
+	OrderStatusCancelled       = "cancelled"
+	OrderStatusScheduled       = "scheduled"
+	OrderStatusPending         = "pending"
+	OrderStatusReady           = "ready"
+	OrderStatusAssigned        = "assigned"
+	OrderStatusAccepted        = "accepted"
+	OrderStatusPickedUp        = "picked_up"
+	OrderStatusDelivered       = "delivered"
+)
+
+// Delivery orders are brought to the address of the order. Pickup orders are collected at
+// the supplier with the pickup code, dine-in orders are served at a table and need no address.
+const (
+	FulfilmentTypeDelivery = "delivery"
+	FulfilmentTypePickup   = "pickup"
+	FulfilmentTypeDineIn   = "dine_in"
+)
+
 type Order struct {
-	ID           int64        `json:"ID"`
-	UserID       int64        `json:"user_id"`
-	UserName     string       `json:"user_name"`
-	SupplierID   int64        `json:"supplier_id"`
-	SupplierName string       `json:"supplier_name"`
-	AddressID    int64        `json:"address_id"`
-	TrackingID   string       `json:"tracking_id"`
-	Status       string       `json:"status"`
-	Price        float32      `json:"price"`
-	CreatedAT    string       `json:"created_at"`
-	Items        *[]OrderItem `json:"items"`
+	ID                     int64               `json:"ID"`
+	UserID                 int64               `json:"user_id"`
+	UserName               string              `json:"user_name"`
+	SupplierID             int64               `json:"supplier_id"`
+	SupplierName           string              `json:"supplier_name"`
+	FulfilmentType         string              `json:"fulfilment_type"`
+	AddressID              int64               `json:"address_id"`
+	TableNumber            string              `json:"table_number,omitempty"`
+	TrackingID             string              `json:"tracking_id"`
+	Status                 string              `json:"status"`
+	Price                  float32             `json:"price"`
+	WalletAmount           float32             `json:"wallet_amount"`
+	LoyaltyPoints          int                 `json:"loyalty_points"`
+	Breakdown              *PriceBreakdown     `json:"breakdown"`
+	CreatedAT              string              `json:"created_at"`
+	RequestedDeliveryAt    *time.Time          `json:"requested_delivery_at"`
+	ReleaseAt              *time.Time          `json:"release_at"`
+	CourierID              int64               `json:"courier_id"`
+	AssignedAt             *time.Time          `json:"assigned_at"`
+	PickedUpAt             *time.Time          `json:"picked_up_at"`
+	ReadyAt                *time.Time          `json:"ready_at"`
+	DeliveredAt            *time.Time          `json:"delivered_at"`
+	DeliveryPin            string              `json:"delivery_pin,omitempty"`
+	DeliveryPinAttempts    int                 `json:"-"`
+	PickupCode             string              `json:"pickup_code,omitempty"`
+	DeliveryProof          *DeliveryProof      `json:"delivery_proof,omitempty"`
+	Tips                   []*Tip              `json:"tips"`
+	PaymentMethod          string              `json:"payment_method,omitempty"`
+	CouponCode             string              `json:"coupon_code,omitempty"`
+	CouponDiscount         float32             `json:"coupon_discount,omitempty"`
+	Coupon                 *Coupon             `json:"-"`
+	SubstitutionPreference string              `json:"substitution_preference"`
+	ConfirmedAt            *time.Time          `json:"confirmed_at"`
+	FulfilmentChanges      []*FulfilmentChange `json:"fulfilment_changes,omitempty"`
+	Items                  *[]OrderItem        `json:"items"`
 }
 
 type OrderItem struct {
-	ID          int64   `json:"id"`
-	OrderID     int64   `json:"order_id"`
-	FoodID      int64   `json:"food_id"`
-	FoodName    string  `json:"food_name"`
-	Quantity    int8    `json:"quantity"`
-	SinglePrice float32 `json:"single_price"`
+	ID          int64                `json:"id"`
+	OrderID     int64                `json:"order_id"`
+	FoodID      int64                `json:"food_id"`
+	FoodName    string               `json:"food_name"`
+	Quantity    int8                 `json:"quantity"`
+	SinglePrice float32              `json:"single_price"`
+	Note        string               `json:"note"`
+	Modifiers   []*OrderItemModifier `json:"modifiers"`
 }
@@ -1,10 +1,14 @@
 package domain
 
 type Address struct {
-	ID      int64  `json:"id"`
-	UserID  int64  `json:"user_id"`
-	Name    string `json:"name"`
-	Zip     string `json:"zip"`
-	Phone   string `json:"phone"`
-	Address string `json:"address"`
+	ID       int64     `json:"id"`
+	UserID   int64     `json:"user_id"`
+	Name     string    `json:"name"`
+	Zip      string    `json:"zip"`
+	Phone    string    `json:"phone"`
+	Address  string    `json:"address"`
+	Street   string    `json:"street"`
+	City     string    `json:"city"`
+	Country  string    `json:"country"`
+	Location *GeoPoint `json:"location"`
 }
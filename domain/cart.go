@@ -0,0 +1,42 @@
+package domain
+
+import "time"
+
+type Cart struct {
+	ID         int64       `json:"id"`
+	UserID     int64       `json:"user_id"`
+	SupplierID int64       `json:"supplier_id"`
+	Items      []*CartItem `json:"items"`
+	Subtotal   float32     `json:"subtotal"`
+	UpdatedAt  string      `json:"updated_at"`
+}
+
+// CartItem keeps the price the food had when it was added, modifiers included. Available,
+// CurrentPrice and Warning are filled when the cart is revalidated on read.
+type CartItem struct {
+	ID           int64                `json:"id"`
+	CartID       int64                `json:"cart_id"`
+	FoodID       int64                `json:"food_id"`
+	FoodName     string               `json:"food_name"`
+	Quantity     int8                 `json:"quantity"`
+	UnitPrice    float32              `json:"unit_price"`
+	CurrentPrice float32              `json:"current_price"`
+	Available    bool                 `json:"available"`
+	Warning      string               `json:"warning,omitempty"`
+	Note         string               `json:"note"`
+	Modifiers    []*OrderItemModifier `json:"modifiers"`
+}
+
+// Checkout holds what is needed on top of the cart to place an order.
+type Checkout struct {
+	FulfilmentType         string     `json:"fulfilment_type"`
+	AddressID              int64      `json:"address_id"`
+	TableNumber            string     `json:"table_number"`
+	RequestedDeliveryAt    *time.Time `json:"requested_delivery_at"`
+	Tips                   []*Tip     `json:"tips"`
+	PaymentMethod          string     `json:"payment_method"`
+	WalletAmount           float32    `json:"wallet_amount"`
+	LoyaltyPoints          int        `json:"loyalty_points"`
+	CouponCode             string     `json:"coupon_code"`
+	SubstitutionPreference string     `json:"substitution_preference"`
+}
@@ -0,0 +1,39 @@
+package domain
+
+import "time"
+
+const (
+	CouponTypePercentage = "percentage"
+	CouponTypeFixed      = "fixed"
+)
+
+// Coupon is a promo code. Empty supplier or category lists mean every supplier or
+// category, a zero limit means no limit and a missing date an open validity window.
+// With categories, the discount only applies to the lines of those categories. A coupon
+// with a user can only be used by that user.
+type Coupon struct {
+	ID             int64      `json:"id"`
+	Code           string     `json:"code"`
+	Description    string     `json:"description"`
+	Type           string     `json:"type"`
+	Value          float32    `json:"value"`
+	MaxDiscount    float32    `json:"max_discount"`
+	MinSubtotal    float32    `json:"min_subtotal"`
+	FirstOrderOnly bool       `json:"first_order_only"`
+	SupplierIDs    []int64    `json:"supplier_ids"`
+	CategoryIDs    []int64    `json:"category_ids"`
+	UsageLimit     int        `json:"usage_limit"`
+	PerUserLimit   int        `json:"per_user_limit"`
+	UsedCount      int        `json:"used_count"`
+	StartsAt       *time.Time `json:"starts_at"`
+	EndsAt         *time.Time `json:"ends_at"`
+	Active         bool       `json:"active"`
+	UserID         int64      `json:"user_id,omitempty"`
+}
+
+// CouponValidation is what the checkout screen shows for an entered code.
+type CouponValidation struct {
+	Code      string          `json:"code"`
+	Discount  float32         `json:"discount"`
+	Breakdown *PriceBreakdown `json:"breakdown"`
+}
@@ -0,0 +1,30 @@
+package domain
+
+import "time"
+
+type Courier struct {
+	ID        int64           `json:"id"`
+	UserID    int64           `json:"user_id"`
+	Name      string          `json:"name"`
+	Phone     string          `json:"phone"`
+	Vehicle   string          `json:"vehicle"`
+	Active    bool            `json:"active"`
+	Location  *GeoPoint       `json:"location"`
+	LocatedAt *time.Time      `json:"located_at"`
+	Shifts    []*CourierShift `json:"shifts,omitempty"`
+	Available bool            `json:"available"`
+}
+
+// CourierShift is a period in which a courier takes deliveries.
+type CourierShift struct {
+	ID        int64     `json:"id"`
+	CourierID int64     `json:"courier_id"`
+	StartsAt  time.Time `json:"starts_at"`
+	EndsAt    time.Time `json:"ends_at"`
+}
+
+// CourierAssignment is the body of a manual assignment. Without a courier the
+// order is assigned to the nearest available one.
+type CourierAssignment struct {
+	CourierID int64 `json:"courier_id"`
+}
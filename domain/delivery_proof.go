@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"io"
+	"time"
+)
+
+const (
+	ProofMethodPin      = "pin"
+	ProofMethodPhoto    = "photo"
+	ProofMethodGeofence = "geofence"
+)
+
+// DeliveryProof is the evidence a courier left when completing a delivery.
+type DeliveryProof struct {
+	ID        int64     `json:"id"`
+	OrderID   int64     `json:"order_id"`
+	CourierID int64     `json:"courier_id"`
+	Method    string    `json:"method"`
+	PhotoURL  string    `json:"photo_url,omitempty"`
+	Location  *GeoPoint `json:"location,omitempty"`
+	DistanceM float64   `json:"distance_m,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// DeliveryConfirmation is what the courier sends to complete a delivery.
+type DeliveryConfirmation struct {
+	Method   string    `json:"method"`
+	Pin      string    `json:"pin"`
+	Location *GeoPoint `json:"location"`
+	Photo    io.Reader `json:"-"`
+}
+
+// OrderHandover is what the supplier sends when it hands over a pickup or dine-in order.
+// Pickup orders need the code the customer shows at the counter.
+type OrderHandover struct {
+	PickupCode string `json:"pickup_code"`
+}
@@ -1,17 +1,20 @@
 package domain
 
 type Food struct {
-	ID            int64    `json:"id"`
-	Name          string   `json:"name"`
-	SupplierID    int64    `json:"supplier_id"`
-	SupplierName  string   `json:"supplier_name"`
-	CategoryID    int64    `json:"category_id"`
-	CategoryName  string   `json:"category_name"`
-	ImageUrl      string   `json:"image_url"`
-	Description   string   `json:"description"`
-	Price         int8     `json:"price"`
-	DailyQuantity int8     `json:"daily_quantity"`
-	Gallery       []*Image `json:"gallery"`
+	ID             int64            `json:"id"`
+	Name           string           `json:"name"`
+	SupplierID     int64            `json:"supplier_id"`
+	SupplierName   string           `json:"supplier_name"`
+	CategoryID     int64            `json:"category_id"`
+	CategoryName   string           `json:"category_name"`
+	ImageUrl       string           `json:"image_url"`
+	Description    string           `json:"description"`
+	Price          int8             `json:"price"`
+	DailyQuantity  int8             `json:"daily_quantity"`
+	Gallery        []*Image         `json:"gallery"`
+	ModifierGroups []*ModifierGroup `json:"modifier_groups"`
+	RatingAverage  float32          `json:"rating_average"`
+	RatingCount    int              `json:"rating_count"`
 }
 
 type Image struct {
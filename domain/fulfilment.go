@@ -0,0 +1,48 @@
+package domain
+
+import "time"
+
+// Substitution preferences of an order, applied to the items the supplier cannot make.
+// Replace takes the replacement the supplier suggests and removes the item without one.
+const (
+	SubstitutionCancel  = "cancel"
+	SubstitutionRemove  = "remove"
+	SubstitutionReplace = "replace"
+)
+
+const (
+	FulfilmentActionRemoved  = "removed"
+	FulfilmentActionReplaced = "replaced"
+)
+
+// DiscountCodeFulfilment is the code of the discount line that keeps an order changed by
+// its supplier from costing more than it did when placed.
+const DiscountCodeFulfilment = "FULFILMENT"
+
+// UnavailableItem is a line of an order the supplier cannot make, with the food it
+// suggests instead, if any.
+type UnavailableItem struct {
+	OrderItemID       int64 `json:"order_item_id"`
+	ReplacementFoodID int64 `json:"replacement_food_id,omitempty"`
+}
+
+// OrderAcceptance is the answer of a supplier to a pending order.
+type OrderAcceptance struct {
+	UnavailableItems []*UnavailableItem `json:"unavailable_items"`
+}
+
+// FulfilmentChange records how an unavailable line was handled. UnitPrice is what the
+// line cost with its modifiers before the change.
+type FulfilmentChange struct {
+	ID                  int64     `json:"id"`
+	OrderID             int64     `json:"order_id"`
+	OrderItemID         int64     `json:"order_item_id"`
+	FoodID              int64     `json:"food_id"`
+	FoodName            string    `json:"food_name"`
+	Quantity            int8      `json:"quantity"`
+	UnitPrice           float32   `json:"unit_price"`
+	Action              string    `json:"action"`
+	ReplacementFoodID   int64     `json:"replacement_food_id,omitempty"`
+	ReplacementFoodName string    `json:"replacement_food_name,omitempty"`
+	CreatedAt           time.Time `json:"created_at"`
+}
@@ -0,0 +1,25 @@
+package domain
+
+import "time"
+
+// InvoiceParty is the seller or the buyer printed on an invoice.
+type InvoiceParty struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+	Email   string `json:"email,omitempty"`
+	Phone   string `json:"phone,omitempty"`
+}
+
+// Invoice is issued once per delivered order. Numbers run without gaps within a year,
+// like INV-2026-000042. The parties are copied when the invoice is issued so later
+// profile changes do not alter it, the lines and totals are those of the order.
+type Invoice struct {
+	ID        int64         `json:"id"`
+	Number    string        `json:"number"`
+	OrderID   int64         `json:"order_id"`
+	Supplier  *InvoiceParty `json:"supplier"`
+	Customer  *InvoiceParty `json:"customer"`
+	IssuedAt  time.Time     `json:"issued_at"`
+	EmailedAt *time.Time    `json:"emailed_at"`
+	Order     *Order        `json:"order"`
+}
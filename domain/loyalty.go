@@ -0,0 +1,47 @@
+package domain
+
+import "time"
+
+const (
+	LoyaltyTransactionEarn     = "earn"
+	LoyaltyTransactionRedeem   = "redeem"
+	LoyaltyTransactionRestore  = "restore"
+	LoyaltyTransactionReversal = "reversal"
+	LoyaltyTransactionExpire   = "expire"
+)
+
+// DiscountCodeLoyalty is the code of the discount line paid with loyalty points.
+const DiscountCodeLoyalty = "LOYALTY"
+
+// LoyaltyTier is a level of the loyalty program, reached with the points earned over
+// the tier period. Multiplier scales the points earned on each order.
+type LoyaltyTier struct {
+	Name         string  `json:"name"`
+	MinPoints    int     `json:"min_points"`
+	Multiplier   float32 `json:"multiplier"`
+	FreeDelivery bool    `json:"free_delivery"`
+}
+
+type LoyaltyAccount struct {
+	UserID           int64        `json:"user_id"`
+	Points           int          `json:"points"`
+	PointsValue      float32      `json:"points_value"`
+	Tier             *LoyaltyTier `json:"tier"`
+	TierPoints       int          `json:"tier_points"`
+	NextTier         *LoyaltyTier `json:"next_tier,omitempty"`
+	PointsToNextTier int          `json:"points_to_next_tier,omitempty"`
+	ExpiringPoints   int          `json:"expiring_points,omitempty"`
+	ExpiresAt        *time.Time   `json:"expires_at,omitempty"`
+}
+
+// LoyaltyTransaction is one movement of the points of a user: earned points are
+// positive, redeemed, reversed and expired ones negative.
+type LoyaltyTransaction struct {
+	ID          int64      `json:"id"`
+	Type        string     `json:"type"`
+	Points      int        `json:"points"`
+	OrderID     int64      `json:"order_id,omitempty"`
+	Description string     `json:"description"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
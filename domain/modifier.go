@@ -0,0 +1,30 @@
+package domain
+
+// ModifierGroup is a set of choices offered on a food, like "Size" or "Extras".
+// A required group needs at least max(1, MinSelections) options, MaxSelections of 0 means no upper limit.
+type ModifierGroup struct {
+	ID            int64             `json:"id"`
+	FoodID        int64             `json:"food_id"`
+	Name          string            `json:"name"`
+	Required      bool              `json:"required"`
+	MinSelections int               `json:"min_selections"`
+	MaxSelections int               `json:"max_selections"`
+	Options       []*ModifierOption `json:"options"`
+}
+
+type ModifierOption struct {
+	ID         int64   `json:"id"`
+	GroupID    int64   `json:"group_id"`
+	Name       string  `json:"name"`
+	PriceDelta float32 `json:"price_delta"`
+}
+
+// OrderItemModifier is an option chosen on an order or cart line. Only
+// ModifierOptionID is read from requests, the rest is a snapshot of the option.
+type OrderItemModifier struct {
+	ID               int64   `json:"id"`
+	ModifierOptionID int64   `json:"modifier_option_id"`
+	GroupName        string  `json:"group_name"`
+	Name             string  `json:"name"`
+	PriceDelta       float32 `json:"price_delta"`
+}
@@ -0,0 +1,23 @@
+package domain
+
+import "time"
+
+// OrderModification changes an order the supplier has not accepted yet. Items, when given,
+// is the new list of lines of the order, and an AddressID of 0 keeps the address.
+type OrderModification struct {
+	Items     *[]OrderItem `json:"items"`
+	AddressID int64        `json:"address_id"`
+}
+
+// OrderHistoryEntry records one modification of an order by the customer, with a line per
+// change and the totals before and after it.
+type OrderHistoryEntry struct {
+	ID                int64     `json:"id"`
+	OrderID           int64     `json:"order_id"`
+	Changes           []string  `json:"changes"`
+	PreviousAddressID int64     `json:"previous_address_id"`
+	AddressID         int64     `json:"address_id"`
+	PreviousTotal     float32   `json:"previous_total"`
+	Total             float32   `json:"total"`
+	CreatedAt         time.Time `json:"created_at"`
+}
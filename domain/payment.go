@@ -0,0 +1,36 @@
+package domain
+
+import "time"
+
+const (
+	PaymentStatusPending    = "pending"
+	PaymentStatusAuthorized = "authorized"
+	PaymentStatusCaptured   = "captured"
+	PaymentStatusRefunded   = "refunded"
+	PaymentStatusVoided     = "voided"
+	PaymentStatusFailed     = "failed"
+)
+
+// Payment is one charge of an order at the payment gateway. A captured payment
+// stays captured when part of it is refunded, it is refunded once all of it is.
+type Payment struct {
+	ID             int64     `json:"id"`
+	OrderID        int64     `json:"order_id"`
+	Provider       string    `json:"provider"`
+	Reference      string    `json:"reference"`
+	Method         string    `json:"-"`
+	Status         string    `json:"status"`
+	Amount         float32   `json:"amount"`
+	CapturedAmount float32   `json:"captured_amount"`
+	RefundedAmount float32   `json:"refunded_amount"`
+	Message        string    `json:"message,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// RefundRequest gives back part or all of a captured payment, to the payment method
+// or to the customer's wallet.
+type RefundRequest struct {
+	Amount   float32 `json:"amount"`
+	ToWallet bool    `json:"to_wallet"`
+}
@@ -0,0 +1,41 @@
+package domain
+
+const (
+	DeliveryFeeFlat     = "flat"
+	DeliveryFeeDistance = "distance"
+)
+
+// TaxRate applies to the foods of a category, a rate without category is the default one.
+type TaxRate struct {
+	ID         int64   `json:"id"`
+	Name       string  `json:"name"`
+	CategoryID int64   `json:"category_id"`
+	Rate       float32 `json:"rate"`
+}
+
+type TaxLine struct {
+	Name   string  `json:"name"`
+	Rate   float32 `json:"rate"`
+	Base   float32 `json:"base"`
+	Amount float32 `json:"amount"`
+}
+
+type Discount struct {
+	Code   string  `json:"code"`
+	Label  string  `json:"label"`
+	Amount float32 `json:"amount"`
+}
+
+// PriceBreakdown is how the total of an order is made up. The sums and the tax lines
+// are stored with the order, the discount details are only returned when calculated.
+type PriceBreakdown struct {
+	Subtotal    float32     `json:"subtotal"`
+	DeliveryFee float32     `json:"delivery_fee"`
+	ServiceFee  float32     `json:"service_fee"`
+	Tax         float32     `json:"tax"`
+	Discount    float32     `json:"discount"`
+	Tip         float32     `json:"tip"`
+	Total       float32     `json:"total"`
+	Taxes       []*TaxLine  `json:"taxes,omitempty"`
+	Discounts   []*Discount `json:"discounts,omitempty"`
+}
@@ -0,0 +1,48 @@
+package domain
+
+import "time"
+
+const (
+	ReferralStatusPending  = "pending"
+	ReferralStatusRewarded = "rewarded"
+	ReferralStatusRejected = "rejected"
+)
+
+const (
+	ReferralRewardWallet = "wallet"
+	ReferralRewardCoupon = "coupon"
+)
+
+// Referral links a user who signed up with an invite code to the owner of the code.
+// Both get their reward when the first order of the referred user is delivered. The
+// coupon codes are only set when the rewards are coupons.
+type Referral struct {
+	ID             int64      `json:"id"`
+	ReferrerID     int64      `json:"referrer_id"`
+	ReferredID     int64      `json:"referred_id"`
+	ReferredName   string     `json:"referred_name"`
+	DeviceID       string     `json:"-"`
+	Status         string     `json:"status"`
+	Reason         string     `json:"reason,omitempty"`
+	OrderID        int64      `json:"order_id,omitempty"`
+	ReferrerCoupon string     `json:"referrer_coupon,omitempty"`
+	ReferredCoupon string     `json:"referred_coupon,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	CompletedAt    *time.Time `json:"completed_at,omitempty"`
+}
+
+// ReferralReward is what one side of a referral gets: wallet credit or a coupon only
+// they can use.
+type ReferralReward struct {
+	UserID int64
+	Credit *WalletTransaction
+	Coupon *Coupon
+}
+
+// ReferralSummary is the invite code of a user with the friends they invited and the
+// referral they signed up with, if any.
+type ReferralSummary struct {
+	Code       string      `json:"code"`
+	Referrals  []*Referral `json:"referrals"`
+	ReferredBy *Referral   `json:"referred_by,omitempty"`
+}
@@ -0,0 +1,55 @@
+package domain
+
+import "time"
+
+const (
+	ReorderModeAuto  = "auto"
+	ReorderModePlace = "place"
+	ReorderModeCart  = "cart"
+)
+
+const (
+	ReorderChangeRemoved         = "removed"
+	ReorderChangeUnavailable     = "unavailable"
+	ReorderChangeQuantityReduced = "quantity_reduced"
+	ReorderChangePriceChanged    = "price_changed"
+	ReorderChangeOptionsChanged  = "options_changed"
+)
+
+// ReorderRequest controls how a previous order is repeated. In auto mode the
+// order is placed when nothing changed and a prefilled cart is returned otherwise.
+// A cart that is not empty is only replaced with ReplaceCart.
+type ReorderRequest struct {
+	Mode                   string     `json:"mode"`
+	ReplaceCart            bool       `json:"replace_cart"`
+	FulfilmentType         string     `json:"fulfilment_type"`
+	AddressID              int64      `json:"address_id"`
+	TableNumber            string     `json:"table_number"`
+	RequestedDeliveryAt    *time.Time `json:"requested_delivery_at"`
+	PaymentMethod          string     `json:"payment_method"`
+	WalletAmount           float32    `json:"wallet_amount"`
+	LoyaltyPoints          int        `json:"loyalty_points"`
+	CouponCode             string     `json:"coupon_code"`
+	SubstitutionPreference string     `json:"substitution_preference"`
+}
+
+// ReorderChange describes how one line of the previous order differs today. A line
+// whose quantity and price both changed has a change of each kind.
+type ReorderChange struct {
+	FoodID      int64   `json:"food_id"`
+	FoodName    string  `json:"food_name"`
+	Change      string  `json:"change"`
+	OldPrice    float32 `json:"old_price"`
+	NewPrice    float32 `json:"new_price"`
+	OldQuantity int8    `json:"old_quantity"`
+	NewQuantity int8    `json:"new_quantity"`
+}
+
+// ReorderResult is the placed order or the prefilled cart, with the lines of the cart
+// that were replaced.
+type ReorderResult struct {
+	Order          *Order           `json:"order,omitempty"`
+	Cart           *Cart            `json:"cart,omitempty"`
+	Changes        []*ReorderChange `json:"changes"`
+	DiscardedItems []*CartItem      `json:"discarded_items,omitempty"`
+}
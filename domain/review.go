@@ -0,0 +1,38 @@
+package domain
+
+import "time"
+
+// SortByRating orders a listing by average rating, best first.
+const SortByRating = "rating"
+
+// Review is the feedback of a customer on one delivered order. CourierRating is 0
+// when the courier was not rated.
+type Review struct {
+	ID             int64         `json:"id"`
+	OrderID        int64         `json:"order_id"`
+	UserID         int64         `json:"user_id"`
+	UserName       string        `json:"user_name"`
+	SupplierID     int64         `json:"supplier_id"`
+	SupplierRating int           `json:"supplier_rating"`
+	CourierID      int64         `json:"courier_id,omitempty"`
+	CourierRating  int           `json:"courier_rating,omitempty"`
+	Comment        string        `json:"comment"`
+	Foods          []*FoodReview `json:"foods"`
+	Reply          string        `json:"reply,omitempty"`
+	RepliedAt      *time.Time    `json:"replied_at,omitempty"`
+	CreatedAt      time.Time     `json:"created_at"`
+}
+
+type FoodReview struct {
+	ID       int64  `json:"id"`
+	ReviewID int64  `json:"review_id"`
+	FoodID   int64  `json:"food_id"`
+	FoodName string `json:"food_name"`
+	Rating   int    `json:"rating"`
+	Comment  string `json:"comment"`
+}
+
+// ReviewReply is the public answer of a supplier to a review.
+type ReviewReply struct {
+	Reply string `json:"reply"`
+}
@@ -0,0 +1,21 @@
+package domain
+
+// OpeningInterval is one opening range of a supplier on a weekday.
+// Weekday follows time.Weekday (0 is Sunday), OpensAt and ClosesAt are
+// "HH:MM" in the supplier time zone. A ClosesAt before OpensAt means the
+// interval runs past midnight into the next day.
+type OpeningInterval struct {
+	ID         int64  `json:"id"`
+	SupplierID int64  `json:"supplier_id"`
+	Weekday    int    `json:"weekday"`
+	OpensAt    string `json:"opens_at"`
+	ClosesAt   string `json:"closes_at"`
+}
+
+// Holiday closes a supplier for a whole calendar day ("YYYY-MM-DD").
+type Holiday struct {
+	ID         int64  `json:"id"`
+	SupplierID int64  `json:"supplier_id"`
+	Date       string `json:"date"`
+	Reason     string `json:"reason"`
+}
@@ -0,0 +1,75 @@
+package domain
+
+import "time"
+
+const (
+	SettlementStatusPending    = "pending"
+	SettlementStatusProcessing = "processing"
+	SettlementStatusPaid       = "paid"
+	SettlementStatusFailed     = "failed"
+)
+
+// CommissionRate is the share of the food sales the platform keeps from a supplier
+// between two dates. EndsOn is empty for a rate that is still in effect.
+type CommissionRate struct {
+	ID         int64   `json:"id"`
+	SupplierID int64   `json:"supplier_id"`
+	Rate       float32 `json:"rate"`
+	StartsOn   string  `json:"starts_on"`
+	EndsOn     string  `json:"ends_on,omitempty"`
+}
+
+// SettlementAdjustment is a correction of the payout of a supplier, positive when owed
+// to the supplier. It goes into the first settlement covering its date. Adjustments with
+// an order are added automatically for refunds given after the order was settled.
+type SettlementAdjustment struct {
+	ID           int64     `json:"id"`
+	SupplierID   int64     `json:"supplier_id"`
+	SettlementID int64     `json:"settlement_id,omitempty"`
+	OrderID      int64     `json:"order_id,omitempty"`
+	Amount       float32   `json:"amount"`
+	Description  string    `json:"description"`
+	EffectiveOn  string    `json:"effective_on"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// Settlement is what the platform owes a supplier for the orders delivered in a period:
+// the food sales and supplier tips less commission and refunds, plus adjustments.
+type Settlement struct {
+	ID              int64                   `json:"id"`
+	SupplierID      int64                   `json:"supplier_id"`
+	SupplierName    string                  `json:"supplier_name"`
+	PeriodFrom      string                  `json:"period_from"`
+	PeriodTo        string                  `json:"period_to"`
+	Orders          int                     `json:"orders"`
+	Sales           float32                 `json:"sales"`
+	Commission      float32                 `json:"commission"`
+	Refunds         float32                 `json:"refunds"`
+	Tips            float32                 `json:"tips"`
+	Adjustments     float32                 `json:"adjustments"`
+	Payout          float32                 `json:"payout"`
+	Status          string                  `json:"status"`
+	PayoutReference string                  `json:"payout_reference,omitempty"`
+	CreatedAt       time.Time               `json:"created_at"`
+	PaidAt          *time.Time              `json:"paid_at,omitempty"`
+	Lines           []*SettlementLine       `json:"lines,omitempty"`
+	AdjustmentItems []*SettlementAdjustment `json:"adjustment_items,omitempty"`
+}
+
+// SettlementLine is one delivered order of a settlement.
+type SettlementLine struct {
+	OrderID        int64     `json:"order_id"`
+	DeliveredAt    time.Time `json:"delivered_at"`
+	Sales          float32   `json:"sales"`
+	CommissionRate float32   `json:"commission_rate"`
+	Commission     float32   `json:"commission"`
+	Refunds        float32   `json:"refunds"`
+	Tips           float32   `json:"tips"`
+	Net            float32   `json:"net"`
+}
+
+// PayoutUpdate moves a settlement along its payout, with the bank reference once paid.
+type PayoutUpdate struct {
+	Status    string `json:"status"`
+	Reference string `json:"reference"`
+}
@@ -1,13 +1,57 @@
 package domain
 
+import "time"
+
 type Supplier struct {
-	ID           int64  `json:"ID"`
-	Name         string `json:"name"`
-	Address      string `json:"address"`
-	Description  string `json:"description"`
-	LogoUrl      string `json:"logo_url"`
-	OpeningHour  string `json:"opening_hour"`
-	ClosingHour  string `json:"closing_hour"`
-	UserID       int64  `json:"user_id"`
-	DeliveryTime string `json:"delivery_time"`
+	ID                int64              `json:"ID"`
+	Name              string             `json:"name"`
+	Address           string             `json:"address"`
+	Description       string             `json:"description"`
+	LogoUrl           string             `json:"logo_url"`
+	OpeningHour       string             `json:"opening_hour"`
+	ClosingHour       string             `json:"closing_hour"`
+	UserID            int64              `json:"user_id"`
+	DeliveryTime      string             `json:"delivery_time"`
+	PrepTime          int                `json:"prep_time"`
+	DeliveryFeeType   string             `json:"delivery_fee_type"`
+	DeliveryFee       float32            `json:"delivery_fee"`
+	DeliveryFeePerKm  float32            `json:"delivery_fee_per_km"`
+	FreeDeliveryAbove float32            `json:"free_delivery_above"`
+	MinOrderSubtotal  float32            `json:"min_order_subtotal"`
+	MaxItems          int                `json:"max_items"`
+	MaxFoodQuantity   int                `json:"max_food_quantity"`
+	TimeZone          string             `json:"time_zone"`
+	OpeningHours      []*OpeningInterval `json:"opening_hours"`
+	Holidays          []*Holiday         `json:"holidays"`
+	IsOpen            bool               `json:"is_open"`
+	NextOpeningAt     *time.Time         `json:"next_opening_at"`
+	DeliveryZones     []*DeliveryZone    `json:"delivery_zones"`
+	DeliveryZone      *DeliveryZone      `json:"delivery_zone,omitempty"`
+	Location          *GeoPoint          `json:"location"`
+	DistanceKm        float64            `json:"distance_km,omitempty"`
+	EstimatedMinutes  int                `json:"estimated_minutes,omitempty"`
+	RatingAverage     float32            `json:"rating_average"`
+	RatingCount       int                `json:"rating_count"`
+}
+
+// SupplierFilter holds the optional filters of the supplier listing.
+type SupplierFilter struct {
+	OpenNow   bool
+	AddressID int64
+	Sort      string
+}
+
+// NearbyQuery selects the suppliers within RadiusKm of Point, one page at a time.
+type NearbyQuery struct {
+	Point    GeoPoint
+	RadiusKm float64
+	Page     int
+	Limit    int
+}
+
+type SupplierPage struct {
+	Suppliers []*Supplier `json:"suppliers"`
+	Total     int         `json:"total"`
+	Page      int         `json:"page"`
+	Limit     int         `json:"limit"`
 }
@@ -0,0 +1,40 @@
+package domain
+
+import "time"
+
+const (
+	TipRecipientCourier  = "courier"
+	TipRecipientSupplier = "supplier"
+
+	TipTypeFixed      = "fixed"
+	TipTypePercentage = "percentage"
+)
+
+// Tip is an optional gratuity for the courier or the supplier of an order. A fixed tip
+// is an amount, a percentage tip is a percentage of the order subtotal.
+type Tip struct {
+	ID        int64     `json:"id"`
+	OrderID   int64     `json:"order_id"`
+	Recipient string    `json:"recipient"`
+	Type      string    `json:"type"`
+	Value     float32   `json:"value"`
+	Amount    float32   `json:"amount"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TipRequest adds tips to an order after it was delivered.
+type TipRequest struct {
+	Tips []*Tip `json:"tips"`
+}
+
+// Earnings sums the delivered orders of a courier or a supplier over [From, To).
+// OrderEarnings is the delivery fees for a courier and the food subtotals for a
+// supplier, tips are reported on their own.
+type Earnings struct {
+	From          time.Time `json:"from"`
+	To            time.Time `json:"to"`
+	Orders        int       `json:"orders"`
+	OrderEarnings float32   `json:"order_earnings"`
+	Tips          float32   `json:"tips"`
+	Total         float32   `json:"total"`
+}
@@ -0,0 +1,23 @@
+package domain
+
+import "time"
+
+// LocationPing is a GPS position sent by a courier app.
+type LocationPing struct {
+	Lat        float64   `json:"lat"`
+	Lng        float64   `json:"lng"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// OrderTracking is what the customer sees while an order is on its way.
+type OrderTracking struct {
+	OrderID          int64      `json:"order_id"`
+	Status           string     `json:"status"`
+	CourierID        int64      `json:"courier_id"`
+	CourierName      string     `json:"courier_name,omitempty"`
+	Location         *GeoPoint  `json:"location"`
+	LocationAt       *time.Time `json:"location_at"`
+	DistanceKm       float64    `json:"distance_km"`
+	EtaMinutes       int        `json:"eta_minutes"`
+	EstimatedArrival *time.Time `json:"estimated_arrival"`
+}
@@ -8,4 +8,5 @@ type User struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
 	Status   string `json:"status"`
+	DeviceID string `json:"device_id,omitempty"`
 }
@@ -0,0 +1,44 @@
+package domain
+
+import "time"
+
+const (
+	WalletTransactionTopUp           = "top_up"
+	WalletTransactionRefund          = "refund"
+	WalletTransactionPromotion       = "promotion"
+	WalletTransactionOrderPayment    = "order_payment"
+	WalletTransactionOrderReversal   = "order_reversal"
+	WalletTransactionOrderAdjustment = "order_adjustment"
+)
+
+type Wallet struct {
+	UserID  int64   `json:"user_id"`
+	Balance float32 `json:"balance"`
+}
+
+// WalletTransaction is one movement of a wallet, seen from the wallet: credits are
+// positive and debits negative. Every movement is a balanced ledger transaction
+// against one of the platform accounts.
+type WalletTransaction struct {
+	ID          int64     `json:"id"`
+	Type        string    `json:"type"`
+	Amount      float32   `json:"amount"`
+	Description string    `json:"description"`
+	OrderID     int64     `json:"order_id,omitempty"`
+	Reference   string    `json:"reference,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// TopUpRequest adds money to the wallet of the signed in user with a payment method.
+type TopUpRequest struct {
+	Amount        float32 `json:"amount"`
+	PaymentMethod string  `json:"payment_method"`
+}
+
+// WalletCredit is a refund or goodwill credit given by support.
+type WalletCredit struct {
+	Type        string  `json:"type"`
+	Amount      float32 `json:"amount"`
+	Description string  `json:"description"`
+	OrderID     int64   `json:"order_id"`
+}
@@ -0,0 +1,20 @@
+package domain
+
+// GeoPoint is a WGS84 coordinate.
+type GeoPoint struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+// DeliveryZone is an area a supplier delivers to, given as a list of postcodes
+// and/or a polygon. DeliveryFee and DeliveryTime (minutes) replace the supplier
+// defaults for addresses inside the zone.
+type DeliveryZone struct {
+	ID           int64      `json:"id"`
+	SupplierID   int64      `json:"supplier_id"`
+	Name         string     `json:"name"`
+	Postcodes    []string   `json:"postcodes"`
+	Polygon      []GeoPoint `json:"polygon"`
+	DeliveryFee  float32    `json:"delivery_fee"`
+	DeliveryTime int        `json:"delivery_time"`
+}
@@ -0,0 +1,86 @@
+package geocoding
+
+import (
+	"encoding/json"
+	"foodDelivery/domain"
+	"os"
+	"strings"
+)
+
+// fileEntry is one known place of the geocoding file.
+type fileEntry struct {
+	Street  string  `json:"street"`
+	City    string  `json:"city"`
+	Zip     string  `json:"zip"`
+	Country string  `json:"country"`
+	Lat     float64 `json:"lat"`
+	Lng     float64 `json:"lng"`
+}
+
+// fileGeocoder resolves addresses from a JSON file of known places. It is meant
+// for tests and development, where no geocoding service is available.
+type fileGeocoder struct {
+	byStreet map[string]domain.GeoPoint
+	byZip    map[string]domain.GeoPoint
+	byCity   map[string]domain.GeoPoint
+}
+
+func NewFileGeocoder(path string) (Geocoder, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var entries []fileEntry
+	err = json.Unmarshal(data, &entries)
+	if err != nil {
+		return nil, err
+	}
+
+	geocoder := &fileGeocoder{
+		byStreet: make(map[string]domain.GeoPoint),
+		byZip:    make(map[string]domain.GeoPoint),
+		byCity:   make(map[string]domain.GeoPoint),
+	}
+	for _, entry := range entries {
+		point := domain.GeoPoint{Lat: entry.Lat, Lng: entry.Lng}
+		if entry.Street != "" {
+			geocoder.byStreet[key(entry.Country, entry.City, entry.Street)] = point
+		}
+		// Postcodes and cities resolve to the first place listed for them.
+		if _, found := geocoder.byZip[key(entry.Country, entry.Zip)]; entry.Zip != "" && !found {
+			geocoder.byZip[key(entry.Country, entry.Zip)] = point
+		}
+		if _, found := geocoder.byCity[key(entry.Country, entry.City)]; entry.City != "" && !found {
+			geocoder.byCity[key(entry.Country, entry.City)] = point
+		}
+	}
+	return geocoder, nil
+}
+
+// Geocode matches the most precise known place: the street, then the postcode, then the city.
+func (fg *fileGeocoder) Geocode(query Query) (*domain.GeoPoint, error) {
+	if query.Street != "" {
+		if point, found := fg.byStreet[key(query.Country, query.City, query.Street)]; found {
+			return &point, nil
+		}
+	}
+	if query.Zip != "" {
+		if point, found := fg.byZip[key(query.Country, query.Zip)]; found {
+			return &point, nil
+		}
+	}
+	if query.City != "" {
+		if point, found := fg.byCity[key(query.Country, query.City)]; found {
+			return &point, nil
+		}
+	}
+	return nil, ErrNoMatch
+}
+
+func key(parts ...string) string {
+	normalized := make([]string, len(parts))
+	for i, part := range parts {
+		normalized[i] = strings.Join(strings.Fields(strings.ToLower(part)), " ")
+	}
+	return strings.Join(normalized, "|")
+}
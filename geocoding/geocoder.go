@@ -0,0 +1,37 @@
+package geocoding
+
+import (
+	"errors"
+	"foodDelivery/domain"
+	"math"
+)
+
+var (
+	ErrNoMatch = errors.New("address could not be geocoded")
+)
+
+// Query is the structured address to look up.
+type Query struct {
+	Street  string
+	City    string
+	Zip     string
+	Country string
+}
+
+// Geocoder turns an address into coordinates.
+type Geocoder interface {
+	Geocode(query Query) (*domain.GeoPoint, error)
+}
+
+const earthRadiusKm = 6371.0
+
+// DistanceKm is the great-circle distance between two points.
+func DistanceKm(a, b domain.GeoPoint) float64 {
+	lat1 := a.Lat * math.Pi / 180
+	lat2 := b.Lat * math.Pi / 180
+	dLat := (b.Lat - a.Lat) * math.Pi / 180
+	dLng := (b.Lng - a.Lng) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
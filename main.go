@@ -3,14 +3,21 @@ package main
 import (
 	"database/sql"
 	intPkg "foodDelivery/delivery/http"
+	"foodDelivery/domain"
+	"foodDelivery/geocoding"
 	"foodDelivery/migrations"
+	"foodDelivery/notification"
+	"foodDelivery/payment"
 	"foodDelivery/repository"
+	"foodDelivery/storage"
 	"foodDelivery/usecase"
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
 	_ "github.com/lib/pq"
 	"log"
 	"net/http"
+	"time"
+	_ "time/tzdata"
 )
 
 func main() {
@@ -22,17 +29,65 @@ func main() {
 	}
 	defer db.Close()
 
-	// Create the users table.
-	err = migrations.CreateUsersTable(db)
-	err = migrations.CreateCategoriesTable(db)
-	err = migrations.CreateSuppliersTable(db)
-	err = migrations.CreateFoodsTable(db)
-	err = migrations.CreateGalleryTable(db)
-	err = migrations.CreateAddressesTable(db)
-	err = migrations.CreateOrdersTable(db)
-	err = migrations.CreateOrderItemsTable(db)
-	if err != nil {
-		log.Fatalf("Failed to create table: %v", err)
+	// Create and update the tables, in order, stopping at the first one that fails.
+	tableMigrations := []func(*sql.DB) error{
+		migrations.CreateUsersTable,
+		migrations.CreateCategoriesTable,
+		migrations.CreateSuppliersTable,
+		migrations.CreateFoodsTable,
+		migrations.CreateGalleryTable,
+		migrations.CreateAddressesTable,
+		migrations.CreateOrdersTable,
+		migrations.CreateOrderItemsTable,
+		migrations.UpdateSuppliersTable,
+		migrations.UpdateFoodsTable,
+		migrations.CreateCouriersTable,
+		migrations.CreateCourierShiftsTable,
+		migrations.UpdateCouriersTable,
+		migrations.CreateDeliveryProofsTable,
+		migrations.UpdateOrdersTable,
+		migrations.CreateReviewsTable,
+		migrations.CreateFoodReviewsTable,
+		migrations.CreateOrderTipsTable,
+		migrations.CreatePaymentsTable,
+		migrations.CreatePaymentEventsTable,
+		migrations.CreateLedgerAccountsTable,
+		migrations.CreateLedgerTransactionsTable,
+		migrations.CreateLedgerEntriesTable,
+		migrations.CreateCouponsTable,
+		migrations.CreateCouponRedemptionsTable,
+		migrations.UpdateCouponsTable,
+		migrations.CreateLoyaltyTransactionsTable,
+		migrations.UpdateUsersTable,
+		migrations.CreateReferralsTable,
+		migrations.CreateCommissionRatesTable,
+		migrations.CreateSettlementsTable,
+		migrations.CreateSettlementOrdersTable,
+		migrations.CreateSettlementAdjustmentsTable,
+		migrations.UpdateSettlementAdjustmentsTable,
+		migrations.CreateOrderTaxesTable,
+		migrations.CreateInvoicesTable,
+		migrations.CreateOrderFulfilmentChangesTable,
+		migrations.CreateOrderHistoryTable,
+		migrations.CreateSupplierOpeningHoursTable,
+		migrations.CreateSupplierHolidaysTable,
+		migrations.CreateCartsTable,
+		migrations.CreateCartItemsTable,
+		migrations.CreateModifierGroupsTable,
+		migrations.CreateModifierOptionsTable,
+		migrations.CreateOrderItemModifiersTable,
+		migrations.CreateCartItemModifiersTable,
+		migrations.UpdateOrderItemsTable,
+		migrations.UpdateCartItemsTable,
+		migrations.CreateTaxRatesTable,
+		migrations.CreateDeliveryZonesTable,
+		migrations.UpdateAddressesTable,
+	}
+	for _, migrate := range tableMigrations {
+		err = migrate(db)
+		if err != nil {
+			log.Fatalf("Failed to create table: %v", err)
+		}
 	}
 
 	// Create an instance of the repository.
@@ -43,23 +98,145 @@ func main() {
 	galleryRepository := repository.NewGalleryRepository(db)
 	orderRepository := repository.NewOrderRepository(db)
 	addressRepository := repository.NewAddressRepository(db)
+	supplierScheduleRepository := repository.NewSupplierScheduleRepository(db)
+	cartRepository := repository.NewCartRepository(db)
+	modifierRepository := repository.NewModifierRepository(db)
+	taxRateRepository := repository.NewTaxRateRepository(db)
+	deliveryZoneRepository := repository.NewDeliveryZoneRepository(db)
+	courierRepository := repository.NewCourierRepository(db)
+	reviewRepository := repository.NewReviewRepository(db)
+	tipRepository := repository.NewTipRepository(db)
+	paymentRepository := repository.NewPaymentRepository(db)
+	walletRepository := repository.NewWalletRepository(db)
+	couponRepository := repository.NewCouponRepository(db)
+	loyaltyRepository := repository.NewLoyaltyRepository(db)
+	referralRepository := repository.NewReferralRepository(db)
+	settlementRepository := repository.NewSettlementRepository(db)
+	invoiceRepository := repository.NewInvoiceRepository(db)
+
+	// Addresses are geocoded from a local file of known places.
+	geocoder, err := geocoding.NewFileGeocoder("data/geocoding.json")
+	if err != nil {
+		log.Fatalf("Failed to load geocoding data: %v", err)
+	}
+
+	// Uploaded files are kept on disk and served under /uploads/.
+	uploadStore, err := storage.NewLocalStore("uploads", "/uploads")
+	if err != nil {
+		log.Fatalf("Failed to prepare the upload directory: %v", err)
+	}
+
+	// Payments go through a fake gateway until a real provider is configured. Its webhooks
+	// are signed with this secret.
+	paymentGateway := payment.NewFakeGateway("dev-webhook-secret")
+
+	// E-mails are only logged until a mail server is configured with notification.NewSMTPNotifier.
+	notifier := notification.NewLogNotifier()
+
+	// Platform fees applied on top of every order.
+	pricingEngine := usecase.NewPricingEngine(foodRepository, taxRateRepository, usecase.PricingConfig{
+		ServiceFeeRate: 0.05,
+		MinServiceFee:  0.5,
+		MaxServiceFee:  5,
+	})
+
+	// One point per unit spent, worth 0.01 at checkout. Points expire after a year and the
+	// tier depends on the points earned over the last year.
+	loyaltyUseCase := usecase.NewLoyaltyUseCase(loyaltyRepository, orderRepository, usecase.LoyaltyConfig{
+		PointsPerUnit:   1,
+		PointValue:      0.01,
+		MinRedeemPoints: 100,
+		ExpiryPeriod:    365 * 24 * time.Hour,
+		TierPeriod:      365 * 24 * time.Hour,
+		Tiers: []domain.LoyaltyTier{
+			{Name: "Bronze", MinPoints: 0, Multiplier: 1},
+			{Name: "Silver", MinPoints: 500, Multiplier: 1.25},
+			{Name: "Gold", MinPoints: 1500, Multiplier: 1.5, FreeDelivery: true},
+		},
+	})
+
+	// Both sides of a referral get wallet credit once the first order of the friend is delivered.
+	referralUseCase := usecase.NewReferralUseCase(referralRepository, orderRepository, addressRepository,
+		usecase.ReferralConfig{
+			RewardType:     domain.ReferralRewardWallet,
+			ReferrerReward: 10,
+			ReferredReward: 5,
+			CouponValidity: 30 * 24 * time.Hour,
+		})
 
 	// Create an instance of the use case, passing in the UserRepository interface.
 	userUseCase := usecase.NewUserUseCase(userRepository)
 	categoryUseCase := usecase.NewCategoryUseCase(categoryRepository)
-	supplierUseCase := usecase.NewSupplierUseCase(supplierRepository)
-	foodUseCase := usecase.NewFoodUseCase(foodRepository, categoryRepository, supplierRepository, galleryRepository)
-	orderUseCase := usecase.NewOrderUseCase(orderRepository)
-	addressUseCase := usecase.NewAddressUseCase(addressRepository)
+	supplierUseCase := usecase.NewSupplierUseCase(supplierRepository, supplierScheduleRepository, deliveryZoneRepository,
+		addressRepository)
+	foodUseCase := usecase.NewFoodUseCase(foodRepository, categoryRepository, supplierRepository, galleryRepository, modifierRepository)
+	paymentUseCase := usecase.NewPaymentUseCase(paymentRepository, orderRepository, walletRepository, couponRepository, loyaltyUseCase, paymentGateway)
+	orderUseCase := usecase.NewOrderUseCase(orderRepository, supplierRepository, supplierScheduleRepository, modifierRepository, addressRepository,
+		deliveryZoneRepository, couponRepository, loyaltyUseCase, pricingEngine, paymentUseCase)
+	addressUseCase := usecase.NewAddressUseCase(addressRepository, geocoder)
+	taxRateUseCase := usecase.NewTaxRateUseCase(taxRateRepository)
+	cartUseCase := usecase.NewCartUseCase(cartRepository, foodRepository, orderRepository, modifierRepository, orderUseCase)
+	courierUseCase := usecase.NewCourierUseCase(courierRepository)
+	invoiceUseCase := usecase.NewInvoiceUseCase(invoiceRepository, orderRepository, supplierRepository, userRepository,
+		addressRepository, notifier)
+	fulfilmentUseCase := usecase.NewFulfilmentUseCase(orderRepository, supplierRepository, foodRepository, couponRepository,
+		userRepository, pricingEngine, paymentUseCase, notifier)
+	// Courier positions of deliveries are kept in memory and written to the database every 15 seconds.
+	locationTracker := usecase.NewLocationTracker(courierRepository, 15*time.Second)
+	locationTracker.Start()
+	defer locationTracker.Stop()
+	deliveryUseCase := usecase.NewDeliveryUseCase(orderRepository, courierRepository, supplierRepository, addressRepository,
+		uploadStore, usecase.NewNearestCourierStrategy(), paymentUseCase, loyaltyUseCase, referralUseCase, invoiceUseCase,
+		userRepository, notifier, locationTracker)
+	trackingUseCase := usecase.NewTrackingUseCase(orderRepository, courierRepository, supplierRepository, addressRepository,
+		locationTracker)
+	reviewUseCase := usecase.NewReviewUseCase(reviewRepository, orderRepository)
+	tipUseCase := usecase.NewTipUseCase(tipRepository, orderRepository, courierRepository, supplierRepository, paymentUseCase)
+	walletUseCase := usecase.NewWalletUseCase(walletRepository, userRepository, orderRepository, loyaltyUseCase, paymentGateway)
+	couponUseCase := usecase.NewCouponUseCase(couponRepository, orderUseCase)
+	// Suppliers without a commission rate for the day of an order pay the default one.
+	settlementUseCase := usecase.NewSettlementUseCase(settlementRepository, supplierRepository, usecase.SettlementConfig{
+		DefaultCommissionRate: 0.15,
+	})
+	reorderUseCase := usecase.NewReorderUseCase(orderRepository, foodRepository, modifierRepository, orderUseCase, cartUseCase)
+
+	// Release scheduled orders to their suppliers once preparation has to start.
+	orderScheduler := usecase.NewOrderScheduler(orderRepository, time.Minute)
+	orderScheduler.Start()
+	defer orderScheduler.Stop()
+
+	// Offer ready orders to couriers that came on shift after the order was ready.
+	courierDispatcher := usecase.NewCourierDispatcher(deliveryUseCase, 30*time.Second)
+	courierDispatcher.Start()
+	defer courierDispatcher.Stop()
+
+	// Write off expired loyalty points.
+	pointsExpirer := usecase.NewPointsExpirer(loyaltyRepository, time.Hour)
+	pointsExpirer.Start()
+	defer pointsExpirer.Stop()
 
 	// Create an instance of the user handler, passing in the UserUseCase interface.
 	userHandler := intPkg.NewUserHandler(userUseCase)
 	categoryHandler := intPkg.NewCategoryHandler(categoryUseCase)
-	supplierHandler := intPkg.NewSupplierHandler(supplierUseCase, categoryUseCase, foodUseCase)
+	supplierHandler := intPkg.NewSupplierHandler(supplierUseCase, categoryUseCase, foodUseCase, orderUseCase)
 	foodHandler := intPkg.NewFoodHandler(foodUseCase)
-	authHandler := intPkg.NewAuthHandler(userUseCase)
-	orderHandler := intPkg.NewOrderHandler(orderUseCase)
+	authHandler := intPkg.NewAuthHandler(userUseCase, referralUseCase)
+	orderHandler := intPkg.NewOrderHandler(orderUseCase, reorderUseCase)
 	addressHandler := intPkg.NewAddressHandler(addressUseCase)
+	cartHandler := intPkg.NewCartHandler(cartUseCase)
+	taxRateHandler := intPkg.NewTaxRateHandler(taxRateUseCase)
+	courierHandler := intPkg.NewCourierHandler(courierUseCase, deliveryUseCase)
+	trackingHandler := intPkg.NewTrackingHandler(trackingUseCase)
+	reviewHandler := intPkg.NewReviewHandler(reviewUseCase)
+	tipHandler := intPkg.NewTipHandler(tipUseCase)
+	paymentHandler := intPkg.NewPaymentHandler(paymentUseCase)
+	walletHandler := intPkg.NewWalletHandler(walletUseCase)
+	couponHandler := intPkg.NewCouponHandler(couponUseCase)
+	loyaltyHandler := intPkg.NewLoyaltyHandler(loyaltyUseCase)
+	referralHandler := intPkg.NewReferralHandler(referralUseCase)
+	settlementHandler := intPkg.NewSettlementHandler(settlementUseCase)
+	invoiceHandler := intPkg.NewInvoiceHandler(invoiceUseCase)
+	fulfilmentHandler := intPkg.NewFulfilmentHandler(fulfilmentUseCase)
 
 	// Create a new router.
 	router := mux.NewRouter()
@@ -69,6 +246,7 @@ func main() {
 	router.HandleFunc("/api/users", userHandler.CreateUser).Methods("POST")
 	router.HandleFunc("/api/users/{id}", userHandler.UpdateUser).Methods("PUT")
 	router.HandleFunc("/api/users/{id}", userHandler.DeleteUser).Methods("DELETE")
+	router.HandleFunc("/api/users/{id}/wallet/credits", walletHandler.CreditWallet).Methods("POST")
 
 	// categories Api
 	router.HandleFunc("/api/categories/{id}", categoryHandler.GetCategoryByID).Methods("GET")
@@ -78,12 +256,29 @@ func main() {
 	router.HandleFunc("/api/categories/{id}", categoryHandler.DeleteCategory).Methods("DELETE")
 
 	// suppliers API
+	router.HandleFunc("/api/suppliers/nearby", supplierHandler.GetNearbySuppliers).Methods("GET")
 	router.HandleFunc("/api/suppliers/{id}", supplierHandler.GetSupplierByID).Methods("GET")
 	router.HandleFunc("/api/suppliers", supplierHandler.GetAllSuppliers).Methods("GET")
 	router.HandleFunc("/api/suppliers", supplierHandler.CreateSupplier).Methods("POST")
 	router.HandleFunc("/api/suppliers/{id}", supplierHandler.UpdateSupplier).Methods("PUT")
 	router.HandleFunc("/api/suppliers/{id}", supplierHandler.DeleteSupplier).Methods("DELETE")
 	router.HandleFunc("/api/suppliers/{id}/categories", supplierHandler.GetSupplierCategories).Methods("GET")
+	router.HandleFunc("/api/suppliers/{id}/orders", supplierHandler.GetSupplierOrders).Methods("GET")
+	router.HandleFunc("/api/suppliers/{id}/orders/{order_id}/accept", fulfilmentHandler.AcceptOrder).Methods("PUT")
+	router.HandleFunc("/api/suppliers/{id}/orders/{order_id}/ready", courierHandler.MarkOrderReady).Methods("PUT")
+	router.HandleFunc("/api/suppliers/{id}/orders/{order_id}/handover", courierHandler.HandOverOrder).Methods("PUT")
+	router.HandleFunc("/api/suppliers/{id}/earnings", tipHandler.GetSupplierEarnings).Methods("GET")
+	router.HandleFunc("/api/suppliers/{id}/settlements", settlementHandler.GetSupplierSettlements).Methods("GET")
+	router.HandleFunc("/api/suppliers/{id}/commission-rates", settlementHandler.GetCommissionRates).Methods("GET")
+	router.HandleFunc("/api/suppliers/{id}/commission-rates", settlementHandler.CreateCommissionRate).Methods("POST")
+	router.HandleFunc("/api/suppliers/{id}/commission-rates/{rate_id}", settlementHandler.DeleteCommissionRate).Methods("DELETE")
+	router.HandleFunc("/api/suppliers/{id}/settlement-adjustments", settlementHandler.GetAdjustments).Methods("GET")
+	router.HandleFunc("/api/suppliers/{id}/settlement-adjustments", settlementHandler.CreateAdjustment).Methods("POST")
+	router.HandleFunc("/api/suppliers/{id}/reviews", reviewHandler.GetSupplierReviews).Methods("GET")
+	router.HandleFunc("/api/suppliers/{id}/reviews/{review_id}/reply", reviewHandler.ReplyToReview).Methods("POST")
+	router.HandleFunc("/api/suppliers/{id}/holidays", supplierHandler.GetSupplierHolidays).Methods("GET")
+	router.HandleFunc("/api/suppliers/{id}/holidays", supplierHandler.CreateSupplierHoliday).Methods("POST")
+	router.HandleFunc("/api/suppliers/{id}/holidays/{holiday_id}", supplierHandler.DeleteSupplierHoliday).Methods("DELETE")
 	router.HandleFunc("/api/supplier/{cat_id}/food-list/{supplier_id}", supplierHandler.GetFoodsByCategoryAndSupplier).Methods("GET")
 
 	// foods API
@@ -92,15 +287,28 @@ func main() {
 	router.HandleFunc("/api/foods/{id}", foodHandler.GetFoodByID).Methods("GET")
 	router.HandleFunc("/api/foods/{id}", foodHandler.UpdateFood).Methods("PUT")
 	router.HandleFunc("/api/foods/{id}", foodHandler.DeleteFood).Methods("DELETE")
+	router.HandleFunc("/api/foods/{id}/reviews", reviewHandler.GetFoodReviews).Methods("GET")
 
 	// auth
 	router.HandleFunc("/api/login", authHandler.Login).Methods("POST")
 	router.HandleFunc("/api/register", authHandler.Register).Methods("POST")
+	router.HandleFunc("/api/referrals", referralHandler.GetReferrals).Methods("GET")
 
 	// orders
 	router.HandleFunc("/api/orders", orderHandler.SubmitOrder).Methods("POST")
+	router.HandleFunc("/api/orders/quote", orderHandler.QuoteOrder).Methods("POST")
 	router.HandleFunc("/api/orders", orderHandler.GetUserOrders).Methods("GET")
 	router.HandleFunc("/api/orders/{id}", orderHandler.GetOrderWithItems).Methods("GET")
+	router.HandleFunc("/api/orders/{id}", orderHandler.ModifyOrder).Methods("PUT")
+	router.HandleFunc("/api/orders/{id}/history", orderHandler.GetOrderHistory).Methods("GET")
+	router.HandleFunc("/api/orders/{id}/reorder", orderHandler.Reorder).Methods("POST")
+	router.HandleFunc("/api/orders/{id}/assign", courierHandler.AssignOrder).Methods("POST")
+	router.HandleFunc("/api/orders/{id}/tracking", trackingHandler.GetOrderTracking).Methods("GET")
+	router.HandleFunc("/api/orders/{id}/review", reviewHandler.CreateReview).Methods("POST")
+	router.HandleFunc("/api/orders/{id}/review", reviewHandler.GetOrderReview).Methods("GET")
+	router.HandleFunc("/api/orders/{id}/tips", tipHandler.AddTips).Methods("POST")
+	router.HandleFunc("/api/orders/{id}/payments", paymentHandler.GetOrderPayments).Methods("GET")
+	router.HandleFunc("/api/orders/{id}/invoice", invoiceHandler.GetOrderInvoice).Methods("GET")
 
 	// addresses
 	router.HandleFunc("/api/addresses", addressHandler.GetUsersAddresses).Methods("GET")
@@ -109,6 +317,70 @@ func main() {
 	router.HandleFunc("/api/addresses/{id}", addressHandler.UpdateAddress).Methods("PUT")
 	router.HandleFunc("/api/addresses", addressHandler.CreateAddress).Methods("POST")
 
+	// cart
+	router.HandleFunc("/api/cart", cartHandler.GetCart).Methods("GET")
+	router.HandleFunc("/api/cart", cartHandler.ClearCart).Methods("DELETE")
+	router.HandleFunc("/api/cart/items", cartHandler.AddItem).Methods("POST")
+	router.HandleFunc("/api/cart/items/{id}", cartHandler.UpdateItem).Methods("PUT")
+	router.HandleFunc("/api/cart/items/{id}", cartHandler.RemoveItem).Methods("DELETE")
+	router.HandleFunc("/api/cart/checkout", cartHandler.Checkout).Methods("POST")
+
+	// tax rates
+	router.HandleFunc("/api/tax-rates", taxRateHandler.GetAllTaxRates).Methods("GET")
+	router.HandleFunc("/api/tax-rates", taxRateHandler.CreateTaxRate).Methods("POST")
+	router.HandleFunc("/api/tax-rates/{id}", taxRateHandler.UpdateTaxRate).Methods("PUT")
+	router.HandleFunc("/api/tax-rates/{id}", taxRateHandler.DeleteTaxRate).Methods("DELETE")
+
+	// couriers
+	router.HandleFunc("/api/couriers", courierHandler.GetAllCouriers).Methods("GET")
+	router.HandleFunc("/api/couriers", courierHandler.CreateCourier).Methods("POST")
+	router.HandleFunc("/api/couriers/{id}", courierHandler.GetCourierByID).Methods("GET")
+	router.HandleFunc("/api/couriers/{id}", courierHandler.UpdateCourier).Methods("PUT")
+	router.HandleFunc("/api/couriers/{id}/shifts", courierHandler.GetCourierShifts).Methods("GET")
+	router.HandleFunc("/api/couriers/{id}/shifts", courierHandler.CreateCourierShift).Methods("POST")
+	router.HandleFunc("/api/couriers/{id}/shifts/{shift_id}", courierHandler.DeleteCourierShift).Methods("DELETE")
+
+	// deliveries of the signed in courier
+	router.HandleFunc("/api/courier/location", trackingHandler.RecordLocation).Methods("POST")
+	router.HandleFunc("/api/courier/orders", courierHandler.GetCourierOrders).Methods("GET")
+	router.HandleFunc("/api/courier/earnings", tipHandler.GetCourierEarnings).Methods("GET")
+	router.HandleFunc("/api/courier/orders/{id}/accept", courierHandler.AcceptOrder).Methods("POST")
+	router.HandleFunc("/api/courier/orders/{id}/decline", courierHandler.DeclineOrder).Methods("POST")
+	router.HandleFunc("/api/courier/orders/{id}/pickup", courierHandler.PickUpOrder).Methods("POST")
+	router.HandleFunc("/api/courier/orders/{id}/deliver", courierHandler.DeliverOrder).Methods("POST")
+
+	// payment routes
+	router.HandleFunc("/api/payments/webhook", paymentHandler.Webhook).Methods("POST")
+	router.HandleFunc("/api/payments/{id}/refund", paymentHandler.RefundPayment).Methods("POST")
+	router.HandleFunc("/api/payments/{id}/void", paymentHandler.VoidPayment).Methods("POST")
+
+	// wallet routes
+	router.HandleFunc("/api/wallet", walletHandler.GetWallet).Methods("GET")
+	router.HandleFunc("/api/wallet/transactions", walletHandler.GetTransactions).Methods("GET")
+	router.HandleFunc("/api/wallet/top-up", walletHandler.TopUp).Methods("POST")
+
+	// coupons
+	router.HandleFunc("/api/coupons", couponHandler.GetAllCoupons).Methods("GET")
+	router.HandleFunc("/api/coupons", couponHandler.CreateCoupon).Methods("POST")
+	router.HandleFunc("/api/coupons/validate", couponHandler.ValidateCoupon).Methods("POST")
+	router.HandleFunc("/api/coupons/{id}", couponHandler.GetCouponByID).Methods("GET")
+	router.HandleFunc("/api/coupons/{id}", couponHandler.UpdateCoupon).Methods("PUT")
+	router.HandleFunc("/api/coupons/{id}", couponHandler.DeleteCoupon).Methods("DELETE")
+
+	// supplier settlements
+	router.HandleFunc("/api/settlements", settlementHandler.GetSettlements).Methods("GET")
+	router.HandleFunc("/api/settlements", settlementHandler.GenerateSettlements).Methods("POST")
+	router.HandleFunc("/api/settlements/{id}", settlementHandler.GetSettlement).Methods("GET")
+	router.HandleFunc("/api/settlements/{id}/payout", settlementHandler.UpdatePayout).Methods("PUT")
+
+	// loyalty program
+	router.HandleFunc("/api/loyalty", loyaltyHandler.GetAccount).Methods("GET")
+	router.HandleFunc("/api/loyalty/transactions", loyaltyHandler.GetTransactions).Methods("GET")
+	router.HandleFunc("/api/loyalty/tiers", loyaltyHandler.GetTiers).Methods("GET")
+
+	// uploaded files
+	router.PathPrefix("/uploads/").Handler(http.StripPrefix("/uploads/", http.FileServer(http.Dir("uploads"))))
+
 	// fix cross error
 	allowedOrigins := handlers.AllowedOrigins([]string{"*"})
 	allowedMethods := handlers.AllowedMethods([]string{"GET", "POST", "PUT", "DELETE"})
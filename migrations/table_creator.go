@@ -204,7 +204,7 @@ func CreateOrdersTable(db *sql.DB) error {
 			id SERIAL PRIMARY KEY,
 			user_id BIGINT NOT NULL REFERENCES users(id),
 			supplier_id BIGINT NOT NULL REFERENCES suppliers(id),
-		    address_id BIGINT NOT NULL REFERENCES addresses(id),
+		    address_id BIGINT REFERENCES addresses(id),
 			tracking_id VARCHAR(50) NOT NULL,
 			status VARCHAR(50) NOT NULL,
 			price NUMERIC(10, 2) NOT NULL,
@@ -248,3 +248,979 @@ func CreateOrderItemsTable(db *sql.DB) error {
 	}
 	return nil
 }
+
+func CreateSupplierOpeningHoursTable(db *sql.DB) error {
+	openingHoursTableExists := false
+	err := db.QueryRow("SELECT EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'supplier_opening_hours')").Scan(&openingHoursTableExists)
+	if err != nil {
+		return err
+	}
+	if !openingHoursTableExists {
+		openingHoursTableQuery := `
+		CREATE TABLE IF NOT EXISTS supplier_opening_hours (
+			id SERIAL PRIMARY KEY,
+			supplier_id BIGINT NOT NULL REFERENCES suppliers(id) ON DELETE CASCADE,
+			weekday SMALLINT NOT NULL CHECK (weekday BETWEEN 0 AND 6),
+			opens_at VARCHAR(5) NOT NULL,
+			closes_at VARCHAR(5) NOT NULL
+		)
+	`
+		_, err = db.Exec(openingHoursTableQuery)
+		if err != nil {
+			return fmt.Errorf("failed to create supplier_opening_hours table: %v", err)
+		}
+		log.Println("supplier_opening_hours table created successfully")
+	} else {
+		log.Println("supplier_opening_hours table already exists")
+	}
+	return nil
+}
+
+func CreateSupplierHolidaysTable(db *sql.DB) error {
+	holidaysTableExists := false
+	err := db.QueryRow("SELECT EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'supplier_holidays')").Scan(&holidaysTableExists)
+	if err != nil {
+		return err
+	}
+	if !holidaysTableExists {
+		holidaysTableQuery := `
+		CREATE TABLE IF NOT EXISTS supplier_holidays (
+			id SERIAL PRIMARY KEY,
+			supplier_id BIGINT NOT NULL REFERENCES suppliers(id) ON DELETE CASCADE,
+			date DATE NOT NULL,
+			reason VARCHAR(255) NOT NULL DEFAULT '',
+			UNIQUE (supplier_id, date)
+		)
+	`
+		_, err = db.Exec(holidaysTableQuery)
+		if err != nil {
+			return fmt.Errorf("failed to create supplier_holidays table: %v", err)
+		}
+		log.Println("supplier_holidays table created successfully")
+	} else {
+		log.Println("supplier_holidays table already exists")
+	}
+	return nil
+}
+
+func CreateCartsTable(db *sql.DB) error {
+	cartsTableExists := false
+	err := db.QueryRow("SELECT EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'carts')").Scan(&cartsTableExists)
+	if err != nil {
+		return err
+	}
+	if !cartsTableExists {
+		cartsTableQuery := `
+		CREATE TABLE IF NOT EXISTS carts (
+			id SERIAL PRIMARY KEY,
+			user_id BIGINT NOT NULL UNIQUE REFERENCES users(id),
+			supplier_id BIGINT REFERENCES suppliers(id),
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)
+	`
+		_, err = db.Exec(cartsTableQuery)
+		if err != nil {
+			return fmt.Errorf("failed to create carts table: %v", err)
+		}
+		log.Println("carts table created successfully")
+	} else {
+		log.Println("carts table already exists")
+	}
+	return nil
+}
+
+func CreateCartItemsTable(db *sql.DB) error {
+	cartItemsTableExists := false
+	err := db.QueryRow("SELECT EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'cart_items')").Scan(&cartItemsTableExists)
+	if err != nil {
+		return err
+	}
+	if !cartItemsTableExists {
+		cartItemsTableQuery := `
+		CREATE TABLE IF NOT EXISTS cart_items (
+			id SERIAL PRIMARY KEY,
+			cart_id BIGINT NOT NULL REFERENCES carts(id) ON DELETE CASCADE,
+			food_id BIGINT NOT NULL REFERENCES foods(id) ON DELETE CASCADE,
+			quantity SMALLINT NOT NULL,
+			unit_price NUMERIC(10, 2) NOT NULL
+		)
+	`
+		_, err = db.Exec(cartItemsTableQuery)
+		if err != nil {
+			return fmt.Errorf("failed to create cart_items table: %v", err)
+		}
+		log.Println("cart_items table created successfully")
+	} else {
+		log.Println("cart_items table already exists")
+	}
+	return nil
+}
+
+func CreateModifierGroupsTable(db *sql.DB) error {
+	modifierGroupsTableExists := false
+	err := db.QueryRow("SELECT EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'modifier_groups')").Scan(&modifierGroupsTableExists)
+	if err != nil {
+		return err
+	}
+	if !modifierGroupsTableExists {
+		modifierGroupsTableQuery := `
+		CREATE TABLE IF NOT EXISTS modifier_groups (
+			id SERIAL PRIMARY KEY,
+			food_id BIGINT NOT NULL REFERENCES foods(id) ON DELETE CASCADE,
+			name VARCHAR(255) NOT NULL,
+			required BOOLEAN NOT NULL DEFAULT FALSE,
+			min_selections INT NOT NULL DEFAULT 0,
+			max_selections INT NOT NULL DEFAULT 0
+		)
+	`
+		_, err = db.Exec(modifierGroupsTableQuery)
+		if err != nil {
+			return fmt.Errorf("failed to create modifier_groups table: %v", err)
+		}
+		log.Println("modifier_groups table created successfully")
+	} else {
+		log.Println("modifier_groups table already exists")
+	}
+	return nil
+}
+
+func CreateModifierOptionsTable(db *sql.DB) error {
+	modifierOptionsTableExists := false
+	err := db.QueryRow("SELECT EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'modifier_options')").Scan(&modifierOptionsTableExists)
+	if err != nil {
+		return err
+	}
+	if !modifierOptionsTableExists {
+		modifierOptionsTableQuery := `
+		CREATE TABLE IF NOT EXISTS modifier_options (
+			id SERIAL PRIMARY KEY,
+			group_id BIGINT NOT NULL REFERENCES modifier_groups(id) ON DELETE CASCADE,
+			name VARCHAR(255) NOT NULL,
+			price_delta NUMERIC(10, 2) NOT NULL DEFAULT 0
+		)
+	`
+		_, err = db.Exec(modifierOptionsTableQuery)
+		if err != nil {
+			return fmt.Errorf("failed to create modifier_options table: %v", err)
+		}
+		log.Println("modifier_options table created successfully")
+	} else {
+		log.Println("modifier_options table already exists")
+	}
+	return nil
+}
+
+func CreateOrderItemModifiersTable(db *sql.DB) error {
+	orderItemModifiersTableExists := false
+	err := db.QueryRow("SELECT EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'order_item_modifiers')").Scan(&orderItemModifiersTableExists)
+	if err != nil {
+		return err
+	}
+	if !orderItemModifiersTableExists {
+		orderItemModifiersTableQuery := `
+		CREATE TABLE IF NOT EXISTS order_item_modifiers (
+			id SERIAL PRIMARY KEY,
+			order_item_id BIGINT NOT NULL REFERENCES order_items(id) ON DELETE CASCADE,
+			modifier_option_id BIGINT REFERENCES modifier_options(id) ON DELETE SET NULL,
+			group_name VARCHAR(255) NOT NULL,
+			name VARCHAR(255) NOT NULL,
+			price_delta NUMERIC(10, 2) NOT NULL
+		)
+	`
+		_, err = db.Exec(orderItemModifiersTableQuery)
+		if err != nil {
+			return fmt.Errorf("failed to create order_item_modifiers table: %v", err)
+		}
+		log.Println("order_item_modifiers table created successfully")
+	} else {
+		log.Println("order_item_modifiers table already exists")
+	}
+	return nil
+}
+
+func CreateCartItemModifiersTable(db *sql.DB) error {
+	cartItemModifiersTableExists := false
+	err := db.QueryRow("SELECT EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'cart_item_modifiers')").Scan(&cartItemModifiersTableExists)
+	if err != nil {
+		return err
+	}
+	if !cartItemModifiersTableExists {
+		cartItemModifiersTableQuery := `
+		CREATE TABLE IF NOT EXISTS cart_item_modifiers (
+			id SERIAL PRIMARY KEY,
+			cart_item_id BIGINT NOT NULL REFERENCES cart_items(id) ON DELETE CASCADE,
+			modifier_option_id BIGINT NOT NULL REFERENCES modifier_options(id) ON DELETE CASCADE
+		)
+	`
+		_, err = db.Exec(cartItemModifiersTableQuery)
+		if err != nil {
+			return fmt.Errorf("failed to create cart_item_modifiers table: %v", err)
+		}
+		log.Println("cart_item_modifiers table created successfully")
+	} else {
+		log.Println("cart_item_modifiers table already exists")
+	}
+	return nil
+}
+
+func CreateTaxRatesTable(db *sql.DB) error {
+	taxRatesTableExists := false
+	err := db.QueryRow("SELECT EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'tax_rates')").Scan(&taxRatesTableExists)
+	if err != nil {
+		return err
+	}
+	if !taxRatesTableExists {
+		taxRatesTableQuery := `
+		CREATE TABLE IF NOT EXISTS tax_rates (
+			id SERIAL PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			category_id BIGINT UNIQUE REFERENCES categories(id) ON DELETE CASCADE,
+			rate NUMERIC(6, 4) NOT NULL
+		)
+	`
+		_, err = db.Exec(taxRatesTableQuery)
+		if err != nil {
+			return fmt.Errorf("failed to create tax_rates table: %v", err)
+		}
+		log.Println("tax_rates table created successfully")
+	} else {
+		log.Println("tax_rates table already exists")
+	}
+	return nil
+}
+
+func CreateDeliveryZonesTable(db *sql.DB) error {
+	zonesTableExists := false
+	err := db.QueryRow("SELECT EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'delivery_zones')").Scan(&zonesTableExists)
+	if err != nil {
+		return err
+	}
+	if !zonesTableExists {
+		zonesTableQuery := `
+		CREATE TABLE IF NOT EXISTS delivery_zones (
+			id SERIAL PRIMARY KEY,
+			supplier_id BIGINT NOT NULL REFERENCES suppliers(id) ON DELETE CASCADE,
+			name VARCHAR(255) NOT NULL DEFAULT '',
+			postcodes TEXT[] NOT NULL DEFAULT '{}',
+			polygon JSONB NOT NULL DEFAULT '[]',
+			delivery_fee NUMERIC(10, 2) NOT NULL DEFAULT 0,
+			delivery_time INT NOT NULL DEFAULT 0
+		)
+	`
+		_, err = db.Exec(zonesTableQuery)
+		if err != nil {
+			return fmt.Errorf("failed to create delivery_zones table: %v", err)
+		}
+		log.Println("delivery_zones table created successfully")
+	} else {
+		log.Println("delivery_zones table already exists")
+	}
+	return nil
+}
+
+func CreateCouriersTable(db *sql.DB) error {
+	couriersTableExists := false
+	err := db.QueryRow("SELECT EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'couriers')").Scan(&couriersTableExists)
+	if err != nil {
+		return err
+	}
+	if !couriersTableExists {
+		couriersTableQuery := `
+		CREATE TABLE IF NOT EXISTS couriers (
+			id SERIAL PRIMARY KEY,
+			user_id BIGINT NOT NULL UNIQUE REFERENCES users(id),
+			name VARCHAR(255) NOT NULL,
+			phone VARCHAR(50) NOT NULL,
+			vehicle VARCHAR(50) NOT NULL DEFAULT '',
+			active BOOLEAN NOT NULL DEFAULT TRUE,
+			latitude DOUBLE PRECISION,
+			longitude DOUBLE PRECISION
+		)
+	`
+		_, err = db.Exec(couriersTableQuery)
+		if err != nil {
+			return fmt.Errorf("failed to create couriers table: %v", err)
+		}
+		log.Println("couriers table created successfully")
+	} else {
+		log.Println("couriers table already exists")
+	}
+	return nil
+}
+
+func CreateCourierShiftsTable(db *sql.DB) error {
+	shiftsTableExists := false
+	err := db.QueryRow("SELECT EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'courier_shifts')").Scan(&shiftsTableExists)
+	if err != nil {
+		return err
+	}
+	if !shiftsTableExists {
+		shiftsTableQuery := `
+		CREATE TABLE IF NOT EXISTS courier_shifts (
+			id SERIAL PRIMARY KEY,
+			courier_id BIGINT NOT NULL REFERENCES couriers(id) ON DELETE CASCADE,
+			starts_at TIMESTAMPTZ NOT NULL,
+			ends_at TIMESTAMPTZ NOT NULL
+		)
+	`
+		_, err = db.Exec(shiftsTableQuery)
+		if err != nil {
+			return fmt.Errorf("failed to create courier_shifts table: %v", err)
+		}
+		log.Println("courier_shifts table created successfully")
+	} else {
+		log.Println("courier_shifts table already exists")
+	}
+	return nil
+}
+
+func CreateDeliveryProofsTable(db *sql.DB) error {
+	proofsTableExists := false
+	err := db.QueryRow("SELECT EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'delivery_proofs')").Scan(&proofsTableExists)
+	if err != nil {
+		return err
+	}
+	if !proofsTableExists {
+		proofsTableQuery := `
+		CREATE TABLE IF NOT EXISTS delivery_proofs (
+			id SERIAL PRIMARY KEY,
+			order_id BIGINT NOT NULL UNIQUE REFERENCES orders(id) ON DELETE CASCADE,
+			courier_id BIGINT NOT NULL REFERENCES couriers(id),
+			method VARCHAR(20) NOT NULL,
+			photo_url VARCHAR(255) NOT NULL DEFAULT '',
+			latitude DOUBLE PRECISION,
+			longitude DOUBLE PRECISION,
+			distance_m DOUBLE PRECISION NOT NULL DEFAULT 0,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)
+	`
+		_, err = db.Exec(proofsTableQuery)
+		if err != nil {
+			return fmt.Errorf("failed to create delivery_proofs table: %v", err)
+		}
+		log.Println("delivery_proofs table created successfully")
+	} else {
+		log.Println("delivery_proofs table already exists")
+	}
+	return nil
+}
+
+func CreateReviewsTable(db *sql.DB) error {
+	reviewsTableExists := false
+	err := db.QueryRow("SELECT EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'reviews')").Scan(&reviewsTableExists)
+	if err != nil {
+		return err
+	}
+	if !reviewsTableExists {
+		reviewsTableQuery := `
+		CREATE TABLE IF NOT EXISTS reviews (
+			id SERIAL PRIMARY KEY,
+			order_id BIGINT NOT NULL UNIQUE REFERENCES orders(id) ON DELETE CASCADE,
+			user_id BIGINT NOT NULL REFERENCES users(id),
+			supplier_id BIGINT NOT NULL REFERENCES suppliers(id) ON DELETE CASCADE,
+			supplier_rating SMALLINT NOT NULL CHECK (supplier_rating BETWEEN 1 AND 5),
+			courier_id BIGINT REFERENCES couriers(id) ON DELETE SET NULL,
+			courier_rating SMALLINT CHECK (courier_rating BETWEEN 1 AND 5),
+			comment TEXT NOT NULL DEFAULT '',
+			reply TEXT NOT NULL DEFAULT '',
+			replied_at TIMESTAMPTZ,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)
+	`
+		_, err = db.Exec(reviewsTableQuery)
+		if err != nil {
+			return fmt.Errorf("failed to create reviews table: %v", err)
+		}
+		log.Println("reviews table created successfully")
+	} else {
+		log.Println("reviews table already exists")
+	}
+	return nil
+}
+
+func CreateFoodReviewsTable(db *sql.DB) error {
+	foodReviewsTableExists := false
+	err := db.QueryRow("SELECT EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'food_reviews')").Scan(&foodReviewsTableExists)
+	if err != nil {
+		return err
+	}
+	if !foodReviewsTableExists {
+		foodReviewsTableQuery := `
+		CREATE TABLE IF NOT EXISTS food_reviews (
+			id SERIAL PRIMARY KEY,
+			review_id BIGINT NOT NULL REFERENCES reviews(id) ON DELETE CASCADE,
+			food_id BIGINT NOT NULL REFERENCES foods(id) ON DELETE CASCADE,
+			rating SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+			comment TEXT NOT NULL DEFAULT '',
+			UNIQUE (review_id, food_id)
+		)
+	`
+		_, err = db.Exec(foodReviewsTableQuery)
+		if err != nil {
+			return fmt.Errorf("failed to create food_reviews table: %v", err)
+		}
+		log.Println("food_reviews table created successfully")
+	} else {
+		log.Println("food_reviews table already exists")
+	}
+	return nil
+}
+
+func CreateOrderTipsTable(db *sql.DB) error {
+	orderTipsTableExists := false
+	err := db.QueryRow("SELECT EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'order_tips')").Scan(&orderTipsTableExists)
+	if err != nil {
+		return err
+	}
+	if !orderTipsTableExists {
+		orderTipsTableQuery := `
+		CREATE TABLE IF NOT EXISTS order_tips (
+			id SERIAL PRIMARY KEY,
+			order_id BIGINT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+			recipient VARCHAR(20) NOT NULL,
+			type VARCHAR(20) NOT NULL,
+			value NUMERIC(10, 2) NOT NULL,
+			amount NUMERIC(10, 2) NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			UNIQUE (order_id, recipient)
+		)
+	`
+		_, err = db.Exec(orderTipsTableQuery)
+		if err != nil {
+			return fmt.Errorf("failed to create order_tips table: %v", err)
+		}
+		log.Println("order_tips table created successfully")
+	} else {
+		log.Println("order_tips table already exists")
+	}
+	return nil
+}
+
+func CreatePaymentsTable(db *sql.DB) error {
+	paymentsTableExists := false
+	err := db.QueryRow("SELECT EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'payments')").Scan(&paymentsTableExists)
+	if err != nil {
+		return err
+	}
+	if !paymentsTableExists {
+		paymentsTableQuery := `
+		CREATE TABLE IF NOT EXISTS payments (
+			id SERIAL PRIMARY KEY,
+			order_id BIGINT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+			provider VARCHAR(50) NOT NULL,
+			reference VARCHAR(255) NOT NULL DEFAULT '',
+			method VARCHAR(255) NOT NULL DEFAULT '',
+			status VARCHAR(20) NOT NULL,
+			amount NUMERIC(10, 2) NOT NULL,
+			captured_amount NUMERIC(10, 2) NOT NULL DEFAULT 0,
+			refunded_amount NUMERIC(10, 2) NOT NULL DEFAULT 0,
+			message TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+		CREATE INDEX IF NOT EXISTS payments_order_id_idx ON payments (order_id);
+		CREATE UNIQUE INDEX IF NOT EXISTS payments_reference_idx ON payments (provider, reference) WHERE reference <> '';
+	`
+		_, err = db.Exec(paymentsTableQuery)
+		if err != nil {
+			return fmt.Errorf("failed to create payments table: %v", err)
+		}
+		log.Println("payments table created successfully")
+	} else {
+		log.Println("payments table already exists")
+	}
+	return nil
+}
+
+func CreatePaymentEventsTable(db *sql.DB) error {
+	paymentEventsTableExists := false
+	err := db.QueryRow("SELECT EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'payment_events')").Scan(&paymentEventsTableExists)
+	if err != nil {
+		return err
+	}
+	if !paymentEventsTableExists {
+		paymentEventsTableQuery := `
+		CREATE TABLE IF NOT EXISTS payment_events (
+			event_id VARCHAR(255) PRIMARY KEY,
+			processed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)
+	`
+		_, err = db.Exec(paymentEventsTableQuery)
+		if err != nil {
+			return fmt.Errorf("failed to create payment_events table: %v", err)
+		}
+		log.Println("payment_events table created successfully")
+	} else {
+		log.Println("payment_events table already exists")
+	}
+	return nil
+}
+
+func CreateLedgerAccountsTable(db *sql.DB) error {
+	ledgerAccountsTableExists := false
+	err := db.QueryRow("SELECT EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'ledger_accounts')").Scan(&ledgerAccountsTableExists)
+	if err != nil {
+		return err
+	}
+	if !ledgerAccountsTableExists {
+		ledgerAccountsTableQuery := `
+		CREATE TABLE IF NOT EXISTS ledger_accounts (
+			id SERIAL PRIMARY KEY,
+			user_id BIGINT UNIQUE REFERENCES users(id) ON DELETE CASCADE,
+			code VARCHAR(50) UNIQUE,
+			CHECK ((user_id IS NULL) <> (code IS NULL))
+		)
+	`
+		_, err = db.Exec(ledgerAccountsTableQuery)
+		if err != nil {
+			return fmt.Errorf("failed to create ledger_accounts table: %v", err)
+		}
+		log.Println("ledger_accounts table created successfully")
+	} else {
+		log.Println("ledger_accounts table already exists")
+	}
+	return nil
+}
+
+func CreateLedgerTransactionsTable(db *sql.DB) error {
+	ledgerTransactionsTableExists := false
+	err := db.QueryRow("SELECT EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'ledger_transactions')").Scan(&ledgerTransactionsTableExists)
+	if err != nil {
+		return err
+	}
+	if !ledgerTransactionsTableExists {
+		ledgerTransactionsTableQuery := `
+		CREATE TABLE IF NOT EXISTS ledger_transactions (
+			id SERIAL PRIMARY KEY,
+			type VARCHAR(20) NOT NULL,
+			description TEXT NOT NULL DEFAULT '',
+			order_id BIGINT REFERENCES orders(id) ON DELETE SET NULL,
+			reference VARCHAR(255) NOT NULL DEFAULT '',
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+		CREATE UNIQUE INDEX IF NOT EXISTS ledger_transactions_order_idx ON ledger_transactions (order_id, type)
+			WHERE type IN ('order_payment', 'order_reversal');
+	`
+		_, err = db.Exec(ledgerTransactionsTableQuery)
+		if err != nil {
+			return fmt.Errorf("failed to create ledger_transactions table: %v", err)
+		}
+		log.Println("ledger_transactions table created successfully")
+	} else {
+		log.Println("ledger_transactions table already exists")
+	}
+	return nil
+}
+
+func CreateLedgerEntriesTable(db *sql.DB) error {
+	ledgerEntriesTableExists := false
+	err := db.QueryRow("SELECT EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'ledger_entries')").Scan(&ledgerEntriesTableExists)
+	if err != nil {
+		return err
+	}
+	if !ledgerEntriesTableExists {
+		ledgerEntriesTableQuery := `
+		CREATE TABLE IF NOT EXISTS ledger_entries (
+			id SERIAL PRIMARY KEY,
+			transaction_id BIGINT NOT NULL REFERENCES ledger_transactions(id) ON DELETE CASCADE,
+			account_id BIGINT NOT NULL REFERENCES ledger_accounts(id),
+			amount NUMERIC(12, 2) NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS ledger_entries_account_id_idx ON ledger_entries (account_id);
+	`
+		_, err = db.Exec(ledgerEntriesTableQuery)
+		if err != nil {
+			return fmt.Errorf("failed to create ledger_entries table: %v", err)
+		}
+		log.Println("ledger_entries table created successfully")
+	} else {
+		log.Println("ledger_entries table already exists")
+	}
+	return nil
+}
+
+func CreateCouponsTable(db *sql.DB) error {
+	couponsTableExists := false
+	err := db.QueryRow("SELECT EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'coupons')").Scan(&couponsTableExists)
+	if err != nil {
+		return err
+	}
+	if !couponsTableExists {
+		couponsTableQuery := `
+		CREATE TABLE IF NOT EXISTS coupons (
+			id SERIAL PRIMARY KEY,
+			code VARCHAR(50) NOT NULL UNIQUE,
+			description TEXT NOT NULL DEFAULT '',
+			type VARCHAR(20) NOT NULL,
+			value NUMERIC(10, 2) NOT NULL,
+			max_discount NUMERIC(10, 2) NOT NULL DEFAULT 0,
+			min_subtotal NUMERIC(10, 2) NOT NULL DEFAULT 0,
+			first_order_only BOOLEAN NOT NULL DEFAULT FALSE,
+			supplier_ids BIGINT[] NOT NULL DEFAULT '{}',
+			category_ids BIGINT[] NOT NULL DEFAULT '{}',
+			usage_limit INT NOT NULL DEFAULT 0,
+			per_user_limit INT NOT NULL DEFAULT 0,
+			used_count INT NOT NULL DEFAULT 0,
+			starts_at TIMESTAMPTZ,
+			ends_at TIMESTAMPTZ,
+			active BOOLEAN NOT NULL DEFAULT TRUE
+		)
+	`
+		_, err = db.Exec(couponsTableQuery)
+		if err != nil {
+			return fmt.Errorf("failed to create coupons table: %v", err)
+		}
+		log.Println("coupons table created successfully")
+	} else {
+		log.Println("coupons table already exists")
+	}
+	return nil
+}
+
+func CreateCouponRedemptionsTable(db *sql.DB) error {
+	couponRedemptionsTableExists := false
+	err := db.QueryRow("SELECT EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'coupon_redemptions')").Scan(&couponRedemptionsTableExists)
+	if err != nil {
+		return err
+	}
+	if !couponRedemptionsTableExists {
+		couponRedemptionsTableQuery := `
+		CREATE TABLE IF NOT EXISTS coupon_redemptions (
+			id SERIAL PRIMARY KEY,
+			coupon_id BIGINT NOT NULL REFERENCES coupons(id),
+			user_id BIGINT NOT NULL REFERENCES users(id),
+			order_id BIGINT NOT NULL UNIQUE REFERENCES orders(id) ON DELETE CASCADE,
+			amount NUMERIC(10, 2) NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)
+	`
+		_, err = db.Exec(couponRedemptionsTableQuery)
+		if err != nil {
+			return fmt.Errorf("failed to create coupon_redemptions table: %v", err)
+		}
+		log.Println("coupon_redemptions table created successfully")
+	} else {
+		log.Println("coupon_redemptions table already exists")
+	}
+	return nil
+}
+
+func CreateLoyaltyTransactionsTable(db *sql.DB) error {
+	loyaltyTransactionsTableExists := false
+	err := db.QueryRow("SELECT EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'loyalty_transactions')").Scan(&loyaltyTransactionsTableExists)
+	if err != nil {
+		return err
+	}
+	if !loyaltyTransactionsTableExists {
+		loyaltyTransactionsTableQuery := `
+		CREATE TABLE IF NOT EXISTS loyalty_transactions (
+			id SERIAL PRIMARY KEY,
+			user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			type VARCHAR(20) NOT NULL,
+			points INT NOT NULL,
+			remaining INT NOT NULL DEFAULT 0,
+			order_id BIGINT REFERENCES orders(id) ON DELETE SET NULL,
+			description TEXT NOT NULL DEFAULT '',
+			expires_at TIMESTAMPTZ,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+		CREATE INDEX IF NOT EXISTS loyalty_transactions_user_idx ON loyalty_transactions (user_id);
+		CREATE UNIQUE INDEX IF NOT EXISTS loyalty_transactions_order_idx ON loyalty_transactions (order_id, type)
+			WHERE type IN ('earn', 'redeem', 'restore');
+	`
+		_, err = db.Exec(loyaltyTransactionsTableQuery)
+		if err != nil {
+			return fmt.Errorf("failed to create loyalty_transactions table: %v", err)
+		}
+		log.Println("loyalty_transactions table created successfully")
+	} else {
+		log.Println("loyalty_transactions table already exists")
+	}
+	return nil
+}
+
+func CreateReferralsTable(db *sql.DB) error {
+	referralsTableExists := false
+	err := db.QueryRow("SELECT EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'referrals')").Scan(&referralsTableExists)
+	if err != nil {
+		return err
+	}
+	if !referralsTableExists {
+		referralsTableQuery := `
+		CREATE TABLE IF NOT EXISTS referrals (
+			id SERIAL PRIMARY KEY,
+			referrer_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			referred_id BIGINT NOT NULL UNIQUE REFERENCES users(id) ON DELETE CASCADE,
+			device_id VARCHAR(255) NOT NULL DEFAULT '',
+			status VARCHAR(20) NOT NULL DEFAULT 'pending',
+			reason TEXT NOT NULL DEFAULT '',
+			order_id BIGINT REFERENCES orders(id) ON DELETE SET NULL,
+			referrer_coupon VARCHAR(50) NOT NULL DEFAULT '',
+			referred_coupon VARCHAR(50) NOT NULL DEFAULT '',
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			completed_at TIMESTAMPTZ
+		);
+		CREATE INDEX IF NOT EXISTS referrals_referrer_idx ON referrals (referrer_id);
+	`
+		_, err = db.Exec(referralsTableQuery)
+		if err != nil {
+			return fmt.Errorf("failed to create referrals table: %v", err)
+		}
+		log.Println("referrals table created successfully")
+	} else {
+		log.Println("referrals table already exists")
+	}
+	return nil
+}
+
+func CreateCommissionRatesTable(db *sql.DB) error {
+	commissionRatesTableExists := false
+	err := db.QueryRow("SELECT EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'commission_rates')").Scan(&commissionRatesTableExists)
+	if err != nil {
+		return err
+	}
+	if !commissionRatesTableExists {
+		commissionRatesTableQuery := `
+		CREATE TABLE IF NOT EXISTS commission_rates (
+			id SERIAL PRIMARY KEY,
+			supplier_id BIGINT NOT NULL REFERENCES suppliers(id) ON DELETE CASCADE,
+			rate NUMERIC(5, 4) NOT NULL,
+			starts_on DATE NOT NULL,
+			ends_on DATE
+		)
+	`
+		_, err = db.Exec(commissionRatesTableQuery)
+		if err != nil {
+			return fmt.Errorf("failed to create commission_rates table: %v", err)
+		}
+		log.Println("commission_rates table created successfully")
+	} else {
+		log.Println("commission_rates table already exists")
+	}
+	return nil
+}
+
+func CreateSettlementsTable(db *sql.DB) error {
+	settlementsTableExists := false
+	err := db.QueryRow("SELECT EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'settlements')").Scan(&settlementsTableExists)
+	if err != nil {
+		return err
+	}
+	if !settlementsTableExists {
+		settlementsTableQuery := `
+		CREATE TABLE IF NOT EXISTS settlements (
+			id SERIAL PRIMARY KEY,
+			supplier_id BIGINT NOT NULL REFERENCES suppliers(id),
+			period_from DATE NOT NULL,
+			period_to DATE NOT NULL,
+			orders INT NOT NULL DEFAULT 0,
+			sales NUMERIC(10, 2) NOT NULL DEFAULT 0,
+			commission NUMERIC(10, 2) NOT NULL DEFAULT 0,
+			refunds NUMERIC(10, 2) NOT NULL DEFAULT 0,
+			tips NUMERIC(10, 2) NOT NULL DEFAULT 0,
+			adjustments NUMERIC(10, 2) NOT NULL DEFAULT 0,
+			payout NUMERIC(10, 2) NOT NULL DEFAULT 0,
+			status VARCHAR(20) NOT NULL DEFAULT 'pending',
+			payout_reference VARCHAR(255) NOT NULL DEFAULT '',
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			paid_at TIMESTAMPTZ
+		)
+	`
+		_, err = db.Exec(settlementsTableQuery)
+		if err != nil {
+			return fmt.Errorf("failed to create settlements table: %v", err)
+		}
+		log.Println("settlements table created successfully")
+	} else {
+		log.Println("settlements table already exists")
+	}
+	return nil
+}
+
+func CreateSettlementOrdersTable(db *sql.DB) error {
+	settlementOrdersTableExists := false
+	err := db.QueryRow("SELECT EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'settlement_orders')").Scan(&settlementOrdersTableExists)
+	if err != nil {
+		return err
+	}
+	if !settlementOrdersTableExists {
+		settlementOrdersTableQuery := `
+		CREATE TABLE IF NOT EXISTS settlement_orders (
+			id SERIAL PRIMARY KEY,
+			settlement_id BIGINT NOT NULL REFERENCES settlements(id) ON DELETE CASCADE,
+			order_id BIGINT NOT NULL UNIQUE REFERENCES orders(id),
+			delivered_at TIMESTAMPTZ NOT NULL,
+			sales NUMERIC(10, 2) NOT NULL,
+			commission_rate NUMERIC(5, 4) NOT NULL,
+			commission NUMERIC(10, 2) NOT NULL,
+			refunds NUMERIC(10, 2) NOT NULL,
+			tips NUMERIC(10, 2) NOT NULL,
+			net NUMERIC(10, 2) NOT NULL
+		)
+	`
+		_, err = db.Exec(settlementOrdersTableQuery)
+		if err != nil {
+			return fmt.Errorf("failed to create settlement_orders table: %v", err)
+		}
+		log.Println("settlement_orders table created successfully")
+	} else {
+		log.Println("settlement_orders table already exists")
+	}
+	return nil
+}
+
+func CreateSettlementAdjustmentsTable(db *sql.DB) error {
+	settlementAdjustmentsTableExists := false
+	err := db.QueryRow("SELECT EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'settlement_adjustments')").Scan(&settlementAdjustmentsTableExists)
+	if err != nil {
+		return err
+	}
+	if !settlementAdjustmentsTableExists {
+		settlementAdjustmentsTableQuery := `
+		CREATE TABLE IF NOT EXISTS settlement_adjustments (
+			id SERIAL PRIMARY KEY,
+			supplier_id BIGINT NOT NULL REFERENCES suppliers(id) ON DELETE CASCADE,
+			settlement_id BIGINT REFERENCES settlements(id) ON DELETE SET NULL,
+			amount NUMERIC(10, 2) NOT NULL,
+			description TEXT NOT NULL,
+			effective_on DATE NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)
+	`
+		_, err = db.Exec(settlementAdjustmentsTableQuery)
+		if err != nil {
+			return fmt.Errorf("failed to create settlement_adjustments table: %v", err)
+		}
+		log.Println("settlement_adjustments table created successfully")
+	} else {
+		log.Println("settlement_adjustments table already exists")
+	}
+	return nil
+}
+
+func CreateOrderTaxesTable(db *sql.DB) error {
+	orderTaxesTableExists := false
+	err := db.QueryRow("SELECT EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'order_taxes')").Scan(&orderTaxesTableExists)
+	if err != nil {
+		return err
+	}
+	if !orderTaxesTableExists {
+		orderTaxesTableQuery := `
+		CREATE TABLE IF NOT EXISTS order_taxes (
+			id SERIAL PRIMARY KEY,
+			order_id BIGINT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+			name VARCHAR(100) NOT NULL,
+			rate NUMERIC(5, 4) NOT NULL,
+			base NUMERIC(10, 2) NOT NULL,
+			amount NUMERIC(10, 2) NOT NULL
+		)
+	`
+		_, err = db.Exec(orderTaxesTableQuery)
+		if err != nil {
+			return fmt.Errorf("failed to create order_taxes table: %v", err)
+		}
+		log.Println("order_taxes table created successfully")
+	} else {
+		log.Println("order_taxes table already exists")
+	}
+	return nil
+}
+
+func CreateInvoicesTable(db *sql.DB) error {
+	invoicesTableExists := false
+	err := db.QueryRow("SELECT EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'invoices')").Scan(&invoicesTableExists)
+	if err != nil {
+		return err
+	}
+	if !invoicesTableExists {
+		invoicesTableQuery := `
+		CREATE TABLE IF NOT EXISTS invoices (
+			id SERIAL PRIMARY KEY,
+			number VARCHAR(20) NOT NULL UNIQUE,
+			year INT NOT NULL,
+			sequence INT NOT NULL,
+			order_id BIGINT NOT NULL UNIQUE REFERENCES orders(id),
+			supplier_name VARCHAR(255) NOT NULL,
+			supplier_address TEXT NOT NULL,
+			customer_name VARCHAR(255) NOT NULL,
+			customer_email VARCHAR(255) NOT NULL,
+			customer_phone VARCHAR(50) NOT NULL,
+			customer_address TEXT NOT NULL,
+			issued_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			emailed_at TIMESTAMPTZ,
+			UNIQUE (year, sequence)
+		)
+	`
+		_, err = db.Exec(invoicesTableQuery)
+		if err != nil {
+			return fmt.Errorf("failed to create invoices table: %v", err)
+		}
+		log.Println("invoices table created successfully")
+	} else {
+		log.Println("invoices table already exists")
+	}
+	return nil
+}
+
+func CreateOrderFulfilmentChangesTable(db *sql.DB) error {
+	fulfilmentChangesTableExists := false
+	err := db.QueryRow("SELECT EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'order_fulfilment_changes')").Scan(&fulfilmentChangesTableExists)
+	if err != nil {
+		return err
+	}
+	if !fulfilmentChangesTableExists {
+		fulfilmentChangesTableQuery := `
+		CREATE TABLE IF NOT EXISTS order_fulfilment_changes (
+			id SERIAL PRIMARY KEY,
+			order_id BIGINT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+			order_item_id BIGINT NOT NULL,
+			food_id BIGINT REFERENCES foods(id) ON DELETE SET NULL,
+			food_name VARCHAR(255) NOT NULL,
+			quantity INT NOT NULL,
+			unit_price NUMERIC(10, 2) NOT NULL,
+			action VARCHAR(10) NOT NULL,
+			replacement_food_id BIGINT REFERENCES foods(id) ON DELETE SET NULL,
+			replacement_food_name VARCHAR(255) NOT NULL DEFAULT '',
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)
+	`
+		_, err = db.Exec(fulfilmentChangesTableQuery)
+		if err != nil {
+			return fmt.Errorf("failed to create order_fulfilment_changes table: %v", err)
+		}
+		log.Println("order_fulfilment_changes table created successfully")
+	} else {
+		log.Println("order_fulfilment_changes table already exists")
+	}
+	return nil
+}
+
+func CreateOrderHistoryTable(db *sql.DB) error {
+	orderHistoryTableExists := false
+	err := db.QueryRow("SELECT EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'order_history')").Scan(&orderHistoryTableExists)
+	if err != nil {
+		return err
+	}
+	if !orderHistoryTableExists {
+		orderHistoryTableQuery := `
+		CREATE TABLE IF NOT EXISTS order_history (
+			id SERIAL PRIMARY KEY,
+			order_id BIGINT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+			changes TEXT[] NOT NULL,
+			previous_address_id BIGINT NOT NULL,
+			address_id BIGINT NOT NULL,
+			previous_total NUMERIC(10, 2) NOT NULL,
+			total NUMERIC(10, 2) NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)
+	`
+		_, err = db.Exec(orderHistoryTableQuery)
+		if err != nil {
+			return fmt.Errorf("failed to create order_history table: %v", err)
+		}
+		log.Println("order_history table created successfully")
+	} else {
+		log.Println("order_history table already exists")
+	}
+	return nil
+}
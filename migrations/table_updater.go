@@ -0,0 +1,140 @@
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+)
+
+// addColumns adds the given column definitions to an existing table,
+// skipping the ones that are already there.
+func addColumns(db *sql.DB, table string, columns ...string) error {
+	for _, column := range columns {
+		_, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s", table, column))
+		if err != nil {
+			return fmt.Errorf("failed to update %s table: %v", table, err)
+		}
+	}
+	log.Printf("%s table columns are up to date", table)
+	return nil
+}
+
+// UpdateUsersTable adds the invite code of every user and the device they signed up on.
+func UpdateUsersTable(db *sql.DB) error {
+	return addColumns(db, "users",
+		"referral_code VARCHAR(20) UNIQUE",
+		"device_id VARCHAR(255) NOT NULL DEFAULT ''",
+	)
+}
+
+// UpdateSuppliersTable adds the columns introduced after the suppliers table was first created.
+func UpdateSuppliersTable(db *sql.DB) error {
+	return addColumns(db, "suppliers",
+		"time_zone VARCHAR(64) NOT NULL DEFAULT 'UTC'",
+		"prep_time INT NOT NULL DEFAULT 15",
+		"delivery_fee_type VARCHAR(20) NOT NULL DEFAULT 'flat'",
+		"delivery_fee NUMERIC(10, 2) NOT NULL DEFAULT 0",
+		"delivery_fee_per_km NUMERIC(10, 2) NOT NULL DEFAULT 0",
+		"free_delivery_above NUMERIC(10, 2) NOT NULL DEFAULT 0",
+		"min_order_subtotal NUMERIC(10, 2) NOT NULL DEFAULT 0",
+		"max_items INT NOT NULL DEFAULT 0",
+		"max_food_quantity INT NOT NULL DEFAULT 0",
+		"latitude DOUBLE PRECISION",
+		"longitude DOUBLE PRECISION",
+		"rating_average NUMERIC(3, 2) NOT NULL DEFAULT 0",
+		"rating_count INT NOT NULL DEFAULT 0",
+	)
+}
+
+// UpdateFoodsTable adds the columns introduced after the foods table was first created.
+func UpdateFoodsTable(db *sql.DB) error {
+	return addColumns(db, "foods",
+		"rating_average NUMERIC(3, 2) NOT NULL DEFAULT 0",
+		"rating_count INT NOT NULL DEFAULT 0",
+	)
+}
+
+// UpdateOrdersTable adds the columns introduced after the orders table was first created
+// and makes the address optional.
+func UpdateOrdersTable(db *sql.DB) error {
+	err := addColumns(db, "orders",
+		"requested_delivery_at TIMESTAMPTZ",
+		"release_at TIMESTAMPTZ",
+		"subtotal NUMERIC(10, 2) NOT NULL DEFAULT 0",
+		"delivery_fee NUMERIC(10, 2) NOT NULL DEFAULT 0",
+		"service_fee NUMERIC(10, 2) NOT NULL DEFAULT 0",
+		"tax NUMERIC(10, 2) NOT NULL DEFAULT 0",
+		"discount NUMERIC(10, 2) NOT NULL DEFAULT 0",
+		"courier_id BIGINT REFERENCES couriers(id) ON DELETE SET NULL",
+		"assigned_at TIMESTAMPTZ",
+		"picked_up_at TIMESTAMPTZ",
+		"delivered_at TIMESTAMPTZ",
+		"delivery_pin VARCHAR(4) NOT NULL DEFAULT ''",
+		"delivery_pin_attempts INT NOT NULL DEFAULT 0",
+		"tip NUMERIC(10, 2) NOT NULL DEFAULT 0",
+		"wallet_amount NUMERIC(10, 2) NOT NULL DEFAULT 0",
+		"loyalty_points INT NOT NULL DEFAULT 0",
+		"substitution_preference VARCHAR(10) NOT NULL DEFAULT 'remove'",
+		"confirmed_at TIMESTAMPTZ",
+		"fulfilment_type VARCHAR(10) NOT NULL DEFAULT 'delivery'",
+		"table_number VARCHAR(10) NOT NULL DEFAULT ''",
+		"pickup_code VARCHAR(4) NOT NULL DEFAULT ''",
+		"ready_at TIMESTAMPTZ",
+	)
+	if err != nil {
+		return err
+	}
+	// Pickup and dine-in orders have no delivery address.
+	_, err = db.Exec("ALTER TABLE orders ALTER COLUMN address_id DROP NOT NULL")
+	if err != nil {
+		return fmt.Errorf("failed to update orders table: %v", err)
+	}
+	return nil
+}
+
+// UpdateOrderItemsTable adds the columns introduced after the order_items table was first created.
+func UpdateOrderItemsTable(db *sql.DB) error {
+	return addColumns(db, "order_items",
+		"note TEXT NOT NULL DEFAULT ''",
+	)
+}
+
+// UpdateCartItemsTable adds the columns introduced after the cart_items table was first created.
+func UpdateCartItemsTable(db *sql.DB) error {
+	return addColumns(db, "cart_items",
+		"note TEXT NOT NULL DEFAULT ''",
+	)
+}
+
+// UpdateAddressesTable adds the structured fields and coordinates of addresses.
+func UpdateAddressesTable(db *sql.DB) error {
+	return addColumns(db, "addresses",
+		"street VARCHAR(255) NOT NULL DEFAULT ''",
+		"city VARCHAR(100) NOT NULL DEFAULT ''",
+		"country VARCHAR(100) NOT NULL DEFAULT ''",
+		"latitude DOUBLE PRECISION",
+		"longitude DOUBLE PRECISION",
+	)
+}
+
+// UpdateCouponsTable adds the user a coupon is restricted to, like a referral reward.
+func UpdateCouponsTable(db *sql.DB) error {
+	return addColumns(db, "coupons",
+		"user_id INT REFERENCES users(id)",
+	)
+}
+
+// UpdateSettlementAdjustmentsTable adds the order of the adjustments that carry a refund
+// given after the order was settled.
+func UpdateSettlementAdjustmentsTable(db *sql.DB) error {
+	return addColumns(db, "settlement_adjustments",
+		"order_id BIGINT REFERENCES orders(id)",
+	)
+}
+
+// UpdateCouriersTable adds the columns introduced after the couriers table was first created.
+func UpdateCouriersTable(db *sql.DB) error {
+	return addColumns(db, "couriers",
+		"located_at TIMESTAMPTZ",
+	)
+}
@@ -0,0 +1,20 @@
+package notification
+
+import (
+	"log"
+)
+
+type logNotifier struct{}
+
+// NewLogNotifier only logs the messages, for development without a mail server.
+func NewLogNotifier() Notifier {
+	return &logNotifier{}
+}
+
+func (ln *logNotifier) Send(message *Message) error {
+	if message.To == "" {
+		return ErrNoRecipient
+	}
+	log.Printf("Notification to %s: %s (%d attachments)", message.To, message.Subject, len(message.Attachments))
+	return nil
+}
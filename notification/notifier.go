@@ -0,0 +1,98 @@
+// Package notification delivers the messages of the platform to customers and suppliers.
+package notification
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"time"
+)
+
+var (
+	ErrNoRecipient = errors.New("message has no recipient")
+)
+
+// Attachment is a file sent along with a message.
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Content     []byte
+}
+
+// Message is an e-mail with an HTML body.
+type Message struct {
+	To          string
+	Subject     string
+	HTML        string
+	Attachments []Attachment
+}
+
+// Notifier sends messages.
+type Notifier interface {
+	Send(message *Message) error
+}
+
+// encodeMessage builds the MIME form of a message, ready to be handed to a mail server.
+func encodeMessage(from string, message *Message) ([]byte, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	htmlPart, err := writer.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/html; charset=UTF-8"},
+		"Content-Transfer-Encoding": {"base64"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = writeBase64(htmlPart, []byte(message.HTML))
+	if err != nil {
+		return nil, err
+	}
+	for _, attachment := range message.Attachments {
+		part, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {attachment.ContentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename})},
+		})
+		if err != nil {
+			return nil, err
+		}
+		err = writeBase64(part, attachment.Content)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = writer.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "From: %s\r\n", from)
+	fmt.Fprintf(&out, "To: %s\r\n", message.To)
+	fmt.Fprintf(&out, "Subject: %s\r\n", mime.QEncoding.Encode("UTF-8", message.Subject))
+	fmt.Fprintf(&out, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&out, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&out, "Content-Type: multipart/mixed; boundary=%q\r\n\r\n", writer.Boundary())
+	out.Write(body.Bytes())
+	return out.Bytes(), nil
+}
+
+// writeBase64 writes content in base64 lines of 76 characters.
+func writeBase64(w io.Writer, content []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(content)
+	for len(encoded) > 76 {
+		_, err := w.Write([]byte(encoded[:76] + "\r\n"))
+		if err != nil {
+			return err
+		}
+		encoded = encoded[76:]
+	}
+	_, err := w.Write([]byte(encoded + "\r\n"))
+	return err
+}
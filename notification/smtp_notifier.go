@@ -0,0 +1,36 @@
+package notification
+
+import (
+	"fmt"
+	"net/smtp"
+)
+
+type smtpNotifier struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+// NewSMTPNotifier sends e-mails through a mail server, authenticating when a username is given.
+func NewSMTPNotifier(host string, port int, username string, password string, from string) Notifier {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &smtpNotifier{
+		addr: fmt.Sprintf("%s:%d", host, port),
+		auth: auth,
+		from: from,
+	}
+}
+
+func (sn *smtpNotifier) Send(message *Message) error {
+	if message.To == "" {
+		return ErrNoRecipient
+	}
+	content, err := encodeMessage(sn.from, message)
+	if err != nil {
+		return err
+	}
+	return smtp.SendMail(sn.addr, sn.auth, sn.from, []string{message.To}, content)
+}
@@ -0,0 +1,89 @@
+package payment
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Payment methods the fake gateway understands, any other method is approved.
+const (
+	FakeMethodDecline = "tok_decline"
+	FakeMethodPending = "tok_pending"
+)
+
+var (
+	ErrInvalidAmount    = errors.New("amount must be positive")
+	ErrUnknownReference = errors.New("unknown payment reference")
+)
+
+// fakeGateway approves every payment except the FakeMethodDecline method and leaves
+// FakeMethodPending authorizations pending until a webhook settles them. References are
+// numbered in order from the start time, so they stay unique across restarts. It is meant
+// for tests and development.
+type fakeGateway struct {
+	secret string
+	mu     sync.Mutex
+	next   int64
+}
+
+func NewFakeGateway(webhookSecret string) Gateway {
+	return &fakeGateway{
+		secret: webhookSecret,
+		next:   time.Now().UnixNano(),
+	}
+}
+
+func (fg *fakeGateway) Name() string {
+	return "fake"
+}
+
+func (fg *fakeGateway) Authorize(request AuthorizeRequest) (*Result, error) {
+	if request.Amount <= 0 {
+		return nil, ErrInvalidAmount
+	}
+	fg.mu.Lock()
+	fg.next++
+	reference := fmt.Sprintf("fake_%d_%d", request.OrderID, fg.next)
+	fg.mu.Unlock()
+
+	switch request.Method {
+	case FakeMethodDecline:
+		return &Result{Reference: reference, Status: StatusFailed, Message: "card declined"}, nil
+	case FakeMethodPending:
+		return &Result{Reference: reference, Status: StatusPending}, nil
+	default:
+		return &Result{Reference: reference, Status: StatusAuthorized}, nil
+	}
+}
+
+func (fg *fakeGateway) Capture(reference string, amount float32) (*Result, error) {
+	return fg.settle(reference, amount, StatusCaptured)
+}
+
+func (fg *fakeGateway) Refund(reference string, amount float32) (*Result, error) {
+	return fg.settle(reference, amount, StatusRefunded)
+}
+
+func (fg *fakeGateway) Void(reference string) (*Result, error) {
+	if !strings.HasPrefix(reference, "fake_") {
+		return nil, ErrUnknownReference
+	}
+	return &Result{Reference: reference, Status: StatusVoided}, nil
+}
+
+func (fg *fakeGateway) VerifyWebhook(payload []byte, signature string) (*Event, error) {
+	return ParseSignedEvent(payload, signature, fg.secret)
+}
+
+func (fg *fakeGateway) settle(reference string, amount float32, status string) (*Result, error) {
+	if !strings.HasPrefix(reference, "fake_") {
+		return nil, ErrUnknownReference
+	}
+	if amount <= 0 {
+		return nil, ErrInvalidAmount
+	}
+	return &Result{Reference: reference, Status: status}, nil
+}
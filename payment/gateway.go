@@ -0,0 +1,95 @@
+package payment
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+)
+
+var (
+	ErrInvalidSignature = errors.New("webhook signature does not match")
+	ErrInvalidEvent     = errors.New("webhook event is malformed")
+)
+
+// Result statuses reported by a gateway.
+const (
+	StatusPending    = "pending"
+	StatusAuthorized = "authorized"
+	StatusCaptured   = "captured"
+	StatusRefunded   = "refunded"
+	StatusVoided     = "voided"
+	StatusFailed     = "failed"
+)
+
+// Event types a gateway posts to the webhook.
+const (
+	EventAuthorized = "payment.authorized"
+	EventFailed     = "payment.failed"
+	EventCaptured   = "payment.captured"
+	EventRefunded   = "payment.refunded"
+	EventVoided     = "payment.voided"
+)
+
+// AuthorizeRequest reserves an amount on the customer's payment method.
+type AuthorizeRequest struct {
+	OrderID int64
+	Amount  float32
+	Method  string
+}
+
+// Result is the answer of the gateway to an operation. A pending authorization
+// is settled later through a webhook event.
+type Result struct {
+	Reference string
+	Status    string
+	Message   string
+}
+
+// Event is a webhook callback of the gateway. ID is unique per event and is
+// used to process each event once.
+type Event struct {
+	ID        string  `json:"id"`
+	Type      string  `json:"type"`
+	Reference string  `json:"reference"`
+	Amount    float32 `json:"amount"`
+	Message   string  `json:"message"`
+}
+
+// Gateway is a payment provider.
+type Gateway interface {
+	Name() string
+	Authorize(request AuthorizeRequest) (*Result, error)
+	Capture(reference string, amount float32) (*Result, error)
+	Refund(reference string, amount float32) (*Result, error)
+	Void(reference string) (*Result, error)
+	// VerifyWebhook checks the signature of a callback and decodes its event.
+	VerifyWebhook(payload []byte, signature string) (*Event, error)
+}
+
+// Sign is the hex encoded HMAC-SHA256 of a payload.
+func Sign(payload []byte, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ParseSignedEvent decodes a webhook payload signed with Sign.
+func ParseSignedEvent(payload []byte, signature string, secret string) (*Event, error) {
+	expected, err := hex.DecodeString(Sign(payload, secret))
+	if err != nil {
+		return nil, err
+	}
+	actual, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(expected, actual) {
+		return nil, ErrInvalidSignature
+	}
+
+	var event Event
+	err = json.Unmarshal(payload, &event)
+	if err != nil || event.ID == "" || event.Type == "" || event.Reference == "" {
+		return nil, ErrInvalidEvent
+	}
+	return &event, nil
+}
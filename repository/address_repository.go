@@ -28,24 +28,48 @@ func NewAddressRepository(db *sql.DB) AddressRepository {
 	}
 }
 
-func (ar *addressRepository) CreateAddress(address *domain.Address) error {
-	query := "INSERT INTO addresses (user_id, name, zip, phone, address) VALUES ($1, $2, $3, $4, $5)"
-	result, err := ar.db.Exec(query, address.UserID, address.Name, address.Zip, address.Phone, address.Address)
-	rowsAffected, err := result.RowsAffected()
-	if rowsAffected == 0 {
-		return errors.New("validation error: category not created")
+const addressColumns = "id, user_id, name, zip, phone, address, street, city, country, latitude, longitude"
+
+func scanAddress(row rowScanner) (*domain.Address, error) {
+	address := &domain.Address{}
+	var latitude, longitude sql.NullFloat64
+	err := row.Scan(&address.ID, &address.UserID, &address.Name, &address.Zip, &address.Phone, &address.Address,
+		&address.Street, &address.City, &address.Country, &latitude, &longitude)
+	if err != nil {
+		return nil, err
+	}
+	if latitude.Valid && longitude.Valid {
+		address.Location = &domain.GeoPoint{Lat: latitude.Float64, Lng: longitude.Float64}
+	}
+	return address, nil
+}
+
+// locationValues turns the optional location of an address into nullable columns.
+func locationValues(location *domain.GeoPoint) (sql.NullFloat64, sql.NullFloat64) {
+	if location == nil {
+		return sql.NullFloat64{}, sql.NullFloat64{}
 	}
+	return sql.NullFloat64{Float64: location.Lat, Valid: true}, sql.NullFloat64{Float64: location.Lng, Valid: true}
+}
+
+func (ar *addressRepository) CreateAddress(address *domain.Address) error {
+	latitude, longitude := locationValues(address.Location)
+	query := `
+		INSERT INTO addresses (user_id, name, zip, phone, address, street, city, country, latitude, longitude)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id
+	`
+	err := ar.db.QueryRow(query, address.UserID, address.Name, address.Zip, address.Phone, address.Address,
+		address.Street, address.City, address.Country, latitude, longitude).Scan(&address.ID)
 	if err != nil {
-		println(err.Error())
 		return err
 	}
 	return nil
 }
 
 func (ar *addressRepository) GetAddressByID(addressID int64) (*domain.Address, error) {
-	address := &domain.Address{}
-	query := "SELECT id,user_id, name, zip, phone, address FROM addresses WHERE id = $1"
-	err := ar.db.QueryRow(query, addressID).Scan(&address.ID, &address.UserID, &address.Name, &address.Zip, &address.Phone, &address.Address)
+	query := "SELECT " + addressColumns + " FROM addresses WHERE id = $1"
+	address, err := scanAddress(ar.db.QueryRow(query, addressID))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, ErrAddressNotFound
@@ -56,11 +80,7 @@ func (ar *addressRepository) GetAddressByID(addressID int64) (*domain.Address, e
 }
 
 func (ar *addressRepository) GetUsersAddresses(userID int64) ([]*domain.Address, error) {
-	query := `
-		SELECT id,user_id, name, zip, phone, address
-		FROM addresses
-		WHERE user_id = $1
-	`
+	query := "SELECT " + addressColumns + " FROM addresses WHERE user_id = $1"
 
 	rows, err := ar.db.Query(query, userID)
 	if err != nil {
@@ -70,12 +90,11 @@ func (ar *addressRepository) GetUsersAddresses(userID int64) ([]*domain.Address,
 
 	var addresses []*domain.Address
 	for rows.Next() {
-		var address domain.Address
-		err := rows.Scan(&address.ID, &address.UserID, &address.Name, &address.Zip, &address.Phone, &address.Address)
+		address, err := scanAddress(rows)
 		if err != nil {
 			return nil, err
 		}
-		addresses = append(addresses, &address)
+		addresses = append(addresses, address)
 	}
 
 	if err = rows.Err(); err != nil {
@@ -86,8 +105,14 @@ func (ar *addressRepository) GetUsersAddresses(userID int64) ([]*domain.Address,
 }
 
 func (ar *addressRepository) UpdateAddress(addressID int64, address *domain.Address) error {
-	query := "UPDATE addresses SET name = $1, zip = $2, phone = $3, address = $4 WHERE user_id = $5 and id = $6"
-	result, err := ar.db.Exec(query, address.Name, address.Zip, address.Phone, address.Address, address.UserID, addressID)
+	latitude, longitude := locationValues(address.Location)
+	query := `
+		UPDATE addresses
+		SET name = $1, zip = $2, phone = $3, address = $4, street = $5, city = $6, country = $7, latitude = $8, longitude = $9
+		WHERE user_id = $10 and id = $11
+	`
+	result, err := ar.db.Exec(query, address.Name, address.Zip, address.Phone, address.Address, address.Street, address.City,
+		address.Country, latitude, longitude, address.UserID, addressID)
 	rowsAffected, err := result.RowsAffected()
 	if rowsAffected == 0 {
 		return errors.New("validation error: address not found")
@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"foodDelivery/domain"
+)
+
+var (
+	ErrCartItemNotFound = errors.New("cart item not found")
+)
+
+type CartRepository interface {
+	GetOrCreateCart(userID int64) (*domain.Cart, error)
+	SetCartSupplier(cartID int64, supplierID int64) error
+	AddItem(item *domain.CartItem) error
+	UpdateItemQuantity(cartID int64, itemID int64, quantity int8) error
+	RemoveItem(cartID int64, itemID int64) error
+	ClearCart(cartID int64) error
+}
+
+type cartRepository struct {
+	db *sql.DB
+}
+
+func NewCartRepository(db *sql.DB) CartRepository {
+	return &cartRepository{
+		db: db,
+	}
+}
+
+// GetOrCreateCart returns the cart of a user with its items, creating an empty one on first use.
+func (cr *cartRepository) GetOrCreateCart(userID int64) (*domain.Cart, error) {
+	cart := &domain.Cart{}
+	query := `
+		INSERT INTO carts (user_id) VALUES ($1)
+		ON CONFLICT (user_id) DO UPDATE SET user_id = EXCLUDED.user_id
+		RETURNING id, user_id, COALESCE(supplier_id, 0), updated_at
+	`
+	err := cr.db.QueryRow(query, userID).Scan(&cart.ID, &cart.UserID, &cart.SupplierID, &cart.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	itemsQuery := `
+		SELECT ci.id, ci.cart_id, ci.food_id, f.name AS food_name, ci.quantity, ci.unit_price, ci.note
+		FROM cart_items ci
+		INNER JOIN foods f ON ci.food_id = f.id
+		WHERE ci.cart_id = $1
+		ORDER BY ci.id
+	`
+	rows, err := cr.db.Query(itemsQuery, cart.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cart.Items = make([]*domain.CartItem, 0)
+	for rows.Next() {
+		item := &domain.CartItem{Modifiers: make([]*domain.OrderItemModifier, 0)}
+		err := rows.Scan(&item.ID, &item.CartID, &item.FoodID, &item.FoodName, &item.Quantity, &item.UnitPrice, &item.Note)
+		if err != nil {
+			return nil, err
+		}
+		cart.Items = append(cart.Items, item)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	err = cr.attachItemModifiers(cart)
+	if err != nil {
+		return nil, err
+	}
+
+	return cart, nil
+}
+
+func (cr *cartRepository) attachItemModifiers(cart *domain.Cart) error {
+	query := `
+		SELECT m.id, m.cart_item_id, m.modifier_option_id
+		FROM cart_item_modifiers m
+		INNER JOIN cart_items ci ON m.cart_item_id = ci.id
+		WHERE ci.cart_id = $1
+		ORDER BY m.id
+	`
+	rows, err := cr.db.Query(query, cart.ID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	items := make(map[int64]*domain.CartItem)
+	for _, item := range cart.Items {
+		items[item.ID] = item
+	}
+	for rows.Next() {
+		var cartItemID int64
+		modifier := &domain.OrderItemModifier{}
+		err := rows.Scan(&modifier.ID, &cartItemID, &modifier.ModifierOptionID)
+		if err != nil {
+			return err
+		}
+		if item, found := items[cartItemID]; found {
+			item.Modifiers = append(item.Modifiers, modifier)
+		}
+	}
+	return rows.Err()
+}
+
+func (cr *cartRepository) SetCartSupplier(cartID int64, supplierID int64) error {
+	query := "UPDATE carts SET supplier_id = NULLIF($1, 0), updated_at = NOW() WHERE id = $2"
+	_, err := cr.db.Exec(query, supplierID, cartID)
+	return err
+}
+
+func (cr *cartRepository) AddItem(item *domain.CartItem) error {
+	tx, err := cr.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO cart_items (cart_id, food_id, quantity, unit_price, note)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`
+	err = tx.QueryRow(query, item.CartID, item.FoodID, item.Quantity, item.UnitPrice, item.Note).Scan(&item.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	modifierQuery := "INSERT INTO cart_item_modifiers (cart_item_id, modifier_option_id) VALUES ($1, $2) RETURNING id"
+	for _, modifier := range item.Modifiers {
+		err = tx.QueryRow(modifierQuery, item.ID, modifier.ModifierOptionID).Scan(&modifier.ID)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	_, err = tx.Exec("UPDATE carts SET updated_at = NOW() WHERE id = $1", item.CartID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (cr *cartRepository) UpdateItemQuantity(cartID int64, itemID int64, quantity int8) error {
+	result, err := cr.db.Exec("UPDATE cart_items SET quantity = $1 WHERE id = $2 AND cart_id = $3", quantity, itemID, cartID)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrCartItemNotFound
+	}
+	return cr.touch(cartID)
+}
+
+func (cr *cartRepository) RemoveItem(cartID int64, itemID int64) error {
+	result, err := cr.db.Exec("DELETE FROM cart_items WHERE id = $1 AND cart_id = $2", itemID, cartID)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrCartItemNotFound
+	}
+	return cr.touch(cartID)
+}
+
+func (cr *cartRepository) ClearCart(cartID int64) error {
+	tx, err := cr.db.Begin()
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("DELETE FROM cart_items WHERE cart_id = $1", cartID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	_, err = tx.Exec("UPDATE carts SET supplier_id = NULL, updated_at = NOW() WHERE id = $1", cartID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// touch records the last change of a cart, used to find abandoned carts.
+func (cr *cartRepository) touch(cartID int64) error {
+	_, err := cr.db.Exec("UPDATE carts SET updated_at = NOW() WHERE id = $1", cartID)
+	return err
+}
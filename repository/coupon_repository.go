@@ -0,0 +1,264 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"foodDelivery/domain"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrCouponNotFound = errors.New("coupon not found")
+	ErrCouponExists   = errors.New("coupon code already exists")
+	ErrCouponUsedUp   = errors.New("coupon has reached its usage limit")
+	ErrNotFirstOrder  = errors.New("coupon is only valid on a first order")
+)
+
+type CouponRepository interface {
+	GetAllCoupons() ([]*domain.Coupon, error)
+	GetCouponByID(couponID int64) (*domain.Coupon, error)
+	GetCouponByCode(code string) (*domain.Coupon, error)
+	CreateCoupon(coupon *domain.Coupon) error
+	UpdateCoupon(coupon *domain.Coupon) error
+	DeleteCoupon(couponID int64) error
+	CountUserRedemptions(couponID int64, userID int64) (int, error)
+	CountUserOrders(userID int64) (int, error)
+	ReleaseRedemption(orderID int64) error
+}
+
+type couponRepository struct {
+	db *sql.DB
+}
+
+func NewCouponRepository(db *sql.DB) CouponRepository {
+	return &couponRepository{
+		db: db,
+	}
+}
+
+const couponColumns = `id, code, description, type, value, max_discount, min_subtotal, first_order_only, supplier_ids,
+	category_ids, usage_limit, per_user_limit, used_count, starts_at, ends_at, active, COALESCE(user_id, 0)`
+
+func scanCoupon(row rowScanner) (*domain.Coupon, error) {
+	coupon := &domain.Coupon{}
+	err := row.Scan(&coupon.ID, &coupon.Code, &coupon.Description, &coupon.Type, &coupon.Value, &coupon.MaxDiscount,
+		&coupon.MinSubtotal, &coupon.FirstOrderOnly, pq.Array(&coupon.SupplierIDs), pq.Array(&coupon.CategoryIDs),
+		&coupon.UsageLimit, &coupon.PerUserLimit, &coupon.UsedCount, &coupon.StartsAt, &coupon.EndsAt, &coupon.Active,
+		&coupon.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return coupon, nil
+}
+
+func (cr *couponRepository) GetAllCoupons() ([]*domain.Coupon, error) {
+	rows, err := cr.db.Query("SELECT " + couponColumns + " FROM coupons ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	coupons := make([]*domain.Coupon, 0)
+	for rows.Next() {
+		coupon, err := scanCoupon(rows)
+		if err != nil {
+			return nil, err
+		}
+		coupons = append(coupons, coupon)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return coupons, nil
+}
+
+func (cr *couponRepository) GetCouponByID(couponID int64) (*domain.Coupon, error) {
+	return cr.getCoupon("SELECT "+couponColumns+" FROM coupons WHERE id = $1", couponID)
+}
+
+func (cr *couponRepository) GetCouponByCode(code string) (*domain.Coupon, error) {
+	return cr.getCoupon("SELECT "+couponColumns+" FROM coupons WHERE code = $1", code)
+}
+
+func (cr *couponRepository) getCoupon(query string, args ...interface{}) (*domain.Coupon, error) {
+	coupon, err := scanCoupon(cr.db.QueryRow(query, args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrCouponNotFound
+		}
+		return nil, err
+	}
+	return coupon, nil
+}
+
+func (cr *couponRepository) CreateCoupon(coupon *domain.Coupon) error {
+	return insertCoupon(cr.db, coupon)
+}
+
+// insertCoupon stores a new coupon, on its own or inside the transaction that gives it away.
+func insertCoupon(q queryRower, coupon *domain.Coupon) error {
+	query := `
+		INSERT INTO coupons (code, description, type, value, max_discount, min_subtotal, first_order_only, supplier_ids,
+			category_ids, usage_limit, per_user_limit, starts_at, ends_at, active, user_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, NULLIF($15, 0))
+		RETURNING id
+	`
+	err := q.QueryRow(query, coupon.Code, coupon.Description, coupon.Type, coupon.Value, coupon.MaxDiscount,
+		coupon.MinSubtotal, coupon.FirstOrderOnly, pq.Array(coupon.SupplierIDs), pq.Array(coupon.CategoryIDs),
+		coupon.UsageLimit, coupon.PerUserLimit, coupon.StartsAt, coupon.EndsAt, coupon.Active, coupon.UserID).Scan(&coupon.ID)
+	if err != nil {
+		return couponWriteError(err)
+	}
+	return nil
+}
+
+func (cr *couponRepository) UpdateCoupon(coupon *domain.Coupon) error {
+	query := `
+		UPDATE coupons
+		SET code = $1, description = $2, type = $3, value = $4, max_discount = $5, min_subtotal = $6,
+			first_order_only = $7, supplier_ids = $8, category_ids = $9, usage_limit = $10, per_user_limit = $11,
+			starts_at = $12, ends_at = $13, active = $14, user_id = NULLIF($16, 0)
+		WHERE id = $15
+		RETURNING used_count
+	`
+	err := cr.db.QueryRow(query, coupon.Code, coupon.Description, coupon.Type, coupon.Value, coupon.MaxDiscount,
+		coupon.MinSubtotal, coupon.FirstOrderOnly, pq.Array(coupon.SupplierIDs), pq.Array(coupon.CategoryIDs),
+		coupon.UsageLimit, coupon.PerUserLimit, coupon.StartsAt, coupon.EndsAt, coupon.Active, coupon.ID,
+		coupon.UserID).Scan(&coupon.UsedCount)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrCouponNotFound
+		}
+		return couponWriteError(err)
+	}
+	return nil
+}
+
+// DeleteCoupon removes a coupon that was never redeemed, used ones can only be deactivated.
+func (cr *couponRepository) DeleteCoupon(couponID int64) error {
+	result, err := cr.db.Exec("DELETE FROM coupons WHERE id = $1", couponID)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return errors.New("validation error: redeemed coupons cannot be deleted, deactivate them instead")
+		}
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrCouponNotFound
+	}
+	return nil
+}
+
+func (cr *couponRepository) CountUserRedemptions(couponID int64, userID int64) (int, error) {
+	var count int
+	err := cr.db.QueryRow("SELECT COUNT(*) FROM coupon_redemptions WHERE coupon_id = $1 AND user_id = $2",
+		couponID, userID).Scan(&count)
+	return count, err
+}
+
+// CountUserOrders counts the orders of a user that were paid and not cancelled.
+func (cr *couponRepository) CountUserOrders(userID int64) (int, error) {
+	return countUserOrders(cr.db, userID, 0)
+}
+
+// ReleaseRedemption frees the coupon use of an order whose payment failed or that
+// was cancelled, so the customer can use the code again.
+func (cr *couponRepository) ReleaseRedemption(orderID int64) error {
+	tx, err := cr.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	query := `
+		DELETE FROM coupon_redemptions r
+		USING orders o
+		WHERE r.order_id = o.id AND r.order_id = $1 AND o.status IN ($2, $3)
+		RETURNING r.coupon_id
+	`
+	var couponID int64
+	err = tx.QueryRow(query, orderID, domain.OrderStatusPaymentFailed, domain.OrderStatusCancelled).Scan(&couponID)
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+	_, err = tx.Exec("UPDATE coupons SET used_count = used_count - 1 WHERE id = $1", couponID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// redeemCoupon records the use of a coupon inside the order transaction. Updating the
+// coupon row locks it, so concurrent orders with the same code are checked one after
+// the other and no limit can be exceeded.
+func redeemCoupon(tx *sql.Tx, coupon *domain.Coupon, userID int64, orderID int64, amount float32) error {
+	query := `
+		UPDATE coupons
+		SET used_count = used_count + 1
+		WHERE id = $1 AND (usage_limit = 0 OR used_count < usage_limit)
+		RETURNING per_user_limit, first_order_only, COALESCE(user_id, 0)
+	`
+	var perUserLimit int
+	var firstOrderOnly bool
+	var couponUserID int64
+	err := tx.QueryRow(query, coupon.ID).Scan(&perUserLimit, &firstOrderOnly, &couponUserID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrCouponUsedUp
+		}
+		return err
+	}
+	if couponUserID != 0 && couponUserID != userID {
+		return ErrCouponNotFound
+	}
+
+	if perUserLimit > 0 {
+		var used int
+		err = tx.QueryRow("SELECT COUNT(*) FROM coupon_redemptions WHERE coupon_id = $1 AND user_id = $2",
+			coupon.ID, userID).Scan(&used)
+		if err != nil {
+			return err
+		}
+		if used >= perUserLimit {
+			return ErrCouponUsedUp
+		}
+	}
+	if firstOrderOnly {
+		count, err := countUserOrders(tx, userID, orderID)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrNotFirstOrder
+		}
+	}
+
+	_, err = tx.Exec("INSERT INTO coupon_redemptions (coupon_id, user_id, order_id, amount) VALUES ($1, $2, $3, $4)",
+		coupon.ID, userID, orderID, amount)
+	return err
+}
+
+// countUserOrders counts the orders of a user other than exceptOrderID that were paid and not cancelled.
+func countUserOrders(q queryRower, userID int64, exceptOrderID int64) (int, error) {
+	query := "SELECT COUNT(*) FROM orders WHERE user_id = $1 AND id <> $2 AND status NOT IN ($3, $4)"
+	var count int
+	err := q.QueryRow(query, userID, exceptOrderID, domain.OrderStatusPaymentFailed, domain.OrderStatusCancelled).Scan(&count)
+	return count, err
+}
+
+func couponWriteError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return ErrCouponExists
+	}
+	return err
+}
@@ -0,0 +1,213 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"foodDelivery/domain"
+	"time"
+)
+
+var (
+	ErrCourierNotFound = errors.New("courier not found")
+	ErrShiftNotFound   = errors.New("shift not found")
+)
+
+type CourierRepository interface {
+	CreateCourier(courier *domain.Courier) error
+	UpdateCourier(courier *domain.Courier) error
+	GetCourierByID(courierID int64) (*domain.Courier, error)
+	GetCourierByUserID(userID int64) (*domain.Courier, error)
+	GetAllCouriers() ([]*domain.Courier, error)
+	GetAvailableCouriers(at time.Time) ([]*domain.Courier, error)
+	GetShifts(courierID int64) ([]*domain.CourierShift, error)
+	CreateShift(shift *domain.CourierShift) error
+	DeleteShift(courierID int64, shiftID int64) error
+	UpdateCourierLocation(courierID int64, location domain.GeoPoint, at time.Time) error
+}
+
+type courierRepository struct {
+	db *sql.DB
+}
+
+func NewCourierRepository(db *sql.DB) CourierRepository {
+	return &courierRepository{
+		db: db,
+	}
+}
+
+const courierColumns = "c.id, c.user_id, c.name, c.phone, c.vehicle, c.active, c.latitude, c.longitude, c.located_at"
+
+func scanCourier(row rowScanner) (*domain.Courier, error) {
+	courier := &domain.Courier{}
+	var latitude, longitude sql.NullFloat64
+	err := row.Scan(&courier.ID, &courier.UserID, &courier.Name, &courier.Phone, &courier.Vehicle, &courier.Active,
+		&latitude, &longitude, &courier.LocatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if latitude.Valid && longitude.Valid {
+		courier.Location = &domain.GeoPoint{Lat: latitude.Float64, Lng: longitude.Float64}
+	}
+	return courier, nil
+}
+
+func (cr *courierRepository) CreateCourier(courier *domain.Courier) error {
+	latitude, longitude := locationValues(courier.Location)
+	query := `
+		INSERT INTO couriers (user_id, name, phone, vehicle, active, latitude, longitude)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`
+	return cr.db.QueryRow(query, courier.UserID, courier.Name, courier.Phone, courier.Vehicle, courier.Active,
+		latitude, longitude).Scan(&courier.ID)
+}
+
+func (cr *courierRepository) UpdateCourier(courier *domain.Courier) error {
+	latitude, longitude := locationValues(courier.Location)
+	query := `
+		UPDATE couriers
+		SET name = $1, phone = $2, vehicle = $3, active = $4, latitude = $5, longitude = $6
+		WHERE id = $7
+	`
+	result, err := cr.db.Exec(query, courier.Name, courier.Phone, courier.Vehicle, courier.Active, latitude, longitude, courier.ID)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrCourierNotFound
+	}
+	return nil
+}
+
+func (cr *courierRepository) GetCourierByID(courierID int64) (*domain.Courier, error) {
+	query := "SELECT " + courierColumns + " FROM couriers c WHERE c.id = $1"
+	courier, err := scanCourier(cr.db.QueryRow(query, courierID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrCourierNotFound
+		}
+		return nil, err
+	}
+	return courier, nil
+}
+
+func (cr *courierRepository) GetCourierByUserID(userID int64) (*domain.Courier, error) {
+	query := "SELECT " + courierColumns + " FROM couriers c WHERE c.user_id = $1"
+	courier, err := scanCourier(cr.db.QueryRow(query, userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrCourierNotFound
+		}
+		return nil, err
+	}
+	return courier, nil
+}
+
+func (cr *courierRepository) GetAllCouriers() ([]*domain.Courier, error) {
+	return cr.getCouriers("SELECT " + courierColumns + " FROM couriers c ORDER BY c.id")
+}
+
+// GetAvailableCouriers returns the active couriers that are on shift at the given
+// time and not busy with another delivery.
+func (cr *courierRepository) GetAvailableCouriers(at time.Time) ([]*domain.Courier, error) {
+	query := `
+		SELECT ` + courierColumns + `
+		FROM couriers c
+		WHERE c.active
+			AND EXISTS (SELECT 1 FROM courier_shifts cs WHERE cs.courier_id = c.id AND cs.starts_at <= $1 AND cs.ends_at > $1)
+			AND NOT EXISTS (SELECT 1 FROM orders o WHERE o.courier_id = c.id AND o.status IN ($2, $3, $4))
+		ORDER BY c.id
+	`
+	return cr.getCouriers(query, at, domain.OrderStatusAssigned, domain.OrderStatusAccepted, domain.OrderStatusPickedUp)
+}
+
+func (cr *courierRepository) getCouriers(query string, args ...interface{}) ([]*domain.Courier, error) {
+	rows, err := cr.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	couriers := make([]*domain.Courier, 0)
+	for rows.Next() {
+		courier, err := scanCourier(rows)
+		if err != nil {
+			return nil, err
+		}
+		couriers = append(couriers, courier)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return couriers, nil
+}
+
+func (cr *courierRepository) GetShifts(courierID int64) ([]*domain.CourierShift, error) {
+	query := `
+		SELECT id, courier_id, starts_at, ends_at
+		FROM courier_shifts
+		WHERE courier_id = $1
+		ORDER BY starts_at
+	`
+	rows, err := cr.db.Query(query, courierID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	shifts := make([]*domain.CourierShift, 0)
+	for rows.Next() {
+		shift := &domain.CourierShift{}
+		err := rows.Scan(&shift.ID, &shift.CourierID, &shift.StartsAt, &shift.EndsAt)
+		if err != nil {
+			return nil, err
+		}
+		shifts = append(shifts, shift)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return shifts, nil
+}
+
+func (cr *courierRepository) CreateShift(shift *domain.CourierShift) error {
+	query := `
+		INSERT INTO courier_shifts (courier_id, starts_at, ends_at)
+		VALUES ($1, $2, $3)
+		RETURNING id
+	`
+	return cr.db.QueryRow(query, shift.CourierID, shift.StartsAt, shift.EndsAt).Scan(&shift.ID)
+}
+
+func (cr *courierRepository) DeleteShift(courierID int64, shiftID int64) error {
+	result, err := cr.db.Exec("DELETE FROM courier_shifts WHERE id = $1 AND courier_id = $2", shiftID, courierID)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrShiftNotFound
+	}
+	return nil
+}
+
+// UpdateCourierLocation stores the last known position of a courier, ignoring positions
+// older than the stored one.
+func (cr *courierRepository) UpdateCourierLocation(courierID int64, location domain.GeoPoint, at time.Time) error {
+	query := `
+		UPDATE couriers
+		SET latitude = $1, longitude = $2, located_at = $3
+		WHERE id = $4 AND (located_at IS NULL OR located_at < $3)
+	`
+	_, err := cr.db.Exec(query, location.Lat, location.Lng, at, courierID)
+	return err
+}
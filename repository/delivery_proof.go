@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"fmt"
+	"foodDelivery/domain"
+	"math/big"
+)
+
+// newDeliveryPin returns the random 4-digit code the customer gives the courier.
+func newDeliveryPin() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(10000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%04d", n.Int64()), nil
+}
+
+// RecordPinAttempt counts a wrong delivery PIN entered for an order.
+func (or *orderRepository) RecordPinAttempt(orderID int64) error {
+	_, err := or.db.Exec("UPDATE orders SET delivery_pin_attempts = delivery_pin_attempts + 1 WHERE id = $1", orderID)
+	return err
+}
+
+// CompleteDelivery marks a picked up order of the courier as delivered and stores the proof.
+func (or *orderRepository) CompleteDelivery(orderID int64, courierID int64, proof *domain.DeliveryProof) error {
+	tx, err := or.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(`
+		UPDATE orders
+		SET status = $1, delivered_at = NOW()
+		WHERE id = $2 AND courier_id = $3 AND status = $4
+	`, domain.OrderStatusDelivered, orderID, courierID, domain.OrderStatusPickedUp)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if rowsAffected == 0 {
+		tx.Rollback()
+		return ErrInvalidStatusTransition
+	}
+
+	latitude, longitude := locationValues(proof.Location)
+	query := `
+		INSERT INTO delivery_proofs (order_id, courier_id, method, photo_url, latitude, longitude, distance_m)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`
+	proof.OrderID = orderID
+	proof.CourierID = courierID
+	err = tx.QueryRow(query, orderID, courierID, proof.Method, proof.PhotoURL, latitude, longitude,
+		proof.DistanceM).Scan(&proof.ID, &proof.CreatedAt)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (or *orderRepository) getDeliveryProof(orderID int64) (*domain.DeliveryProof, error) {
+	proof := &domain.DeliveryProof{}
+	var latitude, longitude sql.NullFloat64
+	query := `
+		SELECT id, order_id, courier_id, method, photo_url, latitude, longitude, distance_m, created_at
+		FROM delivery_proofs
+		WHERE order_id = $1
+	`
+	err := or.db.QueryRow(query, orderID).Scan(&proof.ID, &proof.OrderID, &proof.CourierID, &proof.Method, &proof.PhotoURL,
+		&latitude, &longitude, &proof.DistanceM, &proof.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if latitude.Valid && longitude.Valid {
+		proof.Location = &domain.GeoPoint{Lat: latitude.Float64, Lng: longitude.Float64}
+	}
+	return proof, nil
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"foodDelivery/domain"
+	"github.com/lib/pq"
+)
+
+type DeliveryZoneRepository interface {
+	GetZonesBySupplierID(supplierID int64) ([]*domain.DeliveryZone, error)
+}
+
+type deliveryZoneRepository struct {
+	db *sql.DB
+}
+
+func NewDeliveryZoneRepository(db *sql.DB) DeliveryZoneRepository {
+	return &deliveryZoneRepository{
+		db: db,
+	}
+}
+
+func (zr *deliveryZoneRepository) GetZonesBySupplierID(supplierID int64) ([]*domain.DeliveryZone, error) {
+	query := `
+		SELECT id, supplier_id, name, postcodes, polygon, delivery_fee, delivery_time
+		FROM delivery_zones
+		WHERE supplier_id = $1
+		ORDER BY id
+	`
+	rows, err := zr.db.Query(query, supplierID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	zones := make([]*domain.DeliveryZone, 0)
+	for rows.Next() {
+		zone := &domain.DeliveryZone{}
+		var polygon []byte
+		err := rows.Scan(&zone.ID, &zone.SupplierID, &zone.Name, pq.Array(&zone.Postcodes), &polygon,
+			&zone.DeliveryFee, &zone.DeliveryTime)
+		if err != nil {
+			return nil, err
+		}
+		err = json.Unmarshal(polygon, &zone.Polygon)
+		if err != nil {
+			return nil, err
+		}
+		zones = append(zones, zone)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return zones, nil
+}
+
+// replaceZones replaces all delivery zones of a supplier within the transaction that
+// saves the supplier.
+func replaceZones(tx *sql.Tx, supplierID int64, zones []*domain.DeliveryZone) error {
+	_, err := tx.Exec("DELETE FROM delivery_zones WHERE supplier_id = $1", supplierID)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO delivery_zones (supplier_id, name, postcodes, polygon, delivery_fee, delivery_time)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`
+	for _, zone := range zones {
+		if zone.Postcodes == nil {
+			zone.Postcodes = []string{}
+		}
+		if zone.Polygon == nil {
+			zone.Polygon = []domain.GeoPoint{}
+		}
+		polygon, err := json.Marshal(zone.Polygon)
+		if err != nil {
+			return err
+		}
+		zone.SupplierID = supplierID
+		err = tx.QueryRow(query, supplierID, zone.Name, pq.Array(zone.Postcodes), polygon, zone.DeliveryFee,
+			zone.DeliveryTime).Scan(&zone.ID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -32,7 +32,7 @@ func NewFoodRepository(db *sql.DB) FoodRepository {
 func (fr *foodRepository) GetFoodByID(foodID int64) (*domain.Food, error) {
 	query := `
 		SELECT f.id, f.name, f.supplier_id, s.name AS supplier_name, f.category_id, c.name AS category_name,
-			f.image_url, f.description, f.price, f.daily_quantity
+			f.image_url, f.description, f.price, f.daily_quantity, f.rating_average, f.rating_count
 		FROM foods f
 		INNER JOIN suppliers s ON f.supplier_id = s.id
 		INNER JOIN categories c ON f.category_id = c.id
@@ -41,7 +41,7 @@ func (fr *foodRepository) GetFoodByID(foodID int64) (*domain.Food, error) {
 	row := fr.db.QueryRow(query, foodID)
 	food := &domain.Food{}
 	err := row.Scan(&food.ID, &food.Name, &food.SupplierID, &food.SupplierName, &food.CategoryID, &food.CategoryName,
-		&food.ImageUrl, &food.Description, &food.Price, &food.DailyQuantity)
+		&food.ImageUrl, &food.Description, &food.Price, &food.DailyQuantity, &food.RatingAverage, &food.RatingCount)
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -118,7 +118,7 @@ func (fr *foodRepository) DeleteFood(foodID int64) error {
 func (fr *foodRepository) GetAllFoodsWithImages() ([]*domain.Food, error) {
 	query := `
 		SELECT f.id, f.name, f.supplier_id, s.name AS supplier_name, f.category_id, c.name AS category_name,
-			f.image_url, f.description, f.price, f.daily_quantity,
+			f.image_url, f.description, f.price, f.daily_quantity, f.rating_average, f.rating_count,
 			g.id AS image_id, g.image_url AS image_url
 		FROM foods f
 		INNER JOIN suppliers s ON f.supplier_id = s.id
@@ -139,7 +139,7 @@ func (fr *foodRepository) GetAllFoodsWithImages() ([]*domain.Food, error) {
 		image := &domain.Image{}
 		err := rows.Scan(
 			&food.ID, &food.Name, &food.SupplierID, &food.SupplierName, &food.CategoryID, &food.CategoryName,
-			&food.ImageUrl, &food.Description, &food.Price, &food.DailyQuantity,
+			&food.ImageUrl, &food.Description, &food.Price, &food.DailyQuantity, &food.RatingAverage, &food.RatingCount,
 			&image.ID, &image.ImageURL,
 		)
 		if err != nil {
@@ -168,7 +168,7 @@ func (fr *foodRepository) GetFoodsByCategoryAndSupplier(categoryID, supplierID i
 	var foods []*domain.Food
 	query := `
 		SELECT f.id, f.name, f.supplier_id, s.name AS supplier_name, f.category_id, c.name AS category_name,
-			f.image_url, f.description, f.price, f.daily_quantity
+			f.image_url, f.description, f.price, f.daily_quantity, f.rating_average, f.rating_count
 		FROM foods f
 		INNER JOIN suppliers s ON f.supplier_id = s.id
 		INNER JOIN categories c ON f.category_id = c.id
@@ -183,7 +183,7 @@ func (fr *foodRepository) GetFoodsByCategoryAndSupplier(categoryID, supplierID i
 	for rows.Next() {
 		var food domain.Food
 		err := rows.Scan(&food.ID, &food.Name, &food.SupplierID, &food.SupplierName, &food.CategoryID, &food.CategoryName,
-			&food.ImageUrl, &food.Description, &food.Price, &food.DailyQuantity)
+			&food.ImageUrl, &food.Description, &food.Price, &food.DailyQuantity, &food.RatingAverage, &food.RatingCount)
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"database/sql"
+	"foodDelivery/domain"
+)
+
+// ApplyFulfilment confirms a pending order with the changes to its unavailable lines and
+// its new totals. Removed lines are deleted, replaced lines take the replacement food
+// without modifiers, and the wallet part above the new total is credited back.
+func (or *orderRepository) ApplyFulfilment(order *domain.Order, changes []*domain.FulfilmentChange, walletRefund float32) error {
+	tx, err := or.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	orderQuery := `
+		UPDATE orders
+		SET confirmed_at = NOW(), price = $1, wallet_amount = $2, subtotal = $3, service_fee = $4, tax = $5, discount = $6, tip = $7
+		WHERE id = $8 AND status = $9 AND confirmed_at IS NULL
+		RETURNING confirmed_at
+	`
+	breakdown := order.Breakdown
+	err = tx.QueryRow(orderQuery, order.Price, order.WalletAmount, breakdown.Subtotal, breakdown.ServiceFee, breakdown.Tax,
+		breakdown.Discount, breakdown.Tip, order.ID, domain.OrderStatusPending).Scan(&order.ConfirmedAt)
+	if err != nil {
+		tx.Rollback()
+		if err == sql.ErrNoRows {
+			return ErrInvalidStatusTransition
+		}
+		return err
+	}
+
+	salesDay, err := getOrderSalesDay(tx, order.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	changeQuery := `
+		INSERT INTO order_fulfilment_changes (order_id, order_item_id, food_id, food_name, quantity, unit_price, action,
+			replacement_food_id, replacement_food_name)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, 0), $9)
+		RETURNING id, created_at
+	`
+	for _, change := range changes {
+		if change.Action == domain.FulfilmentActionReplaced {
+			// The replacement is checked against the stock of the day before it takes the line.
+			daySell, err := or.getDailyFoodSales(tx, change.ReplacementFoodID, salesDay)
+			if err != nil {
+				tx.Rollback()
+				return err
+			}
+			dailyQuantity, err := or.getFoodDailyQuantity(change.ReplacementFoodID)
+			if err != nil {
+				tx.Rollback()
+				return err
+			}
+			if daySell+int(change.Quantity) > dailyQuantity {
+				tx.Rollback()
+				return ErrOutOfStock
+			}
+			err = replaceOrderItem(tx, order, change.OrderItemID)
+			if err != nil {
+				tx.Rollback()
+				return err
+			}
+		} else {
+			_, err = tx.Exec("DELETE FROM order_items WHERE id = $1 AND order_id = $2", change.OrderItemID, order.ID)
+			if err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+
+		change.OrderID = order.ID
+		err = tx.QueryRow(changeQuery, order.ID, change.OrderItemID, change.FoodID, change.FoodName, change.Quantity,
+			change.UnitPrice, change.Action, change.ReplacementFoodID, change.ReplacementFoodName).Scan(&change.ID, &change.CreatedAt)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	_, err = tx.Exec("DELETE FROM order_taxes WHERE order_id = $1", order.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	err = insertOrderTaxes(tx, order.ID, breakdown.Taxes)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	for _, tip := range order.Tips {
+		_, err = tx.Exec("UPDATE order_tips SET amount = $1 WHERE id = $2", tip.Amount, tip.ID)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	_, err = tx.Exec("UPDATE coupon_redemptions SET amount = $1 WHERE order_id = $2", order.CouponDiscount, order.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if walletRefund > 0 {
+		err = adjustOrderWallet(tx, order.UserID, order.ID, walletRefund, "Items of the order not available")
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// replaceOrderItem writes the replacement food and price of a line of the order.
+func replaceOrderItem(tx *sql.Tx, order *domain.Order, orderItemID int64) error {
+	for _, item := range *order.Items {
+		if item.ID != orderItemID {
+			continue
+		}
+		_, err := tx.Exec("UPDATE order_items SET food_id = $1, single_price = $2 WHERE id = $3 AND order_id = $4",
+			item.FoodID, item.SinglePrice, item.ID, order.ID)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec("DELETE FROM order_item_modifiers WHERE order_item_id = $1", item.ID)
+		return err
+	}
+	return ErrItemsNotFound
+}
+
+func (or *orderRepository) getFulfilmentChanges(orderID int64) ([]*domain.FulfilmentChange, error) {
+	query := `
+		SELECT id, order_id, order_item_id, COALESCE(food_id, 0), food_name, quantity, unit_price, action,
+			COALESCE(replacement_food_id, 0), replacement_food_name, created_at
+		FROM order_fulfilment_changes
+		WHERE order_id = $1
+		ORDER BY id
+	`
+	rows, err := or.db.Query(query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := make([]*domain.FulfilmentChange, 0)
+	for rows.Next() {
+		change := &domain.FulfilmentChange{}
+		err := rows.Scan(&change.ID, &change.OrderID, &change.OrderItemID, &change.FoodID, &change.FoodName,
+			&change.Quantity, &change.UnitPrice, &change.Action, &change.ReplacementFoodID, &change.ReplacementFoodName,
+			&change.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
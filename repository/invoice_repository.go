@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"foodDelivery/domain"
+	"time"
+)
+
+var (
+	ErrInvoiceNotFound = errors.New("invoice not found")
+	ErrInvoiceExists   = errors.New("order already has an invoice")
+)
+
+type InvoiceRepository interface {
+	CreateInvoice(invoice *domain.Invoice) error
+	GetInvoiceByOrderID(orderID int64) (*domain.Invoice, error)
+	MarkInvoiceEmailed(invoiceID int64, emailedAt time.Time) error
+}
+
+type invoiceRepository struct {
+	db *sql.DB
+}
+
+func NewInvoiceRepository(db *sql.DB) InvoiceRepository {
+	return &invoiceRepository{
+		db: db,
+	}
+}
+
+// CreateInvoice gives the invoice the next number of the year it is issued in. The table
+// is locked for the numbering so that concurrent invoices neither share nor skip a number.
+func (ir *invoiceRepository) CreateInvoice(invoice *domain.Invoice) error {
+	tx, err := ir.db.Begin()
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("LOCK TABLE invoices IN SHARE ROW EXCLUSIVE MODE")
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	year := invoice.IssuedAt.UTC().Year()
+	var sequence int
+	err = tx.QueryRow("SELECT COALESCE(MAX(sequence), 0) + 1 FROM invoices WHERE year = $1", year).Scan(&sequence)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	invoice.Number = fmt.Sprintf("INV-%d-%06d", year, sequence)
+
+	query := `
+		INSERT INTO invoices (number, year, sequence, order_id, supplier_name, supplier_address, customer_name,
+			customer_email, customer_phone, customer_address, issued_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id
+	`
+	err = tx.QueryRow(query, invoice.Number, year, sequence, invoice.OrderID, invoice.Supplier.Name,
+		invoice.Supplier.Address, invoice.Customer.Name, invoice.Customer.Email, invoice.Customer.Phone,
+		invoice.Customer.Address, invoice.IssuedAt).Scan(&invoice.ID)
+	if err != nil {
+		tx.Rollback()
+		if isUniqueViolation(err) {
+			return ErrInvoiceExists
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+func (ir *invoiceRepository) GetInvoiceByOrderID(orderID int64) (*domain.Invoice, error) {
+	query := `
+		SELECT id, number, order_id, supplier_name, supplier_address, customer_name, customer_email, customer_phone,
+			customer_address, issued_at, emailed_at
+		FROM invoices
+		WHERE order_id = $1
+	`
+	invoice := &domain.Invoice{Supplier: &domain.InvoiceParty{}, Customer: &domain.InvoiceParty{}}
+	err := ir.db.QueryRow(query, orderID).Scan(
+		&invoice.ID,
+		&invoice.Number,
+		&invoice.OrderID,
+		&invoice.Supplier.Name,
+		&invoice.Supplier.Address,
+		&invoice.Customer.Name,
+		&invoice.Customer.Email,
+		&invoice.Customer.Phone,
+		&invoice.Customer.Address,
+		&invoice.IssuedAt,
+		&invoice.EmailedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvoiceNotFound
+		}
+		return nil, err
+	}
+	return invoice, nil
+}
+
+func (ir *invoiceRepository) MarkInvoiceEmailed(invoiceID int64, emailedAt time.Time) error {
+	result, err := ir.db.Exec("UPDATE invoices SET emailed_at = $1 WHERE id = $2", emailedAt, invoiceID)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrInvoiceNotFound
+	}
+	return nil
+}
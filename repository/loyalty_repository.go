@@ -0,0 +1,414 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"foodDelivery/domain"
+	"github.com/lib/pq"
+	"math"
+	"time"
+)
+
+var (
+	ErrInsufficientPoints = errors.New("not enough loyalty points")
+)
+
+type LoyaltyRepository interface {
+	GetBalance(userID int64) (int, error)
+	GetEarnedSince(userID int64, since time.Time) (int, error)
+	GetNextExpiry(userID int64) (int, *time.Time, error)
+	GetTransactions(userID int64) ([]*domain.LoyaltyTransaction, error)
+	AwardPoints(userID int64, orderID int64, points int, expiresAt time.Time) error
+	RestoreRedeemedPoints(orderID int64, expiresAt time.Time) error
+	ReverseOrderPoints(orderID int64, share float64) error
+	ExpirePoints(now time.Time) (int, error)
+}
+
+type loyaltyRepository struct {
+	db *sql.DB
+}
+
+func NewLoyaltyRepository(db *sql.DB) LoyaltyRepository {
+	return &loyaltyRepository{
+		db: db,
+	}
+}
+
+func (lr *loyaltyRepository) GetBalance(userID int64) (int, error) {
+	return loyaltyBalance(lr.db, userID)
+}
+
+// GetEarnedSince sums the points earned since a date, less the ones reversed since.
+func (lr *loyaltyRepository) GetEarnedSince(userID int64, since time.Time) (int, error) {
+	query := `
+		SELECT COALESCE(SUM(points), 0)
+		FROM loyalty_transactions
+		WHERE user_id = $1 AND type IN ($2, $3) AND created_at >= $4
+	`
+	var points int
+	err := lr.db.QueryRow(query, userID, domain.LoyaltyTransactionEarn, domain.LoyaltyTransactionReversal, since).Scan(&points)
+	if err != nil {
+		return 0, err
+	}
+	return points, nil
+}
+
+// GetNextExpiry returns the points that expire first and when they do.
+func (lr *loyaltyRepository) GetNextExpiry(userID int64) (int, *time.Time, error) {
+	query := `
+		SELECT expires_at, SUM(remaining)
+		FROM loyalty_transactions
+		WHERE user_id = $1 AND remaining > 0 AND expires_at IS NOT NULL
+		GROUP BY expires_at
+		ORDER BY expires_at
+		LIMIT 1
+	`
+	var expiresAt time.Time
+	var points int
+	err := lr.db.QueryRow(query, userID).Scan(&expiresAt, &points)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil, nil
+		}
+		return 0, nil, err
+	}
+	return points, &expiresAt, nil
+}
+
+// GetTransactions lists the points movements of a user, newest first.
+func (lr *loyaltyRepository) GetTransactions(userID int64) ([]*domain.LoyaltyTransaction, error) {
+	query := `
+		SELECT id, type, points, COALESCE(order_id, 0), description, expires_at, created_at
+		FROM loyalty_transactions
+		WHERE user_id = $1
+		ORDER BY id DESC
+	`
+	rows, err := lr.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transactions := make([]*domain.LoyaltyTransaction, 0)
+	for rows.Next() {
+		transaction := &domain.LoyaltyTransaction{}
+		err := rows.Scan(&transaction.ID, &transaction.Type, &transaction.Points, &transaction.OrderID,
+			&transaction.Description, &transaction.ExpiresAt, &transaction.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, transaction)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
+
+// AwardPoints credits the points earned with an order. An order earns points once.
+func (lr *loyaltyRepository) AwardPoints(userID int64, orderID int64, points int, expiresAt time.Time) error {
+	tx, err := lr.db.Begin()
+	if err != nil {
+		return err
+	}
+	transaction := &domain.LoyaltyTransaction{
+		Type:        domain.LoyaltyTransactionEarn,
+		Points:      points,
+		OrderID:     orderID,
+		Description: "Points earned with order",
+		ExpiresAt:   &expiresAt,
+	}
+	err = creditPoints(tx, userID, transaction)
+	if err != nil {
+		tx.Rollback()
+		if isUniqueViolation(err) {
+			return nil
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+// RestoreRedeemedPoints gives back the points spent on an order whose payment failed or
+// that was cancelled. It does nothing for other orders or when already restored.
+func (lr *loyaltyRepository) RestoreRedeemedPoints(orderID int64, expiresAt time.Time) error {
+	tx, err := lr.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	query := `
+		SELECT t.user_id, -t.points
+		FROM loyalty_transactions t
+		INNER JOIN orders o ON t.order_id = o.id
+		WHERE t.order_id = $1 AND t.type = $2 AND o.status IN ($3, $4)
+	`
+	var userID int64
+	transaction := &domain.LoyaltyTransaction{
+		Type:        domain.LoyaltyTransactionRestore,
+		OrderID:     orderID,
+		Description: "Order not completed",
+		ExpiresAt:   &expiresAt,
+	}
+	err = tx.QueryRow(query, orderID, domain.LoyaltyTransactionRedeem, domain.OrderStatusPaymentFailed,
+		domain.OrderStatusCancelled).Scan(&userID, &transaction.Points)
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+	err = creditPoints(tx, userID, transaction)
+	if err != nil {
+		tx.Rollback()
+		if isUniqueViolation(err) {
+			return nil
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+// ReverseOrderPoints takes back a share of the points earned with a refunded order,
+// never more than was earned in total. Points already spent leave the balance negative
+// until new points cover them.
+func (lr *loyaltyRepository) ReverseOrderPoints(orderID int64, share float64) error {
+	tx, err := lr.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	var userID int64
+	var earned int
+	err = tx.QueryRow("SELECT user_id, points FROM loyalty_transactions WHERE order_id = $1 AND type = $2",
+		orderID, domain.LoyaltyTransactionEarn).Scan(&userID, &earned)
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+	err = lockLoyaltyUser(tx, userID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	var reversed int
+	err = tx.QueryRow("SELECT COALESCE(-SUM(points), 0) FROM loyalty_transactions WHERE order_id = $1 AND type = $2",
+		orderID, domain.LoyaltyTransactionReversal).Scan(&reversed)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	points := int(math.Round(float64(earned) * share))
+	if points > earned-reversed {
+		points = earned - reversed
+	}
+	if points <= 0 {
+		tx.Rollback()
+		return nil
+	}
+	err = consumePoints(tx, userID, points, orderID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	err = insertLoyaltyTransaction(tx, userID, &domain.LoyaltyTransaction{
+		Type:        domain.LoyaltyTransactionReversal,
+		Points:      -points,
+		OrderID:     orderID,
+		Description: "Order refunded",
+	}, 0)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// ExpirePoints writes off the points left of every credit that expired by now and
+// returns how many credits expired.
+func (lr *loyaltyRepository) ExpirePoints(now time.Time) (int, error) {
+	tx, err := lr.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	query := `
+		SELECT id, user_id, remaining
+		FROM loyalty_transactions
+		WHERE remaining > 0 AND expires_at <= $1
+		FOR UPDATE
+	`
+	rows, err := tx.Query(query, now)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	type expiry struct {
+		id     int64
+		userID int64
+		points int
+	}
+	expiries := make([]expiry, 0)
+	for rows.Next() {
+		var e expiry
+		err := rows.Scan(&e.id, &e.userID, &e.points)
+		if err != nil {
+			rows.Close()
+			tx.Rollback()
+			return 0, err
+		}
+		expiries = append(expiries, e)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	for _, e := range expiries {
+		_, err = tx.Exec("UPDATE loyalty_transactions SET remaining = 0 WHERE id = $1", e.id)
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+		err = insertLoyaltyTransaction(tx, e.userID, &domain.LoyaltyTransaction{
+			Type:        domain.LoyaltyTransactionExpire,
+			Points:      -e.points,
+			Description: "Points expired",
+		}, 0)
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+	}
+	return len(expiries), tx.Commit()
+}
+
+// redeemPoints spends points on an order inside the order transaction, oldest points
+// first. The user is locked so concurrent checkouts cannot spend the same points.
+func redeemPoints(tx *sql.Tx, userID int64, orderID int64, points int) error {
+	err := lockLoyaltyUser(tx, userID)
+	if err != nil {
+		return err
+	}
+	balance, err := loyaltyBalance(tx, userID)
+	if err != nil {
+		return err
+	}
+	if balance < points {
+		return ErrInsufficientPoints
+	}
+	err = consumePoints(tx, userID, points, 0)
+	if err != nil {
+		return err
+	}
+	return insertLoyaltyTransaction(tx, userID, &domain.LoyaltyTransaction{
+		Type:        domain.LoyaltyTransactionRedeem,
+		Points:      -points,
+		OrderID:     orderID,
+		Description: "Points redeemed on order",
+	}, 0)
+}
+
+// creditPoints adds earned or restored points. A negative balance left by a reversal
+// is settled first, so only the rest of the points can be spent or expire.
+func creditPoints(tx *sql.Tx, userID int64, transaction *domain.LoyaltyTransaction) error {
+	err := lockLoyaltyUser(tx, userID)
+	if err != nil {
+		return err
+	}
+	balance, err := loyaltyBalance(tx, userID)
+	if err != nil {
+		return err
+	}
+	remaining := transaction.Points
+	if balance < 0 {
+		remaining += balance
+	}
+	if remaining < 0 {
+		remaining = 0
+	}
+	return insertLoyaltyTransaction(tx, userID, transaction, remaining)
+}
+
+// consumePoints takes points from what is left of the credits of a user, the ones of
+// firstOrderID first and then the ones expiring soonest. What cannot be covered is
+// left as a negative balance.
+func consumePoints(tx *sql.Tx, userID int64, points int, firstOrderID int64) error {
+	query := `
+		SELECT id, remaining
+		FROM loyalty_transactions
+		WHERE user_id = $1 AND remaining > 0
+		ORDER BY CASE WHEN order_id = $2 THEN 0 ELSE 1 END, expires_at, id
+		FOR UPDATE
+	`
+	rows, err := tx.Query(query, userID, firstOrderID)
+	if err != nil {
+		return err
+	}
+	taken := make(map[int64]int)
+	takenOrder := make([]int64, 0)
+	for rows.Next() && points > 0 {
+		var id int64
+		var remaining int
+		err := rows.Scan(&id, &remaining)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		if remaining > points {
+			remaining = points
+		}
+		taken[id] = remaining
+		takenOrder = append(takenOrder, id)
+		points -= remaining
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	for _, id := range takenOrder {
+		_, err = tx.Exec("UPDATE loyalty_transactions SET remaining = remaining - $1 WHERE id = $2", taken[id], id)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func insertLoyaltyTransaction(tx *sql.Tx, userID int64, transaction *domain.LoyaltyTransaction, remaining int) error {
+	query := `
+		INSERT INTO loyalty_transactions (user_id, type, points, remaining, order_id, description, expires_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, 0), $6, $7)
+		RETURNING id, created_at
+	`
+	return tx.QueryRow(query, userID, transaction.Type, transaction.Points, remaining, transaction.OrderID,
+		transaction.Description, transaction.ExpiresAt).Scan(&transaction.ID, &transaction.CreatedAt)
+}
+
+// lockLoyaltyUser serializes the points movements of a user until the end of the transaction.
+func lockLoyaltyUser(tx *sql.Tx, userID int64) error {
+	var id int64
+	err := tx.QueryRow("SELECT id FROM users WHERE id = $1 FOR UPDATE", userID).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrUserNotFound
+	}
+	return err
+}
+
+func loyaltyBalance(q queryRower, userID int64) (int, error) {
+	var balance int
+	err := q.QueryRow("SELECT COALESCE(SUM(points), 0) FROM loyalty_transactions WHERE user_id = $1", userID).Scan(&balance)
+	return balance, err
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"database/sql"
+	"foodDelivery/domain"
+	"github.com/lib/pq"
+)
+
+type ModifierRepository interface {
+	GetGroupsByFoodID(foodID int64) ([]*domain.ModifierGroup, error)
+	SyncGroups(foodID int64, groups []*domain.ModifierGroup) error
+}
+
+type modifierRepository struct {
+	db *sql.DB
+}
+
+func NewModifierRepository(db *sql.DB) ModifierRepository {
+	return &modifierRepository{
+		db: db,
+	}
+}
+
+func (mr *modifierRepository) GetGroupsByFoodID(foodID int64) ([]*domain.ModifierGroup, error) {
+	query := `
+		SELECT g.id, g.food_id, g.name, g.required, g.min_selections, g.max_selections,
+			o.id, o.name, o.price_delta
+		FROM modifier_groups g
+		LEFT JOIN modifier_options o ON o.group_id = g.id
+		WHERE g.food_id = $1
+		ORDER BY g.id, o.id
+	`
+	rows, err := mr.db.Query(query, foodID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups := make([]*domain.ModifierGroup, 0)
+	var current *domain.ModifierGroup
+	for rows.Next() {
+		group := &domain.ModifierGroup{}
+		var optionID sql.NullInt64
+		var optionName sql.NullString
+		var priceDelta sql.NullFloat64
+		err := rows.Scan(&group.ID, &group.FoodID, &group.Name, &group.Required, &group.MinSelections, &group.MaxSelections,
+			&optionID, &optionName, &priceDelta)
+		if err != nil {
+			return nil, err
+		}
+
+		if current == nil || current.ID != group.ID {
+			group.Options = make([]*domain.ModifierOption, 0)
+			groups = append(groups, group)
+			current = group
+		}
+		if optionID.Valid {
+			current.Options = append(current.Options, &domain.ModifierOption{
+				ID:         optionID.Int64,
+				GroupID:    current.ID,
+				Name:       optionName.String,
+				PriceDelta: float32(priceDelta.Float64),
+			})
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return groups, nil
+}
+
+// SyncGroups replaces the modifier groups of a food. Existing groups and options
+// keep their IDs so carts referring to them stay valid, the rest is recreated.
+func (mr *modifierRepository) SyncGroups(foodID int64, groups []*domain.ModifierGroup) error {
+	tx, err := mr.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	keptGroups := make([]int64, 0)
+	for _, group := range groups {
+		group.FoodID = foodID
+		if group.ID != 0 {
+			result, err := tx.Exec(`
+				UPDATE modifier_groups
+				SET name = $1, required = $2, min_selections = $3, max_selections = $4
+				WHERE id = $5 AND food_id = $6
+			`, group.Name, group.Required, group.MinSelections, group.MaxSelections, group.ID, foodID)
+			if err != nil {
+				tx.Rollback()
+				return err
+			}
+			if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+				group.ID = 0
+			}
+		}
+		if group.ID == 0 {
+			err = tx.QueryRow(`
+				INSERT INTO modifier_groups (food_id, name, required, min_selections, max_selections)
+				VALUES ($1, $2, $3, $4, $5)
+				RETURNING id
+			`, foodID, group.Name, group.Required, group.MinSelections, group.MaxSelections).Scan(&group.ID)
+			if err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+		keptGroups = append(keptGroups, group.ID)
+
+		keptOptions := make([]int64, 0)
+		for _, option := range group.Options {
+			option.GroupID = group.ID
+			if option.ID != 0 {
+				result, err := tx.Exec(`
+					UPDATE modifier_options SET name = $1, price_delta = $2 WHERE id = $3 AND group_id = $4
+				`, option.Name, option.PriceDelta, option.ID, group.ID)
+				if err != nil {
+					tx.Rollback()
+					return err
+				}
+				if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+					option.ID = 0
+				}
+			}
+			if option.ID == 0 {
+				err = tx.QueryRow(`
+					INSERT INTO modifier_options (group_id, name, price_delta) VALUES ($1, $2, $3) RETURNING id
+				`, group.ID, option.Name, option.PriceDelta).Scan(&option.ID)
+				if err != nil {
+					tx.Rollback()
+					return err
+				}
+			}
+			keptOptions = append(keptOptions, option.ID)
+		}
+
+		_, err = tx.Exec("DELETE FROM modifier_options WHERE group_id = $1 AND NOT (id = ANY($2))", group.ID, pq.Array(keptOptions))
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	_, err = tx.Exec("DELETE FROM modifier_groups WHERE food_id = $1 AND NOT (id = ANY($2))", foodID, pq.Array(keptGroups))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
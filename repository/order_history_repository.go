@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"foodDelivery/domain"
+	"github.com/lib/pq"
+)
+
+// ModifyOrder replaces the lines, address and totals of an order that is neither accepted
+// by its supplier nor further than pending, and records the modification in its history.
+// The old lines are released before the new ones take the stock of the day. A positive
+// wallet adjustment is credited back, a negative one is paid from the wallet. It fails with
+// ErrOrderChanged when the total is no longer entry.PreviousTotal, as the modification was
+// priced against a stale order.
+func (or *orderRepository) ModifyOrder(order *domain.Order, entry *domain.OrderHistoryEntry, walletAdjustment float32) error {
+	tx, err := or.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	orderQuery := `
+		UPDATE orders
+		SET address_id = NULLIF($1, 0), price = $2, wallet_amount = $3, subtotal = $4, delivery_fee = $5, service_fee = $6, tax = $7,
+			discount = $8, tip = $9
+		WHERE id = $10 AND status IN ($11, $12) AND confirmed_at IS NULL AND price = ROUND($13::numeric, 2)
+		RETURNING id
+	`
+	breakdown := order.Breakdown
+	var orderID int64
+	err = tx.QueryRow(orderQuery, order.AddressID, order.Price, order.WalletAmount, breakdown.Subtotal,
+		breakdown.DeliveryFee, breakdown.ServiceFee, breakdown.Tax, breakdown.Discount, breakdown.Tip, order.ID,
+		domain.OrderStatusScheduled, domain.OrderStatusPending, entry.PreviousTotal).Scan(&orderID)
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return or.modifyConflict(order.ID)
+		}
+		return err
+	}
+
+	salesDay, err := getOrderSalesDay(tx, order.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	_, err = tx.Exec("DELETE FROM order_items WHERE order_id = $1", order.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	err = or.insertOrderItems(tx, order.ID, *order.Items, salesDay)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM order_taxes WHERE order_id = $1", order.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	err = insertOrderTaxes(tx, order.ID, breakdown.Taxes)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	for _, tip := range order.Tips {
+		_, err = tx.Exec("UPDATE order_tips SET amount = $1 WHERE id = $2", tip.Amount, tip.ID)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	_, err = tx.Exec("UPDATE coupon_redemptions SET amount = $1 WHERE order_id = $2", order.CouponDiscount, order.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if walletAdjustment != 0 {
+		err = adjustOrderWallet(tx, order.UserID, order.ID, walletAdjustment, "Order modified")
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	historyQuery := `
+		INSERT INTO order_history (order_id, changes, previous_address_id, address_id, previous_total, total)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+	entry.OrderID = order.ID
+	err = tx.QueryRow(historyQuery, order.ID, pq.Array(entry.Changes), entry.PreviousAddressID, entry.AddressID,
+		entry.PreviousTotal, entry.Total).Scan(&entry.ID, &entry.CreatedAt)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// modifyConflict tells why an order could not be modified: it is still modifiable, so
+// something else changed its total, or it moved on.
+func (or *orderRepository) modifyConflict(orderID int64) error {
+	query := "SELECT EXISTS (SELECT 1 FROM orders WHERE id = $1 AND status IN ($2, $3) AND confirmed_at IS NULL)"
+	var modifiable bool
+	err := or.db.QueryRow(query, orderID, domain.OrderStatusScheduled, domain.OrderStatusPending).Scan(&modifiable)
+	if err != nil {
+		return err
+	}
+	if modifiable {
+		return ErrOrderChanged
+	}
+	return ErrInvalidStatusTransition
+}
+
+func (or *orderRepository) GetOrderHistory(orderID int64) ([]*domain.OrderHistoryEntry, error) {
+	query := `
+		SELECT id, order_id, changes, previous_address_id, address_id, previous_total, total, created_at
+		FROM order_history
+		WHERE order_id = $1
+		ORDER BY id
+	`
+	rows, err := or.db.Query(query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]*domain.OrderHistoryEntry, 0)
+	for rows.Next() {
+		entry := &domain.OrderHistoryEntry{}
+		err := rows.Scan(&entry.ID, &entry.OrderID, pq.Array(&entry.Changes), &entry.PreviousAddressID, &entry.AddressID,
+			&entry.PreviousTotal, &entry.Total, &entry.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
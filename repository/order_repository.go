@@ -5,7 +5,7 @@ import (
 	"errors"
 	"foodDelivery/domain"
 	"github.com/google/uuid"
-	"log"
+	"github.com/lib/pq"
 	"time"
 )
 
@@ -13,6 +13,25 @@ type OrderRepository interface {
 	SubmitOrder(order *domain.Order) error
 	GetOrderWithItems(orderID int64) (*domain.Order, error)
 	GetUserOrders(userId int64) (*[]domain.Order, error)
+	ReleaseScheduledOrders(now time.Time) ([]int64, error)
+	GetFoodDailySales(foodID int64, at time.Time) (int, error)
+	GetSupplierOrders(supplierID int64, status string) ([]*domain.Order, error)
+	UpdateOrderStatus(orderID int64, courierID int64, from []string, to string) error
+	AssignCourier(orderID int64, courierID int64) error
+	UnassignCourier(orderID int64, courierID int64) error
+	GetCourierOrders(courierID int64, statuses []string) ([]*domain.Order, error)
+	GetCourierOrderIDs(courierID int64, statuses []string) ([]int64, error)
+	GetUnassignedReadyOrders() ([]int64, error)
+	RecordPinAttempt(orderID int64) error
+	CompleteDelivery(orderID int64, courierID int64, proof *domain.DeliveryProof) error
+	ApplyFulfilment(order *domain.Order, changes []*domain.FulfilmentChange, walletRefund float32) error
+	ModifyOrder(order *domain.Order, entry *domain.OrderHistoryEntry, walletAdjustment float32) error
+	GetOrderHistory(orderID int64) ([]*domain.OrderHistoryEntry, error)
+}
+
+// queryRower is satisfied by both *sql.DB and *sql.Tx.
+type queryRower interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
 }
 
 type orderRepository struct {
@@ -22,6 +41,10 @@ type orderRepository struct {
 var (
 	ErrOrderNotFound = errors.New("order not found")
 	ErrItemsNotFound = errors.New("order must have at least one item")
+	ErrOutOfStock    = errors.New("not enough item in the stock")
+
+	ErrInvalidStatusTransition = errors.New("order is not in a state that allows this action")
+	ErrOrderChanged            = errors.New("order was changed in the meantime, reload it and try again")
 )
 
 func NewOrderRepository(db *sql.DB) OrderRepository {
@@ -34,8 +57,11 @@ func (or *orderRepository) GetUserOrders(userId int64) (*[]domain.Order, error)
 	var orders []domain.Order
 
 	query := `
-		SELECT o.id, o.user_id, u.name AS user_name, o.supplier_id, s.name AS supplier_name, o.address_id
-			o.tracking_id, o.status, o.price, o.created_at
+		SELECT o.id, o.user_id, u.name AS user_name, o.supplier_id, s.name AS supplier_name, o.fulfilment_type,
+			COALESCE(o.address_id, 0), o.table_number,
+			o.tracking_id, o.status, o.price, o.wallet_amount, o.loyalty_points, o.created_at, o.requested_delivery_at, o.release_at,
+			o.subtotal, o.delivery_fee, o.service_fee, o.tax, o.discount, o.tip,
+			COALESCE(o.courier_id, 0), o.assigned_at, o.picked_up_at, o.ready_at, o.delivered_at
 		FROM orders o
 		INNER JOIN users u ON o.user_id = u.id
 		INNER JOIN suppliers s ON o.supplier_id = s.id
@@ -50,18 +76,35 @@ func (or *orderRepository) GetUserOrders(userId int64) (*[]domain.Order, error)
 	defer rows.Close()
 
 	for rows.Next() {
-		var order domain.Order
+		order := domain.Order{Breakdown: &domain.PriceBreakdown{}}
 		err := rows.Scan(
 			&order.ID,
 			&order.UserID,
 			&order.UserName,
 			&order.SupplierID,
 			&order.SupplierName,
+			&order.FulfilmentType,
 			&order.AddressID,
+			&order.TableNumber,
 			&order.TrackingID,
 			&order.Status,
 			&order.Price,
+			&order.WalletAmount,
+			&order.LoyaltyPoints,
 			&order.CreatedAT,
+			&order.RequestedDeliveryAt,
+			&order.ReleaseAt,
+			&order.Breakdown.Subtotal,
+			&order.Breakdown.DeliveryFee,
+			&order.Breakdown.ServiceFee,
+			&order.Breakdown.Tax,
+			&order.Breakdown.Discount,
+			&order.Breakdown.Tip,
+			&order.CourierID,
+			&order.AssignedAt,
+			&order.PickedUpAt,
+			&order.ReadyAt,
+			&order.DeliveredAt,
 		)
 		if err != nil {
 			return nil, err
@@ -77,14 +120,20 @@ func (or *orderRepository) GetUserOrders(userId int64) (*[]domain.Order, error)
 }
 
 func (or *orderRepository) GetOrderWithItems(orderID int64) (*domain.Order, error) {
-	order := &domain.Order{}
+	order := &domain.Order{Breakdown: &domain.PriceBreakdown{}}
 
 	orderQuery := `
-		SELECT o.id, o.user_id, u.name AS user_name, o.supplier_id, s.name AS supplier_name, o.address_id
-			o.tracking_id, o.status, o.price, o.created_at
+		SELECT o.id, o.user_id, u.name AS user_name, o.supplier_id, s.name AS supplier_name, o.fulfilment_type,
+			COALESCE(o.address_id, 0), o.table_number,
+			o.tracking_id, o.status, o.price, o.wallet_amount, o.loyalty_points, o.created_at, o.requested_delivery_at, o.release_at,
+			o.subtotal, o.delivery_fee, o.service_fee, o.tax, o.discount, o.tip,
+			COALESCE(o.courier_id, 0), o.assigned_at, o.picked_up_at, o.ready_at, o.delivered_at, o.delivery_pin, o.delivery_pin_attempts,
+			o.pickup_code, COALESCE(c.code, ''), COALESCE(cr.amount, 0), o.substitution_preference, o.confirmed_at
 		FROM orders o
 		INNER JOIN users u ON o.user_id = u.id
 		INNER JOIN suppliers s ON o.supplier_id = s.id
+		LEFT JOIN coupon_redemptions cr ON cr.order_id = o.id
+		LEFT JOIN coupons c ON cr.coupon_id = c.id
 		WHERE o.id = $1
 	`
 	err := or.db.QueryRow(orderQuery, orderID).Scan(
@@ -93,21 +142,65 @@ func (or *orderRepository) GetOrderWithItems(orderID int64) (*domain.Order, erro
 		&order.UserName,
 		&order.SupplierID,
 		&order.SupplierName,
+		&order.FulfilmentType,
 		&order.AddressID,
+		&order.TableNumber,
 		&order.TrackingID,
 		&order.Status,
 		&order.Price,
+		&order.WalletAmount,
+		&order.LoyaltyPoints,
 		&order.CreatedAT,
+		&order.RequestedDeliveryAt,
+		&order.ReleaseAt,
+		&order.Breakdown.Subtotal,
+		&order.Breakdown.DeliveryFee,
+		&order.Breakdown.ServiceFee,
+		&order.Breakdown.Tax,
+		&order.Breakdown.Discount,
+		&order.Breakdown.Tip,
+		&order.CourierID,
+		&order.AssignedAt,
+		&order.PickedUpAt,
+		&order.ReadyAt,
+		&order.DeliveredAt,
+		&order.DeliveryPin,
+		&order.DeliveryPinAttempts,
+		&order.PickupCode,
+		&order.CouponCode,
+		&order.CouponDiscount,
+		&order.SubstitutionPreference,
+		&order.ConfirmedAt,
 	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, err
+	}
+	order.DeliveryProof, err = or.getDeliveryProof(orderID)
+	if err != nil {
+		return nil, err
+	}
+	order.Tips, err = or.getOrderTips(orderID)
+	if err != nil {
+		return nil, err
+	}
+	order.Breakdown.Taxes, err = or.getOrderTaxes(orderID)
+	if err != nil {
+		return nil, err
+	}
+	order.FulfilmentChanges, err = or.getFulfilmentChanges(orderID)
 	if err != nil {
 		return nil, err
 	}
 
 	itemsQuery := `
-		SELECT oi.id, oi.order_id, oi.food_id, f.name AS food_name, oi.quantity, oi.single_price
+		SELECT oi.id, oi.order_id, oi.food_id, COALESCE(f.name, '') AS food_name, oi.quantity, oi.single_price, oi.note
 		FROM order_items oi
-		INNER JOIN foods f ON oi.food_id = f.id
+		LEFT JOIN foods f ON oi.food_id = f.id
 		WHERE oi.order_id = $1
+		ORDER BY oi.id
 	`
 	rows, err := or.db.Query(itemsQuery, orderID)
 	if err != nil {
@@ -125,6 +218,7 @@ func (or *orderRepository) GetOrderWithItems(orderID int64) (*domain.Order, erro
 			&item.FoodName,
 			&item.Quantity,
 			&item.SinglePrice,
+			&item.Note,
 		)
 		if err != nil {
 			return nil, err
@@ -137,94 +231,447 @@ func (or *orderRepository) GetOrderWithItems(orderID int64) (*domain.Order, erro
 		return nil, err
 	}
 
+	err = or.attachItemModifiers(orderID, orderItems)
+	if err != nil {
+		return nil, err
+	}
+
 	order.Items = &orderItems
 	return order, nil
 }
 
-func (or *orderRepository) SubmitOrder(order *domain.Order) error {
-	tx, err := or.db.Begin()
+// attachItemModifiers loads the chosen modifiers of all lines of an order.
+func (or *orderRepository) attachItemModifiers(orderID int64, items []domain.OrderItem) error {
+	query := `
+		SELECT m.id, m.order_item_id, COALESCE(m.modifier_option_id, 0), m.group_name, m.name, m.price_delta
+		FROM order_item_modifiers m
+		INNER JOIN order_items oi ON m.order_item_id = oi.id
+		WHERE oi.order_id = $1
+		ORDER BY m.id
+	`
+	rows, err := or.db.Query(query, orderID)
 	if err != nil {
 		return err
 	}
+	defer rows.Close()
+
+	modifiers := make(map[int64][]*domain.OrderItemModifier)
+	for rows.Next() {
+		var orderItemID int64
+		modifier := &domain.OrderItemModifier{}
+		err := rows.Scan(&modifier.ID, &orderItemID, &modifier.ModifierOptionID, &modifier.GroupName, &modifier.Name, &modifier.PriceDelta)
+		if err != nil {
+			return err
+		}
+		modifiers[orderItemID] = append(modifiers[orderItemID], modifier)
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	for i := range items {
+		items[i].Modifiers = modifiers[items[i].ID]
+		if items[i].Modifiers == nil {
+			items[i].Modifiers = make([]*domain.OrderItemModifier, 0)
+		}
+	}
+	return nil
+}
+
+// GetSupplierOrders lists the orders of a supplier with their lines, newest first,
+// optionally limited to one status. Orders that were never paid are left out.
+func (or *orderRepository) GetSupplierOrders(supplierID int64, status string) ([]*domain.Order, error) {
+	query := `
+		SELECT o.id
+		FROM orders o
+		WHERE o.supplier_id = $1 AND ($2 = '' OR o.status = $2) AND o.status NOT IN ($3, $4)
+		ORDER BY o.created_at DESC
+	`
+	return or.getOrdersByQuery(query, supplierID, status, domain.OrderStatusAwaitingPayment, domain.OrderStatusPaymentFailed)
+}
+
+// GetCourierOrders lists the orders assigned to a courier, newest first, limited
+// to the given statuses when there are any.
+func (or *orderRepository) GetCourierOrders(courierID int64, statuses []string) ([]*domain.Order, error) {
+	query := `
+		SELECT o.id
+		FROM orders o
+		WHERE o.courier_id = $1 AND (cardinality($2::text[]) = 0 OR o.status = ANY($2))
+		ORDER BY o.created_at DESC
+	`
+	return or.getOrdersByQuery(query, courierID, pq.Array(statuses))
+}
+
+// GetCourierOrderIDs is GetCourierOrders without loading the orders.
+func (or *orderRepository) GetCourierOrderIDs(courierID int64, statuses []string) ([]int64, error) {
+	query := `
+		SELECT o.id
+		FROM orders o
+		WHERE o.courier_id = $1 AND (cardinality($2::text[]) = 0 OR o.status = ANY($2))
+		ORDER BY o.created_at DESC
+	`
+	return or.getOrderIDs(query, courierID, pq.Array(statuses))
+}
+
+// getOrdersByQuery loads the full orders whose IDs the query selects.
+func (or *orderRepository) getOrdersByQuery(query string, args ...interface{}) ([]*domain.Order, error) {
+	orderIDs, err := or.getOrderIDs(query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	orders := make([]*domain.Order, 0, len(orderIDs))
+	for _, orderID := range orderIDs {
+		order, err := or.GetOrderWithItems(orderID)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+	return orders, nil
+}
+
+// getOrderIDs returns the order IDs the query selects.
+func (or *orderRepository) getOrderIDs(query string, args ...interface{}) ([]int64, error) {
+	rows, err := or.db.Query(query, args...)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orderIDs []int64
+	for rows.Next() {
+		var orderID int64
+		if err := rows.Scan(&orderID); err != nil {
+			return nil, err
+		}
+		orderIDs = append(orderIDs, orderID)
 	}
-	order.CreatedAT = time.Now().UTC().Format("2006-01-02 15:04:05")
-	order.Price = 0.0
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return orderIDs, nil
+}
+
+// SubmitOrder stores a priced order with its lines, checking the daily stock of every food.
+func (or *orderRepository) SubmitOrder(order *domain.Order) error {
+	tx, err := or.db.Begin()
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	order.CreatedAT = now.Format("2006-01-02 15:04:05")
 	order.UserID = 7
 	order.TrackingID = uuid.New().String()
+	// The customer gives the courier the delivery pin, or shows the pickup code at the counter.
+	switch order.FulfilmentType {
+	case domain.FulfilmentTypeDelivery:
+		order.DeliveryPin, err = newDeliveryPin()
+	case domain.FulfilmentTypePickup:
+		order.PickupCode, err = newDeliveryPin()
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if order.Breakdown == nil {
+		order.Breakdown = &domain.PriceBreakdown{}
+	}
 
 	orderQuery := `
-		INSERT INTO orders (user_id, supplier_id, address_id, tracking_id, status, price, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6 , $7)
+		INSERT INTO orders (user_id, supplier_id, address_id, tracking_id, status, price, created_at, requested_delivery_at, release_at,
+			subtotal, delivery_fee, service_fee, tax, discount, tip, delivery_pin, wallet_amount, loyalty_points, substitution_preference,
+			fulfilment_type, table_number, pickup_code)
+		VALUES ($1, $2, NULLIF($3, 0), $4, $5, $6 , $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22)
 		RETURNING id
 	`
 	var orderID int64
-	err = tx.QueryRow(orderQuery, order.UserID, order.SupplierID, order.AddressID, order.TrackingID, order.Status, order.Price, order.CreatedAT).Scan(&orderID)
+	err = tx.QueryRow(orderQuery, order.UserID, order.SupplierID, order.AddressID, order.TrackingID, order.Status, order.Price,
+		order.CreatedAT, order.RequestedDeliveryAt, order.ReleaseAt, order.Breakdown.Subtotal, order.Breakdown.DeliveryFee,
+		order.Breakdown.ServiceFee, order.Breakdown.Tax, order.Breakdown.Discount, order.Breakdown.Tip,
+		order.DeliveryPin, order.WalletAmount, order.LoyaltyPoints, order.SubstitutionPreference,
+		order.FulfilmentType, order.TableNumber, order.PickupCode).Scan(&orderID)
 	if err != nil {
 		tx.Rollback()
 		return err
 	}
+	if order.WalletAmount > 0 {
+		err = debitWallet(tx, order.UserID, orderID, order.WalletAmount)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if order.LoyaltyPoints > 0 {
+		err = redeemPoints(tx, order.UserID, orderID, order.LoyaltyPoints)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if order.Coupon != nil {
+		var amount float32
+		for _, discount := range order.Breakdown.Discounts {
+			if discount.Code == order.Coupon.Code {
+				amount += discount.Amount
+			}
+		}
+		err = redeemCoupon(tx, order.Coupon, order.UserID, orderID, amount)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	err = insertTips(tx, orderID, order.Tips)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	err = insertOrderTaxes(tx, orderID, order.Breakdown.Taxes)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	// Scheduled orders use the stock of the day they are delivered on.
+	salesDay, err := getOrderSalesDay(tx, orderID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	err = or.insertOrderItems(tx, orderID, *order.Items, salesDay)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	order.ID = orderID
+	return nil
+}
+
+// insertOrderItems stores the lines of an order with their modifiers, checking the stock
+// of every food for the day ("YYYY-MM-DD") the order is delivered on.
+func (or *orderRepository) insertOrderItems(tx *sql.Tx, orderID int64, items []domain.OrderItem, salesDay string) error {
 	itemQuery := `
-	INSERT INTO order_items (order_id, food_id, quantity, single_price)
-	VALUES ($1, $2, $3, $4)
+	INSERT INTO order_items (order_id, food_id, quantity, single_price, note)
+	VALUES ($1, $2, $3, $4, $5)
+	RETURNING id
 `
-	var totalPrice float32
-	for _, item := range *order.Items {
+	modifierQuery := `
+	INSERT INTO order_item_modifiers (order_item_id, modifier_option_id, group_name, name, price_delta)
+	VALUES ($1, $2, $3, $4, $5)
+`
+	for i := range items {
+		item := &items[i]
 
-		todaySell, err := or.getDailyFoodSales(item.FoodID)
+		daySell, err := or.getDailyFoodSales(tx, item.FoodID, salesDay)
+		if err != nil {
+			return err
+		}
 		dailyQuantity, err := or.getFoodDailyQuantity(item.FoodID)
+		if err != nil {
+			return err
+		}
+		if (daySell + int(item.Quantity)) > dailyQuantity {
+			return ErrOutOfStock
+		}
 
-		if (todaySell + int(item.Quantity)) <= dailyQuantity {
-			var foodPrice float32
-			err = or.db.QueryRow("SELECT price FROM foods WHERE id = $1", item.FoodID).Scan(&foodPrice)
-			if err != nil {
-				tx.Rollback()
-				return err
-			}
-			singlePrice := foodPrice
-			_, err = tx.Exec(itemQuery, orderID, item.FoodID, item.Quantity, singlePrice)
+		err = tx.QueryRow(itemQuery, orderID, item.FoodID, item.Quantity, item.SinglePrice, item.Note).Scan(&item.ID)
+		if err != nil {
+			return err
+		}
+		item.OrderID = orderID
+		for _, modifier := range item.Modifiers {
+			_, err = tx.Exec(modifierQuery, item.ID, modifier.ModifierOptionID, modifier.GroupName, modifier.Name, modifier.PriceDelta)
 			if err != nil {
-				tx.Rollback()
 				return err
 			}
-			totalPrice += singlePrice * float32(item.Quantity)
-		} else {
-			return errors.New("not enough item in the stock")
 		}
 	}
+	return nil
+}
 
-	updateOrderQuery := `
-	UPDATE orders
-	SET price = $1
-	WHERE id = $2
-`
-	_, err = tx.Exec(updateOrderQuery, totalPrice, orderID)
+// UpdateOrderStatus moves an order to a new status when it is in one of the from statuses
+// and, for a courierID other than 0, assigned to that courier.
+func (or *orderRepository) UpdateOrderStatus(orderID int64, courierID int64, from []string, to string) error {
+	query := `
+		UPDATE orders
+		SET status = $1,
+			ready_at = CASE WHEN $1 = 'ready' THEN NOW() ELSE ready_at END,
+			picked_up_at = CASE WHEN $1 = 'picked_up' THEN NOW() ELSE picked_up_at END,
+			delivered_at = CASE WHEN $1 = 'delivered' THEN NOW() ELSE delivered_at END
+		WHERE id = $2 AND status = ANY($3) AND ($4 = 0 OR courier_id = $4)
+	`
+	result, err := or.db.Exec(query, to, orderID, pq.Array(from), courierID)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrInvalidStatusTransition
+	}
+	return nil
+}
 
-	err = tx.Commit()
+// AssignCourier hands a ready delivery order without a courier to the given courier.
+func (or *orderRepository) AssignCourier(orderID int64, courierID int64) error {
+	query := `
+		UPDATE orders
+		SET courier_id = $1, status = $2, assigned_at = NOW()
+		WHERE id = $3 AND status = $4 AND courier_id IS NULL AND fulfilment_type = $5
+	`
+	result, err := or.db.Exec(query, courierID, domain.OrderStatusAssigned, orderID, domain.OrderStatusReady,
+		domain.FulfilmentTypeDelivery)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		tx.Rollback()
 		return err
 	}
+	if rowsAffected == 0 {
+		return ErrInvalidStatusTransition
+	}
+	return nil
+}
 
+// UnassignCourier puts an order the courier has not accepted yet back to ready.
+func (or *orderRepository) UnassignCourier(orderID int64, courierID int64) error {
+	query := `
+		UPDATE orders
+		SET courier_id = NULL, status = $1, assigned_at = NULL
+		WHERE id = $2 AND courier_id = $3 AND status = $4
+	`
+	result, err := or.db.Exec(query, domain.OrderStatusReady, orderID, courierID, domain.OrderStatusAssigned)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrInvalidStatusTransition
+	}
 	return nil
 }
 
-func (or *orderRepository) getDailyFoodSales(foodID int64) (int, error) {
-	today := time.Now().UTC().Format("2006-01-02")
+// GetUnassignedReadyOrders returns the IDs of ready delivery orders waiting for a courier, oldest first.
+func (or *orderRepository) GetUnassignedReadyOrders() ([]int64, error) {
+	query := `
+		SELECT id
+		FROM orders
+		WHERE status = $1 AND courier_id IS NULL AND fulfilment_type = $2
+		ORDER BY created_at
+	`
+	rows, err := or.db.Query(query, domain.OrderStatusReady, domain.FulfilmentTypeDelivery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
+	var orderIDs []int64
+	for rows.Next() {
+		var orderID int64
+		if err := rows.Scan(&orderID); err != nil {
+			return nil, err
+		}
+		orderIDs = append(orderIDs, orderID)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return orderIDs, nil
+}
+
+// ReleaseScheduledOrders moves the scheduled orders whose release time has come
+// into the supplier queue and returns their IDs.
+func (or *orderRepository) ReleaseScheduledOrders(now time.Time) ([]int64, error) {
+	query := `
+		UPDATE orders
+		SET status = $1
+		WHERE status = $2 AND release_at <= $3
+		RETURNING id
+	`
+	rows, err := or.db.Query(query, domain.OrderStatusPending, domain.OrderStatusScheduled, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orderIDs []int64
+	for rows.Next() {
+		var orderID int64
+		if err := rows.Scan(&orderID); err != nil {
+			return nil, err
+		}
+		orderIDs = append(orderIDs, orderID)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return orderIDs, nil
+}
+
+// orderSalesDay is the day an order takes the stock of its foods, in the time zone of its
+// supplier: the delivery day of scheduled orders, the day it was placed otherwise. It
+// needs the order as o and its supplier as s, created_at is stored in UTC.
+const orderSalesDay = "(COALESCE(o.requested_delivery_at, o.created_at AT TIME ZONE 'UTC') AT TIME ZONE s.time_zone)::date"
+
+// GetFoodDailySales returns the quantity of a food already sold for the business day of
+// its supplier that the given time falls on.
+func (or *orderRepository) GetFoodDailySales(foodID int64, at time.Time) (int, error) {
+	query := `
+		SELECT to_char(($2::timestamptz AT TIME ZONE s.time_zone)::date, 'YYYY-MM-DD')
+		FROM foods f
+		INNER JOIN suppliers s ON f.supplier_id = s.id
+		WHERE f.id = $1
+	`
+	var day string
+	err := or.db.QueryRow(query, foodID, at).Scan(&day)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrFoodNotFound
+		}
+		return 0, err
+	}
+	return or.getDailyFoodSales(or.db, foodID, day)
+}
+
+// getOrderSalesDay returns the day ("YYYY-MM-DD") an order takes the stock of its foods.
+func getOrderSalesDay(q queryRower, orderID int64) (string, error) {
+	query := "SELECT to_char(" + orderSalesDay + ", 'YYYY-MM-DD') FROM orders o INNER JOIN suppliers s ON o.supplier_id = s.id WHERE o.id = $1"
+	var day string
+	err := q.QueryRow(query, orderID).Scan(&day)
+	return day, err
+}
+
+// getDailyFoodSales counts the quantity of a food sold for the given day, where
+// scheduled orders count on their delivery day instead of the day they were placed.
+func (or *orderRepository) getDailyFoodSales(q queryRower, foodID int64, day string) (int, error) {
 	query := `
 		SELECT COALESCE(SUM(oi.quantity), 0) AS total_sold
 		FROM order_items oi
 		WHERE oi.food_id = $1 AND oi.order_id IN (
-			SELECT id
-			FROM orders
-			WHERE created_at::date = $2
+			SELECT o.id
+			FROM orders o
+			INNER JOIN suppliers s ON o.supplier_id = s.id
+			WHERE ` + orderSalesDay + ` = $2
+				AND o.status NOT IN ($3, $4)
 		)
 	`
 
 	var totalSold int
-	err := or.db.QueryRow(query, foodID, today).Scan(&totalSold)
+	err := q.QueryRow(query, foodID, day, domain.OrderStatusPaymentFailed, domain.OrderStatusCancelled).Scan(&totalSold)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			totalSold = 0
@@ -254,3 +701,45 @@ func (or *orderRepository) getFoodDailyQuantity(foodID int64) (int, error) {
 
 	return dailyQuantity, nil
 }
+
+func insertOrderTaxes(tx *sql.Tx, orderID int64, taxes []*domain.TaxLine) error {
+	query := `
+		INSERT INTO order_taxes (order_id, name, rate, base, amount)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	for _, tax := range taxes {
+		_, err := tx.Exec(query, orderID, tax.Name, tax.Rate, tax.Base, tax.Amount)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (or *orderRepository) getOrderTaxes(orderID int64) ([]*domain.TaxLine, error) {
+	query := `
+		SELECT name, rate, base, amount
+		FROM order_taxes
+		WHERE order_id = $1
+		ORDER BY id
+	`
+	rows, err := or.db.Query(query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	taxes := make([]*domain.TaxLine, 0)
+	for rows.Next() {
+		tax := &domain.TaxLine{}
+		err := rows.Scan(&tax.Name, &tax.Rate, &tax.Base, &tax.Amount)
+		if err != nil {
+			return nil, err
+		}
+		taxes = append(taxes, tax)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return taxes, nil
+}
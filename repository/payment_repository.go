@@ -0,0 +1,226 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"foodDelivery/domain"
+	"github.com/lib/pq"
+	"math"
+)
+
+var (
+	ErrPaymentNotFound = errors.New("payment not found")
+	ErrRefundTooLarge  = errors.New("refund is more than what is left of the captured payment")
+)
+
+type PaymentRepository interface {
+	CreatePayment(payment *domain.Payment) error
+	UpdatePayment(payment *domain.Payment, orderFrom []string, orderTo string) error
+	RecordEvent(eventID string, payment *domain.Payment, orderFrom []string, orderTo string) (bool, error)
+	RefundPayment(paymentID int64, amount float32, toWallet bool, refund func(payment *domain.Payment) error) (*domain.Payment, error)
+	GetPaymentByID(paymentID int64) (*domain.Payment, error)
+	GetPaymentByReference(provider string, reference string) (*domain.Payment, error)
+	GetOrderPayments(orderID int64) ([]*domain.Payment, error)
+}
+
+type paymentRepository struct {
+	db *sql.DB
+}
+
+func NewPaymentRepository(db *sql.DB) PaymentRepository {
+	return &paymentRepository{
+		db: db,
+	}
+}
+
+const paymentColumns = "id, order_id, provider, reference, method, status, amount, captured_amount, refunded_amount, message, created_at, updated_at"
+
+func scanPayment(row rowScanner) (*domain.Payment, error) {
+	payment := &domain.Payment{}
+	err := row.Scan(&payment.ID, &payment.OrderID, &payment.Provider, &payment.Reference, &payment.Method, &payment.Status,
+		&payment.Amount, &payment.CapturedAmount, &payment.RefundedAmount, &payment.Message, &payment.CreatedAt, &payment.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return payment, nil
+}
+
+func (pr *paymentRepository) CreatePayment(payment *domain.Payment) error {
+	query := `
+		INSERT INTO payments (order_id, provider, reference, method, status, amount, message)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at, updated_at
+	`
+	return pr.db.QueryRow(query, payment.OrderID, payment.Provider, payment.Reference, payment.Method, payment.Status,
+		payment.Amount, payment.Message).Scan(&payment.ID, &payment.CreatedAt, &payment.UpdatedAt)
+}
+
+// UpdatePayment stores the state of a payment and, when orderTo is set, moves its order
+// to that status if it is still in one of the orderFrom statuses.
+func (pr *paymentRepository) UpdatePayment(payment *domain.Payment, orderFrom []string, orderTo string) error {
+	tx, err := pr.db.Begin()
+	if err != nil {
+		return err
+	}
+	err = savePayment(tx, payment, orderFrom, orderTo)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// RecordEvent marks a webhook event as processed and applies its payment update, if any,
+// in one transaction. It returns false when the event was already processed.
+func (pr *paymentRepository) RecordEvent(eventID string, payment *domain.Payment, orderFrom []string, orderTo string) (bool, error) {
+	tx, err := pr.db.Begin()
+	if err != nil {
+		return false, err
+	}
+
+	result, err := tx.Exec("INSERT INTO payment_events (event_id) VALUES ($1) ON CONFLICT (event_id) DO NOTHING", eventID)
+	if err != nil {
+		tx.Rollback()
+		return false, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return false, err
+	}
+	if rowsAffected == 0 {
+		tx.Rollback()
+		return false, nil
+	}
+
+	if payment != nil {
+		err = savePayment(tx, payment, orderFrom, orderTo)
+		if err != nil {
+			tx.Rollback()
+			return false, err
+		}
+	}
+	return true, tx.Commit()
+}
+
+// RefundPayment records a refund of a captured payment. The payment stays locked while the
+// amount is checked against what is left to refund and the money is given back, so that
+// concurrent refunds can not give back more than was captured. A wallet refund credits the
+// customer in the same transaction, any other is made by calling refund.
+func (pr *paymentRepository) RefundPayment(paymentID int64, amount float32, toWallet bool,
+	refund func(payment *domain.Payment) error) (*domain.Payment, error) {
+	tx, err := pr.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	payment, err := scanPayment(tx.QueryRow("SELECT "+paymentColumns+" FROM payments WHERE id = $1 FOR UPDATE", paymentID))
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrPaymentNotFound
+		}
+		return nil, err
+	}
+	if payment.Status != domain.PaymentStatusCaptured || amount > payment.CapturedAmount-payment.RefundedAmount {
+		tx.Rollback()
+		return nil, ErrRefundTooLarge
+	}
+
+	if toWallet {
+		var userID int64
+		err = tx.QueryRow("SELECT user_id FROM orders WHERE id = $1", payment.OrderID).Scan(&userID)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		err = creditWallet(tx, userID, &domain.WalletTransaction{
+			Type:        domain.WalletTransactionRefund,
+			Amount:      amount,
+			Description: "Refund of order payment",
+			OrderID:     payment.OrderID,
+			Reference:   payment.Reference,
+		})
+	} else {
+		err = refund(payment)
+	}
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	payment.RefundedAmount = float32(math.Round(float64(payment.RefundedAmount+amount)*100) / 100)
+	if payment.RefundedAmount >= payment.CapturedAmount {
+		payment.Status = domain.PaymentStatusRefunded
+	}
+	err = savePayment(tx, payment, nil, "")
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	return payment, tx.Commit()
+}
+
+func savePayment(tx *sql.Tx, payment *domain.Payment, orderFrom []string, orderTo string) error {
+	query := `
+		UPDATE payments
+		SET reference = $1, status = $2, captured_amount = $3, refunded_amount = $4, message = $5, updated_at = NOW()
+		WHERE id = $6
+		RETURNING updated_at
+	`
+	err := tx.QueryRow(query, payment.Reference, payment.Status, payment.CapturedAmount, payment.RefundedAmount,
+		payment.Message, payment.ID).Scan(&payment.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrPaymentNotFound
+		}
+		return err
+	}
+	if orderTo == "" {
+		return nil
+	}
+	_, err = tx.Exec("UPDATE orders SET status = $1 WHERE id = $2 AND status = ANY($3)", orderTo, payment.OrderID,
+		pq.Array(orderFrom))
+	return err
+}
+
+func (pr *paymentRepository) GetPaymentByID(paymentID int64) (*domain.Payment, error) {
+	return pr.getPayment("SELECT "+paymentColumns+" FROM payments WHERE id = $1", paymentID)
+}
+
+func (pr *paymentRepository) GetPaymentByReference(provider string, reference string) (*domain.Payment, error) {
+	return pr.getPayment("SELECT "+paymentColumns+" FROM payments WHERE provider = $1 AND reference = $2", provider, reference)
+}
+
+// GetOrderPayments lists the payments of an order, oldest first.
+func (pr *paymentRepository) GetOrderPayments(orderID int64) ([]*domain.Payment, error) {
+	rows, err := pr.db.Query("SELECT "+paymentColumns+" FROM payments WHERE order_id = $1 ORDER BY id", orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	payments := make([]*domain.Payment, 0)
+	for rows.Next() {
+		payment, err := scanPayment(rows)
+		if err != nil {
+			return nil, err
+		}
+		payments = append(payments, payment)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
+func (pr *paymentRepository) getPayment(query string, args ...interface{}) (*domain.Payment, error) {
+	payment, err := scanPayment(pr.db.QueryRow(query, args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrPaymentNotFound
+		}
+		return nil, err
+	}
+	return payment, nil
+}
@@ -0,0 +1,206 @@
+package repository
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"foodDelivery/domain"
+	"math/big"
+)
+
+var (
+	ErrReferralNotFound     = errors.New("referral not found")
+	ErrReferralCodeNotFound = errors.New("referral code not found")
+	ErrReferralCompleted    = errors.New("referral is already completed")
+)
+
+// referralCodeAlphabet leaves out the characters that are easy to mix up.
+const referralCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+type ReferralRepository interface {
+	GetReferralCode(userID int64) (string, error)
+	GetReferrerByCode(code string) (*domain.User, error)
+	CreateReferral(referral *domain.Referral) error
+	GetReferralByReferredID(userID int64) (*domain.Referral, error)
+	GetUserReferrals(referrerID int64) ([]*domain.Referral, error)
+	CompleteReferral(referral *domain.Referral, rewards []*domain.ReferralReward) error
+}
+
+type referralRepository struct {
+	db *sql.DB
+}
+
+func NewReferralRepository(db *sql.DB) ReferralRepository {
+	return &referralRepository{
+		db: db,
+	}
+}
+
+const referralColumns = `r.id, r.referrer_id, r.referred_id, u.name, r.device_id, r.status, r.reason, COALESCE(r.order_id, 0),
+	r.referrer_coupon, r.referred_coupon, r.created_at, r.completed_at`
+
+// GetReferralCode returns the invite code of a user, giving them one on first use.
+func (rr *referralRepository) GetReferralCode(userID int64) (string, error) {
+	var code sql.NullString
+	err := rr.db.QueryRow("SELECT referral_code FROM users WHERE id = $1", userID).Scan(&code)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrUserNotFound
+		}
+		return "", err
+	}
+	if code.Valid {
+		return code.String, nil
+	}
+
+	// A new code can collide with the one of another user, so a few are tried.
+	for attempt := 0; attempt < 5; attempt++ {
+		newCode, err := newReferralCode()
+		if err != nil {
+			return "", err
+		}
+		_, err = rr.db.Exec("UPDATE users SET referral_code = $1 WHERE id = $2 AND referral_code IS NULL", newCode, userID)
+		if err != nil {
+			if isUniqueViolation(err) {
+				continue
+			}
+			return "", err
+		}
+		// Reading it back returns the code of a concurrent call that got there first.
+		err = rr.db.QueryRow("SELECT referral_code FROM users WHERE id = $1", userID).Scan(&code)
+		if err != nil {
+			return "", err
+		}
+		return code.String, nil
+	}
+	return "", errors.New("could not generate a unique referral code")
+}
+
+func (rr *referralRepository) GetReferrerByCode(code string) (*domain.User, error) {
+	query := "SELECT id, name, last_name, phone, email, status, device_id FROM users WHERE referral_code = $1"
+	user := &domain.User{}
+	err := rr.db.QueryRow(query, code).Scan(&user.ID, &user.Name, &user.LastName, &user.Phone, &user.Email,
+		&user.Status, &user.DeviceID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrReferralCodeNotFound
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+func (rr *referralRepository) CreateReferral(referral *domain.Referral) error {
+	query := `
+		INSERT INTO referrals (referrer_id, referred_id, device_id, status, reason, completed_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+	return rr.db.QueryRow(query, referral.ReferrerID, referral.ReferredID, referral.DeviceID, referral.Status,
+		referral.Reason, referral.CompletedAt).Scan(&referral.ID, &referral.CreatedAt)
+}
+
+func (rr *referralRepository) GetReferralByReferredID(userID int64) (*domain.Referral, error) {
+	query := `
+		SELECT ` + referralColumns + `
+		FROM referrals r
+		INNER JOIN users u ON r.referred_id = u.id
+		WHERE r.referred_id = $1
+	`
+	referrals, err := rr.getReferrals(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(referrals) == 0 {
+		return nil, ErrReferralNotFound
+	}
+	return referrals[0], nil
+}
+
+// GetUserReferrals lists the friends a user invited, newest first.
+func (rr *referralRepository) GetUserReferrals(referrerID int64) ([]*domain.Referral, error) {
+	query := `
+		SELECT ` + referralColumns + `
+		FROM referrals r
+		INNER JOIN users u ON r.referred_id = u.id
+		WHERE r.referrer_id = $1
+		ORDER BY r.created_at DESC
+	`
+	return rr.getReferrals(query, referrerID)
+}
+
+// CompleteReferral stores the outcome of a pending referral and gives its rewards in the
+// same transaction, so a referral is never rewarded twice or marked rewarded without its
+// rewards. Only one caller can complete a referral, the others get ErrReferralCompleted.
+func (rr *referralRepository) CompleteReferral(referral *domain.Referral, rewards []*domain.ReferralReward) error {
+	tx, err := rr.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE referrals
+		SET status = $1, reason = $2, order_id = NULLIF($3, 0), referrer_coupon = $4, referred_coupon = $5, completed_at = NOW()
+		WHERE id = $6 AND status = $7
+		RETURNING completed_at
+	`
+	err = tx.QueryRow(query, referral.Status, referral.Reason, referral.OrderID, referral.ReferrerCoupon,
+		referral.ReferredCoupon, referral.ID, domain.ReferralStatusPending).Scan(&referral.CompletedAt)
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrReferralCompleted
+		}
+		return err
+	}
+
+	for _, reward := range rewards {
+		if reward.Coupon != nil {
+			err = insertCoupon(tx, reward.Coupon)
+		} else {
+			err = creditWallet(tx, reward.UserID, reward.Credit)
+		}
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (rr *referralRepository) getReferrals(query string, args ...interface{}) ([]*domain.Referral, error) {
+	rows, err := rr.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	referrals := make([]*domain.Referral, 0)
+	for rows.Next() {
+		referral := &domain.Referral{}
+		err := rows.Scan(&referral.ID, &referral.ReferrerID, &referral.ReferredID, &referral.ReferredName, &referral.DeviceID,
+			&referral.Status, &referral.Reason, &referral.OrderID, &referral.ReferrerCoupon, &referral.ReferredCoupon,
+			&referral.CreatedAt, &referral.CompletedAt)
+		if err != nil {
+			return nil, err
+		}
+		referrals = append(referrals, referral)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return referrals, nil
+}
+
+// newReferralCode returns a random 8 character invite code.
+func newReferralCode() (string, error) {
+	code := make([]byte, 8)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(referralCodeAlphabet))))
+		if err != nil {
+			return "", err
+		}
+		code[i] = referralCodeAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
@@ -0,0 +1,220 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"foodDelivery/domain"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrReviewNotFound = errors.New("review not found")
+	ErrReviewExists   = errors.New("order has already been reviewed")
+	ErrReplyExists    = errors.New("review already has a reply")
+)
+
+type ReviewRepository interface {
+	CreateReview(review *domain.Review) error
+	GetReviewByOrderID(orderID int64) (*domain.Review, error)
+	GetSupplierReviews(supplierID int64) ([]*domain.Review, error)
+	GetFoodReviews(foodID int64) ([]*domain.Review, error)
+	ReplyToReview(supplierID int64, reviewID int64, reply string) error
+}
+
+type reviewRepository struct {
+	db *sql.DB
+}
+
+func NewReviewRepository(db *sql.DB) ReviewRepository {
+	return &reviewRepository{
+		db: db,
+	}
+}
+
+const reviewColumns = `r.id, r.order_id, r.user_id, u.name, r.supplier_id, r.supplier_rating, COALESCE(r.courier_id, 0),
+	COALESCE(r.courier_rating, 0), r.comment, r.reply, r.replied_at, r.created_at`
+
+// CreateReview stores a review with its food ratings and refreshes the aggregate
+// ratings of the supplier and the foods.
+func (rr *reviewRepository) CreateReview(review *domain.Review) error {
+	tx, err := rr.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO reviews (order_id, user_id, supplier_id, supplier_rating, courier_id, courier_rating, comment)
+		VALUES ($1, $2, $3, $4, NULLIF($5, 0), NULLIF($6, 0), $7)
+		RETURNING id, created_at
+	`
+	err = tx.QueryRow(query, review.OrderID, review.UserID, review.SupplierID, review.SupplierRating, review.CourierID,
+		review.CourierRating, review.Comment).Scan(&review.ID, &review.CreatedAt)
+	if err != nil {
+		tx.Rollback()
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return ErrReviewExists
+		}
+		return err
+	}
+
+	foodQuery := `
+		INSERT INTO food_reviews (review_id, food_id, rating, comment)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`
+	foodAggregateQuery := `
+		UPDATE foods
+		SET rating_average = (SELECT COALESCE(AVG(rating), 0) FROM food_reviews WHERE food_id = $1),
+			rating_count = (SELECT COUNT(*) FROM food_reviews WHERE food_id = $1)
+		WHERE id = $1
+	`
+	for _, food := range review.Foods {
+		food.ReviewID = review.ID
+		err = tx.QueryRow(foodQuery, review.ID, food.FoodID, food.Rating, food.Comment).Scan(&food.ID)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		_, err = tx.Exec(foodAggregateQuery, food.FoodID)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	_, err = tx.Exec(`
+		UPDATE suppliers
+		SET rating_average = (SELECT COALESCE(AVG(supplier_rating), 0) FROM reviews WHERE supplier_id = $1),
+			rating_count = (SELECT COUNT(*) FROM reviews WHERE supplier_id = $1)
+		WHERE id = $1
+	`, review.SupplierID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (rr *reviewRepository) GetReviewByOrderID(orderID int64) (*domain.Review, error) {
+	query := `
+		SELECT ` + reviewColumns + `
+		FROM reviews r
+		INNER JOIN users u ON r.user_id = u.id
+		WHERE r.order_id = $1
+	`
+	reviews, err := rr.getReviews(query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if len(reviews) == 0 {
+		return nil, ErrReviewNotFound
+	}
+	return reviews[0], nil
+}
+
+func (rr *reviewRepository) GetSupplierReviews(supplierID int64) ([]*domain.Review, error) {
+	query := `
+		SELECT ` + reviewColumns + `
+		FROM reviews r
+		INNER JOIN users u ON r.user_id = u.id
+		WHERE r.supplier_id = $1
+		ORDER BY r.created_at DESC
+	`
+	return rr.getReviews(query, supplierID)
+}
+
+// GetFoodReviews returns the reviews that rated the food, newest first.
+func (rr *reviewRepository) GetFoodReviews(foodID int64) ([]*domain.Review, error) {
+	query := `
+		SELECT ` + reviewColumns + `
+		FROM reviews r
+		INNER JOIN users u ON r.user_id = u.id
+		WHERE EXISTS (SELECT 1 FROM food_reviews fr WHERE fr.review_id = r.id AND fr.food_id = $1)
+		ORDER BY r.created_at DESC
+	`
+	return rr.getReviews(query, foodID)
+}
+
+// ReplyToReview stores the single public reply a supplier can give to a review.
+func (rr *reviewRepository) ReplyToReview(supplierID int64, reviewID int64, reply string) error {
+	var existingReply string
+	err := rr.db.QueryRow("SELECT reply FROM reviews WHERE id = $1 AND supplier_id = $2", reviewID, supplierID).Scan(&existingReply)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrReviewNotFound
+		}
+		return err
+	}
+
+	result, err := rr.db.Exec("UPDATE reviews SET reply = $1, replied_at = NOW() WHERE id = $2 AND reply = ''", reply, reviewID)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrReplyExists
+	}
+	return nil
+}
+
+func (rr *reviewRepository) getReviews(query string, args ...interface{}) ([]*domain.Review, error) {
+	rows, err := rr.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reviews := make([]*domain.Review, 0)
+	reviewsByID := make(map[int64]*domain.Review)
+	reviewIDs := make([]int64, 0)
+	for rows.Next() {
+		review := &domain.Review{Foods: make([]*domain.FoodReview, 0)}
+		err := rows.Scan(&review.ID, &review.OrderID, &review.UserID, &review.UserName, &review.SupplierID, &review.SupplierRating,
+			&review.CourierID, &review.CourierRating, &review.Comment, &review.Reply, &review.RepliedAt, &review.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, review)
+		reviewsByID[review.ID] = review
+		reviewIDs = append(reviewIDs, review.ID)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(reviewIDs) == 0 {
+		return reviews, nil
+	}
+
+	foodQuery := `
+		SELECT fr.id, fr.review_id, fr.food_id, COALESCE(f.name, ''), fr.rating, fr.comment
+		FROM food_reviews fr
+		LEFT JOIN foods f ON fr.food_id = f.id
+		WHERE fr.review_id = ANY($1)
+		ORDER BY fr.id
+	`
+	foodRows, err := rr.db.Query(foodQuery, pq.Array(reviewIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer foodRows.Close()
+
+	for foodRows.Next() {
+		food := &domain.FoodReview{}
+		err := foodRows.Scan(&food.ID, &food.ReviewID, &food.FoodID, &food.FoodName, &food.Rating, &food.Comment)
+		if err != nil {
+			return nil, err
+		}
+		review := reviewsByID[food.ReviewID]
+		review.Foods = append(review.Foods, food)
+	}
+	if err = foodRows.Err(); err != nil {
+		return nil, err
+	}
+
+	return reviews, nil
+}
@@ -0,0 +1,484 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"foodDelivery/domain"
+	"github.com/lib/pq"
+	"time"
+)
+
+var (
+	ErrCommissionRateNotFound = errors.New("commission rate not found")
+	ErrCommissionRateOverlap  = errors.New("commission rate overlaps another rate of the supplier")
+	ErrSettlementNotFound     = errors.New("settlement not found")
+	ErrAlreadySettled         = errors.New("orders or adjustments are already settled")
+	ErrPayoutTransition       = errors.New("settlement payout cannot move to this status")
+)
+
+type SettlementRepository interface {
+	GetCommissionRates(supplierID int64) ([]*domain.CommissionRate, error)
+	CreateCommissionRate(rate *domain.CommissionRate) error
+	DeleteCommissionRate(supplierID int64, rateID int64) error
+	GetAdjustments(supplierID int64) ([]*domain.SettlementAdjustment, error)
+	CreateAdjustment(adjustment *domain.SettlementAdjustment) error
+	CreateRefundAdjustments(from time.Time) error
+	GetUnsettledSupplierIDs(from time.Time, to time.Time) ([]int64, error)
+	GetUnsettledLines(supplierID int64, from time.Time, to time.Time) ([]*domain.SettlementLine, error)
+	GetUnsettledAdjustments(supplierID int64, from time.Time, to time.Time) ([]*domain.SettlementAdjustment, error)
+	CreateSettlement(settlement *domain.Settlement) error
+	GetSettlements(supplierID int64, status string) ([]*domain.Settlement, error)
+	GetSettlementByID(settlementID int64) (*domain.Settlement, error)
+	UpdatePayout(settlementID int64, from []string, update *domain.PayoutUpdate) error
+}
+
+type settlementRepository struct {
+	db *sql.DB
+}
+
+func NewSettlementRepository(db *sql.DB) SettlementRepository {
+	return &settlementRepository{
+		db: db,
+	}
+}
+
+const settlementColumns = `st.id, st.supplier_id, s.name, to_char(st.period_from, 'YYYY-MM-DD'), to_char(st.period_to, 'YYYY-MM-DD'),
+	st.orders, st.sales, st.commission, st.refunds, st.tips, st.adjustments, st.payout, st.status, st.payout_reference,
+	st.created_at, st.paid_at`
+
+const adjustmentColumns = `id, supplier_id, COALESCE(settlement_id, 0), COALESCE(order_id, 0), amount, description,
+	to_char(effective_on, 'YYYY-MM-DD'), created_at`
+
+func (sr *settlementRepository) GetCommissionRates(supplierID int64) ([]*domain.CommissionRate, error) {
+	query := `
+		SELECT id, supplier_id, rate, to_char(starts_on, 'YYYY-MM-DD'), COALESCE(to_char(ends_on, 'YYYY-MM-DD'), '')
+		FROM commission_rates
+		WHERE supplier_id = $1
+		ORDER BY starts_on
+	`
+	rows, err := sr.db.Query(query, supplierID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rates := make([]*domain.CommissionRate, 0)
+	for rows.Next() {
+		rate := &domain.CommissionRate{}
+		err := rows.Scan(&rate.ID, &rate.SupplierID, &rate.Rate, &rate.StartsOn, &rate.EndsOn)
+		if err != nil {
+			return nil, err
+		}
+		rates = append(rates, rate)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
+
+// CreateCommissionRate stores a rate of a supplier unless it overlaps one of its other
+// rates. The supplier is locked so two overlapping rates cannot be added at once.
+func (sr *settlementRepository) CreateCommissionRate(rate *domain.CommissionRate) error {
+	tx, err := sr.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	var supplierID int64
+	err = tx.QueryRow("SELECT id FROM suppliers WHERE id = $1 FOR UPDATE", rate.SupplierID).Scan(&supplierID)
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrSupplierNotFound
+		}
+		return err
+	}
+	overlapQuery := `
+		SELECT EXISTS (
+			SELECT 1 FROM commission_rates
+			WHERE supplier_id = $1 AND (ends_on IS NULL OR ends_on >= $2::date)
+				AND (NULLIF($3, '')::date IS NULL OR starts_on <= NULLIF($3, '')::date)
+		)
+	`
+	var overlaps bool
+	err = tx.QueryRow(overlapQuery, rate.SupplierID, rate.StartsOn, rate.EndsOn).Scan(&overlaps)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if overlaps {
+		tx.Rollback()
+		return ErrCommissionRateOverlap
+	}
+
+	query := `
+		INSERT INTO commission_rates (supplier_id, rate, starts_on, ends_on)
+		VALUES ($1, $2, $3, NULLIF($4, '')::date)
+		RETURNING id
+	`
+	err = tx.QueryRow(query, rate.SupplierID, rate.Rate, rate.StartsOn, rate.EndsOn).Scan(&rate.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (sr *settlementRepository) DeleteCommissionRate(supplierID int64, rateID int64) error {
+	result, err := sr.db.Exec("DELETE FROM commission_rates WHERE id = $1 AND supplier_id = $2", rateID, supplierID)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrCommissionRateNotFound
+	}
+	return nil
+}
+
+func (sr *settlementRepository) GetAdjustments(supplierID int64) ([]*domain.SettlementAdjustment, error) {
+	query := "SELECT " + adjustmentColumns + " FROM settlement_adjustments WHERE supplier_id = $1 ORDER BY effective_on DESC, id DESC"
+	return sr.getAdjustments(query, supplierID)
+}
+
+func (sr *settlementRepository) CreateAdjustment(adjustment *domain.SettlementAdjustment) error {
+	query := `
+		INSERT INTO settlement_adjustments (supplier_id, amount, description, effective_on)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`
+	err := sr.db.QueryRow(query, adjustment.SupplierID, adjustment.Amount, adjustment.Description,
+		adjustment.EffectiveOn).Scan(&adjustment.ID, &adjustment.CreatedAt)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return ErrSupplierNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// CreateRefundAdjustments takes the refunds given after an order was settled off a later
+// payout: what the settlement of an order and its earlier refund adjustments did not take
+// back becomes a new adjustment. It is dated on the last payment change of the order, but
+// not before from, so that the settlements starting at from pick up the refunds found late.
+func (sr *settlementRepository) CreateRefundAdjustments(from time.Time) error {
+	tx, err := sr.db.Begin()
+	if err != nil {
+		return err
+	}
+	// Two runs at once would both see the same refund as not carried yet.
+	_, err = tx.Exec("LOCK TABLE settlement_adjustments IN SHARE ROW EXCLUSIVE MODE")
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	query := `
+		INSERT INTO settlement_adjustments (supplier_id, order_id, amount, description, effective_on)
+		SELECT o.supplier_id, o.id, -late.amount, 'Refund of order ' || o.id || ' after its settlement',
+			GREATEST(late.refunded_on, $1::date)
+		FROM (
+			SELECT so.order_id, p.refunded_on,
+				LEAST(p.refunded, so.sales + so.tips) - so.refunds +
+					COALESCE((SELECT SUM(a.amount) FROM settlement_adjustments a WHERE a.order_id = so.order_id), 0) AS amount
+			FROM settlement_orders so
+			INNER JOIN (
+				SELECT order_id, SUM(refunded_amount) AS refunded, MAX(updated_at)::date AS refunded_on
+				FROM payments
+				GROUP BY order_id
+			) p ON p.order_id = so.order_id
+		) late
+		INNER JOIN orders o ON late.order_id = o.id
+		WHERE late.amount > 0
+	`
+	_, err = tx.Exec(query, from.Format("2006-01-02"))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetUnsettledSupplierIDs returns the suppliers with orders delivered or adjustments
+// effective in the period that are not settled yet.
+func (sr *settlementRepository) GetUnsettledSupplierIDs(from time.Time, to time.Time) ([]int64, error) {
+	query := `
+		SELECT o.supplier_id
+		FROM orders o
+		WHERE o.status = $1 AND o.delivered_at >= $2 AND o.delivered_at < $3
+			AND NOT EXISTS (SELECT 1 FROM settlement_orders so WHERE so.order_id = o.id)
+		UNION
+		SELECT a.supplier_id
+		FROM settlement_adjustments a
+		WHERE a.settlement_id IS NULL AND a.effective_on >= $4 AND a.effective_on < $5
+		ORDER BY 1
+	`
+	rows, err := sr.db.Query(query, domain.OrderStatusDelivered, from, to, from.Format("2006-01-02"), to.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	supplierIDs := make([]int64, 0)
+	for rows.Next() {
+		var supplierID int64
+		err := rows.Scan(&supplierID)
+		if err != nil {
+			return nil, err
+		}
+		supplierIDs = append(supplierIDs, supplierID)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return supplierIDs, nil
+}
+
+// GetUnsettledLines returns the orders of a supplier delivered in the period that are not
+// settled yet, with their food sales, supplier tips and refunded amounts.
+func (sr *settlementRepository) GetUnsettledLines(supplierID int64, from time.Time, to time.Time) ([]*domain.SettlementLine, error) {
+	query := `
+		SELECT o.id, o.delivered_at, o.subtotal,
+			COALESCE((SELECT SUM(t.amount) FROM order_tips t WHERE t.order_id = o.id AND t.recipient = $1), 0),
+			COALESCE((SELECT SUM(p.refunded_amount) FROM payments p WHERE p.order_id = o.id), 0)
+		FROM orders o
+		WHERE o.supplier_id = $2 AND o.status = $3 AND o.delivered_at >= $4 AND o.delivered_at < $5
+			AND NOT EXISTS (SELECT 1 FROM settlement_orders so WHERE so.order_id = o.id)
+		ORDER BY o.delivered_at, o.id
+	`
+	rows, err := sr.db.Query(query, domain.TipRecipientSupplier, supplierID, domain.OrderStatusDelivered, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lines := make([]*domain.SettlementLine, 0)
+	for rows.Next() {
+		line := &domain.SettlementLine{}
+		err := rows.Scan(&line.OrderID, &line.DeliveredAt, &line.Sales, &line.Tips, &line.Refunds)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
+
+func (sr *settlementRepository) GetUnsettledAdjustments(supplierID int64, from time.Time, to time.Time) ([]*domain.SettlementAdjustment, error) {
+	query := "SELECT " + adjustmentColumns + ` FROM settlement_adjustments
+		WHERE supplier_id = $1 AND settlement_id IS NULL AND effective_on >= $2 AND effective_on < $3
+		ORDER BY effective_on, id`
+	return sr.getAdjustments(query, supplierID, from.Format("2006-01-02"), to.Format("2006-01-02"))
+}
+
+// CreateSettlement stores a settlement with its lines and takes its adjustments. It fails
+// with ErrAlreadySettled when another settlement took one of them first.
+func (sr *settlementRepository) CreateSettlement(settlement *domain.Settlement) error {
+	tx, err := sr.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO settlements (supplier_id, period_from, period_to, orders, sales, commission, refunds, tips, adjustments,
+			payout, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id, created_at
+	`
+	err = tx.QueryRow(query, settlement.SupplierID, settlement.PeriodFrom, settlement.PeriodTo, settlement.Orders,
+		settlement.Sales, settlement.Commission, settlement.Refunds, settlement.Tips, settlement.Adjustments,
+		settlement.Payout, settlement.Status).Scan(&settlement.ID, &settlement.CreatedAt)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	lineQuery := `
+		INSERT INTO settlement_orders (settlement_id, order_id, delivered_at, sales, commission_rate, commission, refunds, tips, net)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+	for _, line := range settlement.Lines {
+		_, err = tx.Exec(lineQuery, settlement.ID, line.OrderID, line.DeliveredAt, line.Sales, line.CommissionRate,
+			line.Commission, line.Refunds, line.Tips, line.Net)
+		if err != nil {
+			tx.Rollback()
+			if isUniqueViolation(err) {
+				return ErrAlreadySettled
+			}
+			return err
+		}
+	}
+	for _, adjustment := range settlement.AdjustmentItems {
+		result, err := tx.Exec("UPDATE settlement_adjustments SET settlement_id = $1 WHERE id = $2 AND settlement_id IS NULL",
+			settlement.ID, adjustment.ID)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		if rowsAffected == 0 {
+			tx.Rollback()
+			return ErrAlreadySettled
+		}
+		adjustment.SettlementID = settlement.ID
+	}
+	return tx.Commit()
+}
+
+// GetSettlements lists settlements, newest first, optionally of one supplier or in one status.
+func (sr *settlementRepository) GetSettlements(supplierID int64, status string) ([]*domain.Settlement, error) {
+	query := `
+		SELECT ` + settlementColumns + `
+		FROM settlements st
+		INNER JOIN suppliers s ON st.supplier_id = s.id
+		WHERE ($1 = 0 OR st.supplier_id = $1) AND ($2 = '' OR st.status = $2)
+		ORDER BY st.period_to DESC, st.id DESC
+	`
+	rows, err := sr.db.Query(query, supplierID, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	settlements := make([]*domain.Settlement, 0)
+	for rows.Next() {
+		settlement, err := scanSettlement(rows)
+		if err != nil {
+			return nil, err
+		}
+		settlements = append(settlements, settlement)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return settlements, nil
+}
+
+// GetSettlementByID returns a settlement with its lines and adjustments.
+func (sr *settlementRepository) GetSettlementByID(settlementID int64) (*domain.Settlement, error) {
+	query := `
+		SELECT ` + settlementColumns + `
+		FROM settlements st
+		INNER JOIN suppliers s ON st.supplier_id = s.id
+		WHERE st.id = $1
+	`
+	settlement, err := scanSettlement(sr.db.QueryRow(query, settlementID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrSettlementNotFound
+		}
+		return nil, err
+	}
+
+	lineQuery := `
+		SELECT order_id, delivered_at, sales, commission_rate, commission, refunds, tips, net
+		FROM settlement_orders
+		WHERE settlement_id = $1
+		ORDER BY delivered_at, order_id
+	`
+	rows, err := sr.db.Query(lineQuery, settlementID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	settlement.Lines = make([]*domain.SettlementLine, 0)
+	for rows.Next() {
+		line := &domain.SettlementLine{}
+		err := rows.Scan(&line.OrderID, &line.DeliveredAt, &line.Sales, &line.CommissionRate, &line.Commission,
+			&line.Refunds, &line.Tips, &line.Net)
+		if err != nil {
+			return nil, err
+		}
+		settlement.Lines = append(settlement.Lines, line)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	adjustmentQuery := "SELECT " + adjustmentColumns + " FROM settlement_adjustments WHERE settlement_id = $1 ORDER BY effective_on, id"
+	settlement.AdjustmentItems, err = sr.getAdjustments(adjustmentQuery, settlementID)
+	if err != nil {
+		return nil, err
+	}
+	return settlement, nil
+}
+
+// UpdatePayout moves a settlement in one of the from statuses to the new payout status.
+func (sr *settlementRepository) UpdatePayout(settlementID int64, from []string, update *domain.PayoutUpdate) error {
+	query := `
+		UPDATE settlements
+		SET status = $1, payout_reference = CASE WHEN $2 = '' THEN payout_reference ELSE $2 END,
+			paid_at = CASE WHEN $1 = $3 THEN NOW() ELSE paid_at END
+		WHERE id = $4 AND status = ANY($5)
+	`
+	result, err := sr.db.Exec(query, update.Status, update.Reference, domain.SettlementStatusPaid, settlementID, pq.Array(from))
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected > 0 {
+		return nil
+	}
+
+	var exists bool
+	err = sr.db.QueryRow("SELECT EXISTS (SELECT 1 FROM settlements WHERE id = $1)", settlementID).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrSettlementNotFound
+	}
+	return ErrPayoutTransition
+}
+
+func (sr *settlementRepository) getAdjustments(query string, args ...interface{}) ([]*domain.SettlementAdjustment, error) {
+	rows, err := sr.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	adjustments := make([]*domain.SettlementAdjustment, 0)
+	for rows.Next() {
+		adjustment := &domain.SettlementAdjustment{}
+		err := rows.Scan(&adjustment.ID, &adjustment.SupplierID, &adjustment.SettlementID, &adjustment.OrderID, &adjustment.Amount,
+			&adjustment.Description, &adjustment.EffectiveOn, &adjustment.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		adjustments = append(adjustments, adjustment)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return adjustments, nil
+}
+
+func scanSettlement(row rowScanner) (*domain.Settlement, error) {
+	settlement := &domain.Settlement{}
+	err := row.Scan(&settlement.ID, &settlement.SupplierID, &settlement.SupplierName, &settlement.PeriodFrom,
+		&settlement.PeriodTo, &settlement.Orders, &settlement.Sales, &settlement.Commission, &settlement.Refunds,
+		&settlement.Tips, &settlement.Adjustments, &settlement.Payout, &settlement.Status, &settlement.PayoutReference,
+		&settlement.CreatedAt, &settlement.PaidAt)
+	if err != nil {
+		return nil, err
+	}
+	return settlement, nil
+}
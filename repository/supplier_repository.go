@@ -12,6 +12,7 @@ type SupplierRepository interface {
 	UpdateSupplier(supplier *domain.Supplier) error
 	DeleteSupplier(supplierID int64) error
 	GetAllSuppliers() ([]*domain.Supplier, error)
+	GetNearbySuppliers(query domain.NearbyQuery, speedKmh float64) ([]*domain.Supplier, int, error)
 }
 
 type supplierRepository struct {
@@ -25,46 +26,132 @@ func NewSupplierRepository(db *sql.DB) SupplierRepository {
 }
 
 var (
-	ErrSupplierNotFound = errors.New("food not found")
+	ErrSupplierNotFound = errors.New("supplier not found")
 )
 
-func (sr *supplierRepository) GetSupplierByID(supplierID int64) (*domain.Supplier, error) {
-	query := "SELECT id,name,address,description,logo_url,opening_hour,closing_hour,user_id,delivery_time FROM suppliers WHERE id = $1"
-	row := sr.db.QueryRow(query, supplierID)
+const supplierColumns = "id,name,address,description,logo_url,opening_hour,closing_hour,user_id,delivery_time,time_zone,prep_time,delivery_fee_type,delivery_fee,delivery_fee_per_km,free_delivery_above," +
+	"min_order_subtotal,max_items,max_food_quantity,latitude,longitude," +
+	"rating_average,rating_count"
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanSupplier reads the supplierColumns of a row, followed by any extra columns of the query.
+func scanSupplier(row rowScanner, extra ...interface{}) (*domain.Supplier, error) {
 	supplier := &domain.Supplier{}
-	err := row.Scan(&supplier.ID, &supplier.Name, &supplier.Address, &supplier.Description, &supplier.LogoUrl,
-		&supplier.OpeningHour, &supplier.ClosingHour, &supplier.UserID, &supplier.DeliveryTime)
+	var latitude, longitude sql.NullFloat64
+	dest := []interface{}{&supplier.ID, &supplier.Name, &supplier.Address, &supplier.Description, &supplier.LogoUrl,
+		&supplier.OpeningHour, &supplier.ClosingHour, &supplier.UserID, &supplier.DeliveryTime, &supplier.TimeZone, &supplier.PrepTime,
+		&supplier.DeliveryFeeType, &supplier.DeliveryFee, &supplier.DeliveryFeePerKm, &supplier.FreeDeliveryAbove,
+		&supplier.MinOrderSubtotal, &supplier.MaxItems, &supplier.MaxFoodQuantity, &latitude, &longitude,
+		&supplier.RatingAverage, &supplier.RatingCount}
+	err := row.Scan(append(dest, extra...)...)
+	if err != nil {
+		return nil, err
+	}
+	if latitude.Valid && longitude.Valid {
+		supplier.Location = &domain.GeoPoint{Lat: latitude.Float64, Lng: longitude.Float64}
+	}
+	return supplier, nil
+}
+
+func (sr *supplierRepository) GetSupplierByID(supplierID int64) (*domain.Supplier, error) {
+	query := "SELECT " + supplierColumns + " FROM suppliers WHERE id = $1"
+	supplier, err := scanSupplier(sr.db.QueryRow(query, supplierID))
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrSupplierNotFound
+		}
 		return nil, err
 	}
 	return supplier, nil
 }
 
+// CreateSupplier stores a supplier with its opening hours and delivery zones, if given,
+// in one transaction.
 func (sr *supplierRepository) CreateSupplier(supplier *domain.Supplier) error {
-	query := "INSERT INTO suppliers (name, address, description, logo_url, opening_hour, closing_hour, user_id, delivery_time) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)"
-	result, err := sr.db.Exec(query, supplier.Name, supplier.Address, supplier.Description, supplier.LogoUrl, supplier.OpeningHour, supplier.ClosingHour, supplier.UserID, supplier.DeliveryTime)
-	rowsAffected, err := result.RowsAffected()
-	if rowsAffected == 0 {
-		return errors.New("validation error: supplier not created")
+	tx, err := sr.db.Begin()
+	if err != nil {
+		return err
 	}
+
+	query := `
+		INSERT INTO suppliers (name, address, description, logo_url, opening_hour, closing_hour, user_id, delivery_time, time_zone, prep_time,
+			delivery_fee_type, delivery_fee, delivery_fee_per_km, free_delivery_above, min_order_subtotal, max_items, max_food_quantity,
+			latitude, longitude)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
+		RETURNING id
+	`
+	latitude, longitude := locationValues(supplier.Location)
+	err = tx.QueryRow(query, supplier.Name, supplier.Address, supplier.Description, supplier.LogoUrl, supplier.OpeningHour,
+		supplier.ClosingHour, supplier.UserID, supplier.DeliveryTime, supplier.TimeZone, supplier.PrepTime,
+		supplier.DeliveryFeeType, supplier.DeliveryFee, supplier.DeliveryFeePerKm, supplier.FreeDeliveryAbove,
+		supplier.MinOrderSubtotal, supplier.MaxItems, supplier.MaxFoodQuantity, latitude, longitude).Scan(&supplier.ID)
 	if err != nil {
+		tx.Rollback()
 		return err
 	}
 
-	return nil
+	err = saveSupplierDetails(tx, supplier)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
 }
 
+// UpdateSupplier updates a supplier and replaces its opening hours and delivery zones, if
+// given, in one transaction.
 func (sr *supplierRepository) UpdateSupplier(supplier *domain.Supplier) error {
-	query := "UPDATE suppliers SET name = $1, address = $2, description = $3, logo_url = $4, opening_hour = $5, closing_hour = $6, user_id = $7, delivery_time = $8 WHERE id = $9"
-	result, err := sr.db.Exec(query, supplier.Name, supplier.Address, supplier.Description, supplier.LogoUrl, supplier.OpeningHour, supplier.ClosingHour, supplier.UserID, supplier.DeliveryTime, supplier.ID)
+	tx, err := sr.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE suppliers
+		SET name = $1, address = $2, description = $3, logo_url = $4, opening_hour = $5, closing_hour = $6, user_id = $7,
+			delivery_time = $8, time_zone = $9, prep_time = $10, delivery_fee_type = $11, delivery_fee = $12,
+			delivery_fee_per_km = $13, free_delivery_above = $14, min_order_subtotal = $15, max_items = $16,
+			max_food_quantity = $17, latitude = $18, longitude = $19
+		WHERE id = $20
+	`
+	latitude, longitude := locationValues(supplier.Location)
+	result, err := tx.Exec(query, supplier.Name, supplier.Address, supplier.Description, supplier.LogoUrl, supplier.OpeningHour,
+		supplier.ClosingHour, supplier.UserID, supplier.DeliveryTime, supplier.TimeZone, supplier.PrepTime,
+		supplier.DeliveryFeeType, supplier.DeliveryFee, supplier.DeliveryFeePerKm, supplier.FreeDeliveryAbove,
+		supplier.MinOrderSubtotal, supplier.MaxItems, supplier.MaxFoodQuantity, latitude, longitude, supplier.ID)
 	if err != nil {
+		tx.Rollback()
 		return err
 	}
 	rowsAffected, err := result.RowsAffected()
 	if rowsAffected == 0 {
+		tx.Rollback()
 		return errors.New("validation error: supplier not updated")
 	}
 
+	err = saveSupplierDetails(tx, supplier)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// saveSupplierDetails replaces the opening hours and delivery zones of a supplier. Each of
+// them is only replaced when the supplier carries it.
+func saveSupplierDetails(tx *sql.Tx, supplier *domain.Supplier) error {
+	if supplier.OpeningHours != nil {
+		err := replaceOpeningHours(tx, supplier.ID, supplier.OpeningHours)
+		if err != nil {
+			return err
+		}
+	}
+	if supplier.DeliveryZones != nil {
+		return replaceZones(tx, supplier.ID, supplier.DeliveryZones)
+	}
 	return nil
 }
 
@@ -83,7 +170,7 @@ func (sr *supplierRepository) DeleteSupplier(supplierID int64) error {
 }
 
 func (sr *supplierRepository) GetAllSuppliers() ([]*domain.Supplier, error) {
-	query := "SELECT id,name,address,description,logo_url,opening_hour,closing_hour,user_id,delivery_time FROM suppliers"
+	query := "SELECT " + supplierColumns + " FROM suppliers"
 	rows, err := sr.db.Query(query)
 	if err != nil {
 		return nil, err
@@ -92,9 +179,7 @@ func (sr *supplierRepository) GetAllSuppliers() ([]*domain.Supplier, error) {
 
 	suppliers := make([]*domain.Supplier, 0)
 	for rows.Next() {
-		supplier := &domain.Supplier{}
-		err := rows.Scan(&supplier.ID, &supplier.Name, &supplier.Address, &supplier.Description, &supplier.LogoUrl,
-			&supplier.OpeningHour, &supplier.ClosingHour, &supplier.UserID, &supplier.DeliveryTime)
+		supplier, err := scanSupplier(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -106,3 +191,58 @@ func (sr *supplierRepository) GetAllSuppliers() ([]*domain.Supplier, error) {
 
 	return suppliers, nil
 }
+
+// nearbySuppliers selects the suppliers with coordinates and their haversine distance
+// in km to the point ($1, $2).
+const nearbySuppliers = `
+	SELECT *, 2 * 6371 * ASIN(SQRT(
+		POWER(SIN(RADIANS(latitude - $1) / 2), 2) +
+		COS(RADIANS($1)) * COS(RADIANS(latitude)) * POWER(SIN(RADIANS(longitude - $2) / 2), 2)
+	)) AS distance_km
+	FROM suppliers
+	WHERE latitude IS NOT NULL AND longitude IS NOT NULL
+`
+
+// GetNearbySuppliers returns the suppliers within the radius of a point, fastest first.
+// The estimate is the preparation time plus the travel time at speedKmh, the distance
+// is the haversine distance. It also returns the number of suppliers in the radius.
+func (sr *supplierRepository) GetNearbySuppliers(query domain.NearbyQuery, speedKmh float64) ([]*domain.Supplier, int, error) {
+	sqlQuery := `
+		SELECT ` + supplierColumns + `, distance_km, COUNT(*) OVER () AS total
+		FROM (` + nearbySuppliers + `) nearby
+		WHERE distance_km <= $3
+		ORDER BY prep_time + distance_km / $4 * 60, distance_km, id
+		LIMIT $5 OFFSET $6
+	`
+	rows, err := sr.db.Query(sqlQuery, query.Point.Lat, query.Point.Lng, query.RadiusKm, speedKmh, query.Limit,
+		(query.Page-1)*query.Limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	total := 0
+	suppliers := make([]*domain.Supplier, 0)
+	for rows.Next() {
+		var distanceKm float64
+		supplier, err := scanSupplier(rows, &distanceKm, &total)
+		if err != nil {
+			return nil, 0, err
+		}
+		supplier.DistanceKm = distanceKm
+		suppliers = append(suppliers, supplier)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	// Past the last page no row carries the total.
+	if len(suppliers) == 0 && query.Page > 1 {
+		countQuery := "SELECT COUNT(*) FROM (" + nearbySuppliers + ") nearby WHERE distance_km <= $3"
+		err = sr.db.QueryRow(countQuery, query.Point.Lat, query.Point.Lng, query.RadiusKm).Scan(&total)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	return suppliers, total, nil
+}
@@ -0,0 +1,145 @@
+package repository_test
+
+import (
+	"database/sql"
+	"foodDelivery/domain"
+	"foodDelivery/migrations"
+	"foodDelivery/repository"
+	"foodDelivery/seeder"
+	"math"
+	"os"
+	"testing"
+
+	_ "github.com/lib/pq"
+)
+
+const courierSpeedKmh = 30
+
+var nearbyCenter = domain.GeoPoint{Lat: 52.52, Lng: 13.405}
+
+// openTestDB connects to the database in TEST_DATABASE_URL and empties the suppliers table.
+// The tables it touches are truncated, so it must never point at a real database.
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	for _, migrate := range []func(*sql.DB) error{migrations.CreateUsersTable, migrations.UpdateUsersTable,
+		migrations.CreateSuppliersTable, migrations.UpdateSuppliersTable} {
+		if err := migrate(db); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err = db.Exec("TRUNCATE suppliers RESTART IDENTITY CASCADE")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(`
+		INSERT INTO users (id, name, last_name, phone, email, password, status)
+		VALUES (7, 'Test', 'Supplier', '000', 'supplier@example.com', '', 'active')
+		ON CONFLICT (id) DO NOTHING
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// seedNearby creates suppliers at fixed distances from the center and returns them by name.
+// With the courier at 30 km/h the estimates are b 16, d 18, g 23.8, c 24 and a 32 minutes;
+// e is outside a 5 km radius.
+func seedNearby(t *testing.T, supplierRepo repository.SupplierRepository) map[string]*domain.Supplier {
+	t.Helper()
+	names := []string{"a", "b", "c", "d", "g", "e"}
+	sites := []seeder.SupplierSite{
+		{Location: seeder.OffsetKm(nearbyCenter, 1, 0), PrepTime: 30},
+		{Location: seeder.OffsetKm(nearbyCenter, 0, 3), PrepTime: 10},
+		{Location: seeder.OffsetKm(nearbyCenter, -2, 0), PrepTime: 20},
+		{Location: seeder.OffsetKm(nearbyCenter, 0, -4), PrepTime: 10},
+		{Location: seeder.OffsetKm(nearbyCenter, 2.4, 0), PrepTime: 19},
+		{Location: seeder.OffsetKm(nearbyCenter, 8, 0), PrepTime: 5},
+	}
+	suppliers := seeder.NewSupplierSeeder(supplierRepo).SeedSuppliersAt(sites)
+	if len(suppliers) != len(sites) {
+		t.Fatalf("seeded %d suppliers, want %d", len(suppliers), len(sites))
+	}
+	byName := make(map[string]*domain.Supplier)
+	for i, supplier := range suppliers {
+		byName[names[i]] = supplier
+	}
+	return byName
+}
+
+func assertNearby(t *testing.T, got []*domain.Supplier, want []*domain.Supplier) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d suppliers, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].ID != want[i].ID {
+			t.Errorf("supplier %d is %d, want %d", i, got[i].ID, want[i].ID)
+		}
+	}
+}
+
+func TestGetNearbySuppliersFiltersByRadiusAndRanksByEstimate(t *testing.T) {
+	supplierRepo := repository.NewSupplierRepository(openTestDB(t))
+	s := seedNearby(t, supplierRepo)
+
+	query := domain.NearbyQuery{Point: nearbyCenter, RadiusKm: 5, Page: 1, Limit: 20}
+	suppliers, total, err := supplierRepo.GetNearbySuppliers(query, courierSpeedKmh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 5 {
+		t.Errorf("total is %d, want 5", total)
+	}
+	assertNearby(t, suppliers, []*domain.Supplier{s["b"], s["d"], s["g"], s["c"], s["a"]})
+
+	wantDistances := map[int64]float64{s["a"].ID: 1, s["b"].ID: 3, s["c"].ID: 2, s["d"].ID: 4, s["g"].ID: 2.4}
+	for _, supplier := range suppliers {
+		if math.Abs(supplier.DistanceKm-wantDistances[supplier.ID]) > 0.01 {
+			t.Errorf("supplier %d is %.3f km away, want %.1f", supplier.ID, supplier.DistanceKm, wantDistances[supplier.ID])
+		}
+	}
+
+	query.RadiusKm = 2.2
+	suppliers, total, err = supplierRepo.GetNearbySuppliers(query, courierSpeedKmh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 2 {
+		t.Errorf("total within 2.2 km is %d, want 2", total)
+	}
+	assertNearby(t, suppliers, []*domain.Supplier{s["c"], s["a"]})
+}
+
+func TestGetNearbySuppliersPages(t *testing.T) {
+	supplierRepo := repository.NewSupplierRepository(openTestDB(t))
+	s := seedNearby(t, supplierRepo)
+
+	pages := [][]*domain.Supplier{
+		{s["b"], s["d"]},
+		{s["g"], s["c"]},
+		{s["a"]},
+		{},
+	}
+	for i, want := range pages {
+		query := domain.NearbyQuery{Point: nearbyCenter, RadiusKm: 5, Page: i + 1, Limit: 2}
+		suppliers, total, err := supplierRepo.GetNearbySuppliers(query, courierSpeedKmh)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if total != 5 {
+			t.Errorf("page %d: total is %d, want 5", query.Page, total)
+		}
+		assertNearby(t, suppliers, want)
+	}
+}
@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"foodDelivery/domain"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrHolidayNotFound = errors.New("holiday not found")
+)
+
+type SupplierScheduleRepository interface {
+	GetOpeningHoursBySupplierID(supplierID int64) ([]*domain.OpeningInterval, error)
+	GetOpeningHoursBySupplierIDs(supplierIDs []int64) (map[int64][]*domain.OpeningInterval, error)
+	GetHolidaysBySupplierID(supplierID int64) ([]*domain.Holiday, error)
+	GetHolidaysBySupplierIDs(supplierIDs []int64) (map[int64][]*domain.Holiday, error)
+	CreateHoliday(holiday *domain.Holiday) error
+	DeleteHoliday(supplierID int64, holidayID int64) error
+}
+
+type supplierScheduleRepository struct {
+	db *sql.DB
+}
+
+func NewSupplierScheduleRepository(db *sql.DB) SupplierScheduleRepository {
+	return &supplierScheduleRepository{
+		db: db,
+	}
+}
+
+func (sr *supplierScheduleRepository) GetOpeningHoursBySupplierID(supplierID int64) ([]*domain.OpeningInterval, error) {
+	openingHours, err := sr.GetOpeningHoursBySupplierIDs([]int64{supplierID})
+	if err != nil {
+		return nil, err
+	}
+	return openingHours[supplierID], nil
+}
+
+// GetOpeningHoursBySupplierIDs loads the weekly schedules of several suppliers in one query,
+// by supplier. Every requested supplier gets a list, empty when it has no opening hours.
+func (sr *supplierScheduleRepository) GetOpeningHoursBySupplierIDs(supplierIDs []int64) (map[int64][]*domain.OpeningInterval, error) {
+	query := `
+		SELECT id, supplier_id, weekday, opens_at, closes_at
+		FROM supplier_opening_hours
+		WHERE supplier_id = ANY($1)
+		ORDER BY supplier_id, weekday, opens_at
+	`
+	rows, err := sr.db.Query(query, pq.Array(supplierIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	openingHours := make(map[int64][]*domain.OpeningInterval, len(supplierIDs))
+	for _, supplierID := range supplierIDs {
+		openingHours[supplierID] = make([]*domain.OpeningInterval, 0)
+	}
+	for rows.Next() {
+		interval := &domain.OpeningInterval{}
+		err := rows.Scan(&interval.ID, &interval.SupplierID, &interval.Weekday, &interval.OpensAt, &interval.ClosesAt)
+		if err != nil {
+			return nil, err
+		}
+		openingHours[interval.SupplierID] = append(openingHours[interval.SupplierID], interval)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return openingHours, nil
+}
+
+// replaceOpeningHours replaces the whole weekly schedule of a supplier within the
+// transaction that saves the supplier.
+func replaceOpeningHours(tx *sql.Tx, supplierID int64, intervals []*domain.OpeningInterval) error {
+	_, err := tx.Exec("DELETE FROM supplier_opening_hours WHERE supplier_id = $1", supplierID)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO supplier_opening_hours (supplier_id, weekday, opens_at, closes_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`
+	for _, interval := range intervals {
+		interval.SupplierID = supplierID
+		err = tx.QueryRow(query, supplierID, interval.Weekday, interval.OpensAt, interval.ClosesAt).Scan(&interval.ID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (sr *supplierScheduleRepository) GetHolidaysBySupplierID(supplierID int64) ([]*domain.Holiday, error) {
+	holidays, err := sr.GetHolidaysBySupplierIDs([]int64{supplierID})
+	if err != nil {
+		return nil, err
+	}
+	return holidays[supplierID], nil
+}
+
+// GetHolidaysBySupplierIDs loads the holidays of several suppliers in one query, by supplier.
+// Every requested supplier gets a list, empty when it has no holidays.
+func (sr *supplierScheduleRepository) GetHolidaysBySupplierIDs(supplierIDs []int64) (map[int64][]*domain.Holiday, error) {
+	query := `
+		SELECT id, supplier_id, to_char(date, 'YYYY-MM-DD'), reason
+		FROM supplier_holidays
+		WHERE supplier_id = ANY($1)
+		ORDER BY supplier_id, date
+	`
+	rows, err := sr.db.Query(query, pq.Array(supplierIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	holidays := make(map[int64][]*domain.Holiday, len(supplierIDs))
+	for _, supplierID := range supplierIDs {
+		holidays[supplierID] = make([]*domain.Holiday, 0)
+	}
+	for rows.Next() {
+		holiday := &domain.Holiday{}
+		err := rows.Scan(&holiday.ID, &holiday.SupplierID, &holiday.Date, &holiday.Reason)
+		if err != nil {
+			return nil, err
+		}
+		holidays[holiday.SupplierID] = append(holidays[holiday.SupplierID], holiday)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return holidays, nil
+}
+
+func (sr *supplierScheduleRepository) CreateHoliday(holiday *domain.Holiday) error {
+	query := `
+		INSERT INTO supplier_holidays (supplier_id, date, reason)
+		VALUES ($1, $2, $3)
+		RETURNING id
+	`
+	return sr.db.QueryRow(query, holiday.SupplierID, holiday.Date, holiday.Reason).Scan(&holiday.ID)
+}
+
+func (sr *supplierScheduleRepository) DeleteHoliday(supplierID int64, holidayID int64) error {
+	result, err := sr.db.Exec("DELETE FROM supplier_holidays WHERE id = $1 AND supplier_id = $2", holidayID, supplierID)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrHolidayNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"foodDelivery/domain"
+)
+
+var (
+	ErrTaxRateNotFound = errors.New("tax rate not found")
+)
+
+type TaxRateRepository interface {
+	GetAllTaxRates() ([]*domain.TaxRate, error)
+	CreateTaxRate(taxRate *domain.TaxRate) error
+	UpdateTaxRate(taxRate *domain.TaxRate) error
+	DeleteTaxRate(taxRateID int64) error
+}
+
+type taxRateRepository struct {
+	db *sql.DB
+}
+
+func NewTaxRateRepository(db *sql.DB) TaxRateRepository {
+	return &taxRateRepository{
+		db: db,
+	}
+}
+
+func (tr *taxRateRepository) GetAllTaxRates() ([]*domain.TaxRate, error) {
+	rows, err := tr.db.Query("SELECT id, name, COALESCE(category_id, 0), rate FROM tax_rates ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	taxRates := make([]*domain.TaxRate, 0)
+	for rows.Next() {
+		taxRate := &domain.TaxRate{}
+		err := rows.Scan(&taxRate.ID, &taxRate.Name, &taxRate.CategoryID, &taxRate.Rate)
+		if err != nil {
+			return nil, err
+		}
+		taxRates = append(taxRates, taxRate)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return taxRates, nil
+}
+
+func (tr *taxRateRepository) CreateTaxRate(taxRate *domain.TaxRate) error {
+	query := "INSERT INTO tax_rates (name, category_id, rate) VALUES ($1, NULLIF($2, 0), $3) RETURNING id"
+	return tr.db.QueryRow(query, taxRate.Name, taxRate.CategoryID, taxRate.Rate).Scan(&taxRate.ID)
+}
+
+func (tr *taxRateRepository) UpdateTaxRate(taxRate *domain.TaxRate) error {
+	query := "UPDATE tax_rates SET name = $1, category_id = NULLIF($2, 0), rate = $3 WHERE id = $4"
+	result, err := tr.db.Exec(query, taxRate.Name, taxRate.CategoryID, taxRate.Rate, taxRate.ID)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrTaxRateNotFound
+	}
+	return nil
+}
+
+func (tr *taxRateRepository) DeleteTaxRate(taxRateID int64) error {
+	result, err := tr.db.Exec("DELETE FROM tax_rates WHERE id = $1", taxRateID)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrTaxRateNotFound
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"foodDelivery/domain"
+	"github.com/lib/pq"
+	"time"
+)
+
+var (
+	ErrTipExists = errors.New("recipient has already been tipped for this order")
+)
+
+type TipRepository interface {
+	AddTips(order *domain.Order, tips []*domain.Tip, fromWallet bool) error
+	RemoveTips(orderID int64, tips []*domain.Tip) error
+	GetCourierEarnings(courierID int64, from time.Time, to time.Time) (*domain.Earnings, error)
+	GetSupplierEarnings(supplierID int64, from time.Time, to time.Time) (*domain.Earnings, error)
+}
+
+type tipRepository struct {
+	db *sql.DB
+}
+
+func NewTipRepository(db *sql.DB) TipRepository {
+	return &tipRepository{
+		db: db,
+	}
+}
+
+// AddTips stores tips given after checkout and adds them to the order total. Tips paid
+// from the wallet are debited in the same transaction.
+func (tr *tipRepository) AddTips(order *domain.Order, tips []*domain.Tip, fromWallet bool) error {
+	tx, err := tr.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	var amount, walletAmount float32
+	for _, tip := range tips {
+		amount += tip.Amount
+	}
+	if fromWallet {
+		walletAmount = amount
+	}
+	_, err = tx.Exec("UPDATE orders SET tip = tip + $1, price = price + $1, wallet_amount = wallet_amount + $2 WHERE id = $3",
+		amount, walletAmount, order.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	err = insertTips(tx, order.ID, tips)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if fromWallet {
+		err = adjustOrderWallet(tx, order.UserID, order.ID, -amount, "Order tip")
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// RemoveTips takes back tips stored by AddTips whose payment failed.
+func (tr *tipRepository) RemoveTips(orderID int64, tips []*domain.Tip) error {
+	tx, err := tr.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	var amount float32
+	tipIDs := make([]int64, 0, len(tips))
+	for _, tip := range tips {
+		amount += tip.Amount
+		tipIDs = append(tipIDs, tip.ID)
+	}
+	_, err = tx.Exec("DELETE FROM order_tips WHERE order_id = $1 AND id = ANY($2)", orderID, pq.Array(tipIDs))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	_, err = tx.Exec("UPDATE orders SET tip = tip - $1, price = price - $1 WHERE id = $2", amount, orderID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetCourierEarnings sums the delivery fees and courier tips of the orders the courier delivered in the period.
+func (tr *tipRepository) GetCourierEarnings(courierID int64, from time.Time, to time.Time) (*domain.Earnings, error) {
+	query := `
+		SELECT COUNT(*), COALESCE(SUM(o.delivery_fee), 0), COALESCE(SUM(t.amount), 0)
+		FROM orders o
+		LEFT JOIN order_tips t ON t.order_id = o.id AND t.recipient = $1
+		WHERE o.courier_id = $2 AND o.status = $3 AND o.delivered_at >= $4 AND o.delivered_at < $5
+	`
+	return tr.getEarnings(query, domain.TipRecipientCourier, courierID, from, to)
+}
+
+// GetSupplierEarnings sums the subtotals and supplier tips of the orders of the supplier delivered in the period.
+func (tr *tipRepository) GetSupplierEarnings(supplierID int64, from time.Time, to time.Time) (*domain.Earnings, error) {
+	query := `
+		SELECT COUNT(*), COALESCE(SUM(o.subtotal), 0), COALESCE(SUM(t.amount), 0)
+		FROM orders o
+		LEFT JOIN order_tips t ON t.order_id = o.id AND t.recipient = $1
+		WHERE o.supplier_id = $2 AND o.status = $3 AND o.delivered_at >= $4 AND o.delivered_at < $5
+	`
+	return tr.getEarnings(query, domain.TipRecipientSupplier, supplierID, from, to)
+}
+
+func (tr *tipRepository) getEarnings(query string, recipient string, ownerID int64, from time.Time, to time.Time) (*domain.Earnings, error) {
+	earnings := &domain.Earnings{From: from, To: to}
+	err := tr.db.QueryRow(query, recipient, ownerID, domain.OrderStatusDelivered, from, to).Scan(&earnings.Orders, &earnings.OrderEarnings, &earnings.Tips)
+	if err != nil {
+		return nil, err
+	}
+	earnings.Total = earnings.OrderEarnings + earnings.Tips
+	return earnings, nil
+}
+
+// insertTips stores the tips of an order inside the transaction that places or tips it.
+func insertTips(tx *sql.Tx, orderID int64, tips []*domain.Tip) error {
+	query := `
+		INSERT INTO order_tips (order_id, recipient, type, value, amount)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+	for _, tip := range tips {
+		tip.OrderID = orderID
+		err := tx.QueryRow(query, orderID, tip.Recipient, tip.Type, tip.Value, tip.Amount).Scan(&tip.ID, &tip.CreatedAt)
+		if err != nil {
+			var pqErr *pq.Error
+			if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+				return ErrTipExists
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (or *orderRepository) getOrderTips(orderID int64) ([]*domain.Tip, error) {
+	query := `
+		SELECT id, order_id, recipient, type, value, amount, created_at
+		FROM order_tips
+		WHERE order_id = $1
+		ORDER BY id
+	`
+	rows, err := or.db.Query(query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tips := make([]*domain.Tip, 0)
+	for rows.Next() {
+		tip := &domain.Tip{}
+		err := rows.Scan(&tip.ID, &tip.OrderID, &tip.Recipient, &tip.Type, &tip.Value, &tip.Amount, &tip.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		tips = append(tips, tip)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return tips, nil
+}
@@ -85,8 +85,13 @@ func (ur *userRepository) RegisterUser(user *domain.User) error {
 
 	userStatus := "deactive"
 
-	query := "INSERT INTO users (name, last_name, phone, email, password, status) VALUES ($1, $2, $3, $4, $5, $6)"
-	_, err := ur.db.Exec(query, user.Name, user.LastName, user.Phone, user.Email, user.Password, userStatus)
+	query := `
+		INSERT INTO users (name, last_name, phone, email, password, status, device_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`
+	err := ur.db.QueryRow(query, user.Name, user.LastName, user.Phone, user.Email, user.Password, userStatus,
+		user.DeviceID).Scan(&user.ID)
 	if err != nil {
 		return err
 	}
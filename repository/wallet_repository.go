@@ -0,0 +1,270 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"foodDelivery/domain"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrInsufficientBalance = errors.New("wallet balance is too low")
+)
+
+// systemAccounts are the platform side of the ledger, by wallet transaction type.
+var systemAccounts = map[string]string{
+	domain.WalletTransactionTopUp:           "payments",
+	domain.WalletTransactionRefund:          "refunds",
+	domain.WalletTransactionPromotion:       "promotions",
+	domain.WalletTransactionOrderPayment:    "orders",
+	domain.WalletTransactionOrderReversal:   "orders",
+	domain.WalletTransactionOrderAdjustment: "orders",
+}
+
+type WalletRepository interface {
+	GetBalance(userID int64) (float32, error)
+	GetTransactions(userID int64) ([]*domain.WalletTransaction, error)
+	Credit(userID int64, transaction *domain.WalletTransaction) error
+	ReverseOrderPayment(orderID int64) error
+}
+
+type walletRepository struct {
+	db *sql.DB
+}
+
+func NewWalletRepository(db *sql.DB) WalletRepository {
+	return &walletRepository{
+		db: db,
+	}
+}
+
+func (wr *walletRepository) GetBalance(userID int64) (float32, error) {
+	query := `
+		SELECT COALESCE(SUM(e.amount), 0)
+		FROM ledger_entries e
+		INNER JOIN ledger_accounts a ON e.account_id = a.id
+		WHERE a.user_id = $1
+	`
+	var balance float32
+	err := wr.db.QueryRow(query, userID).Scan(&balance)
+	if err != nil {
+		return 0, err
+	}
+	return balance, nil
+}
+
+// GetTransactions lists the movements of a wallet, newest first.
+func (wr *walletRepository) GetTransactions(userID int64) ([]*domain.WalletTransaction, error) {
+	query := `
+		SELECT t.id, t.type, e.amount, t.description, COALESCE(t.order_id, 0), t.reference, t.created_at
+		FROM ledger_entries e
+		INNER JOIN ledger_accounts a ON e.account_id = a.id
+		INNER JOIN ledger_transactions t ON e.transaction_id = t.id
+		WHERE a.user_id = $1
+		ORDER BY t.id DESC
+	`
+	rows, err := wr.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transactions := make([]*domain.WalletTransaction, 0)
+	for rows.Next() {
+		transaction := &domain.WalletTransaction{}
+		err := rows.Scan(&transaction.ID, &transaction.Type, &transaction.Amount, &transaction.Description,
+			&transaction.OrderID, &transaction.Reference, &transaction.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, transaction)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
+
+// Credit adds a positive amount to the wallet of a user from the platform account of its type.
+func (wr *walletRepository) Credit(userID int64, transaction *domain.WalletTransaction) error {
+	tx, err := wr.db.Begin()
+	if err != nil {
+		return err
+	}
+	err = creditWallet(tx, userID, transaction)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// creditWallet adds a positive amount to the wallet of a user inside the given transaction.
+func creditWallet(tx *sql.Tx, userID int64, transaction *domain.WalletTransaction) error {
+	walletID, err := walletAccountID(tx, userID)
+	if err != nil {
+		return err
+	}
+	systemID, err := systemAccountID(tx, transaction.Type)
+	if err != nil {
+		return err
+	}
+	return postTransfer(tx, transaction, systemID, walletID)
+}
+
+// ReverseOrderPayment gives back the wallet part of an order whose payment failed or
+// that was cancelled, less what an order adjustment already gave back. It does nothing
+// for other orders or when already reversed.
+func (wr *walletRepository) ReverseOrderPayment(orderID int64) error {
+	tx, err := wr.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	query := `
+		SELECT e.account_id, -SUM(e.amount), o.user_id
+		FROM ledger_transactions t
+		INNER JOIN ledger_entries e ON e.transaction_id = t.id
+		INNER JOIN ledger_accounts a ON e.account_id = a.id AND a.user_id IS NOT NULL
+		INNER JOIN orders o ON t.order_id = o.id
+		WHERE t.order_id = $1 AND t.type IN ($2, $5) AND o.status IN ($3, $4)
+		GROUP BY e.account_id, o.user_id
+		HAVING SUM(e.amount) < 0
+	`
+	var walletID, userID int64
+	transaction := &domain.WalletTransaction{
+		Type:        domain.WalletTransactionOrderReversal,
+		Description: "Order not completed",
+		OrderID:     orderID,
+	}
+	err = tx.QueryRow(query, orderID, domain.WalletTransactionOrderPayment, domain.OrderStatusPaymentFailed,
+		domain.OrderStatusCancelled, domain.WalletTransactionOrderAdjustment).Scan(&walletID, &transaction.Amount, &userID)
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+	systemID, err := systemAccountID(tx, transaction.Type)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	err = postTransfer(tx, transaction, systemID, walletID)
+	if err != nil {
+		tx.Rollback()
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return nil
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+// debitWallet pays part of an order from the wallet inside the order transaction. The
+// wallet account is locked so concurrent checkouts cannot spend the same balance.
+func debitWallet(tx *sql.Tx, userID int64, orderID int64, amount float32) error {
+	walletID, err := walletAccountID(tx, userID)
+	if err != nil {
+		return err
+	}
+	var balance float32
+	err = tx.QueryRow("SELECT COALESCE(SUM(amount), 0) FROM ledger_entries WHERE account_id = $1", walletID).Scan(&balance)
+	if err != nil {
+		return err
+	}
+	if balance < amount {
+		return ErrInsufficientBalance
+	}
+
+	transaction := &domain.WalletTransaction{
+		Type:        domain.WalletTransactionOrderPayment,
+		Amount:      amount,
+		Description: "Order payment",
+		OrderID:     orderID,
+	}
+	systemID, err := systemAccountID(tx, transaction.Type)
+	if err != nil {
+		return err
+	}
+	return postTransfer(tx, transaction, walletID, systemID)
+}
+
+// adjustOrderWallet changes the wallet payment of an order inside the transaction that
+// changes its total: a positive amount is credited back, a negative one is paid.
+func adjustOrderWallet(tx *sql.Tx, userID int64, orderID int64, amount float32, description string) error {
+	walletID, err := walletAccountID(tx, userID)
+	if err != nil {
+		return err
+	}
+	transaction := &domain.WalletTransaction{
+		Type:        domain.WalletTransactionOrderAdjustment,
+		Amount:      amount,
+		Description: description,
+		OrderID:     orderID,
+	}
+	systemID, err := systemAccountID(tx, transaction.Type)
+	if err != nil {
+		return err
+	}
+	if amount > 0 {
+		return postTransfer(tx, transaction, systemID, walletID)
+	}
+
+	var balance float32
+	err = tx.QueryRow("SELECT COALESCE(SUM(amount), 0) FROM ledger_entries WHERE account_id = $1", walletID).Scan(&balance)
+	if err != nil {
+		return err
+	}
+	if balance < -amount {
+		return ErrInsufficientBalance
+	}
+	transaction.Amount = -amount
+	return postTransfer(tx, transaction, walletID, systemID)
+}
+
+// walletAccountID returns the ledger account of a user, creating it on first use, and
+// locks it until the end of the transaction.
+func walletAccountID(tx *sql.Tx, userID int64) (int64, error) {
+	_, err := tx.Exec("INSERT INTO ledger_accounts (user_id) VALUES ($1) ON CONFLICT (user_id) DO NOTHING", userID)
+	if err != nil {
+		return 0, err
+	}
+	var accountID int64
+	err = tx.QueryRow("SELECT id FROM ledger_accounts WHERE user_id = $1 FOR UPDATE", userID).Scan(&accountID)
+	return accountID, err
+}
+
+func systemAccountID(tx *sql.Tx, transactionType string) (int64, error) {
+	code, found := systemAccounts[transactionType]
+	if !found {
+		return 0, errors.New("unknown wallet transaction type")
+	}
+	_, err := tx.Exec("INSERT INTO ledger_accounts (code) VALUES ($1) ON CONFLICT (code) DO NOTHING", code)
+	if err != nil {
+		return 0, err
+	}
+	var accountID int64
+	err = tx.QueryRow("SELECT id FROM ledger_accounts WHERE code = $1", code).Scan(&accountID)
+	return accountID, err
+}
+
+// postTransfer records a balanced transaction moving the amount from one account to the other.
+func postTransfer(tx *sql.Tx, transaction *domain.WalletTransaction, fromAccountID int64, toAccountID int64) error {
+	query := `
+		INSERT INTO ledger_transactions (type, description, order_id, reference)
+		VALUES ($1, $2, NULLIF($3, 0), $4)
+		RETURNING id, created_at
+	`
+	err := tx.QueryRow(query, transaction.Type, transaction.Description, transaction.OrderID,
+		transaction.Reference).Scan(&transaction.ID, &transaction.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	entryQuery := "INSERT INTO ledger_entries (transaction_id, account_id, amount) VALUES ($1, $2, $3), ($1, $4, $5)"
+	_, err = tx.Exec(entryQuery, transaction.ID, fromAccountID, -transaction.Amount, toAccountID, transaction.Amount)
+	return err
+}
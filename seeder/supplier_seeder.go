@@ -0,0 +1,86 @@
+package seeder
+
+import (
+	"fmt"
+	"foodDelivery/domain"
+	"foodDelivery/repository"
+	"log"
+	"math"
+	"math/rand"
+
+	"github.com/bxcodec/faker/v3"
+)
+
+type SupplierSeeder struct {
+	supplierRepository repository.SupplierRepository
+}
+
+func NewSupplierSeeder(supplierRepository repository.SupplierRepository) *SupplierSeeder {
+	return &SupplierSeeder{
+		supplierRepository: supplierRepository,
+	}
+}
+
+// SupplierSite is where a seeded supplier is and how many minutes it takes to prepare an order.
+type SupplierSite struct {
+	Location domain.GeoPoint
+	PrepTime int
+}
+
+// SeedSuppliers creates suppliers spread up to radiusKm around the center,
+// so nearby search can be tried against known positions.
+func (s *SupplierSeeder) SeedSuppliers(center domain.GeoPoint, radiusKm float64, count int) {
+	sites := make([]SupplierSite, 0, count)
+	for i := 0; i < count; i++ {
+		distance := radiusKm * math.Sqrt(rand.Float64())
+		angle := rand.Float64() * 2 * math.Pi
+		sites = append(sites, SupplierSite{
+			Location: OffsetKm(center, distance*math.Cos(angle), distance*math.Sin(angle)),
+			PrepTime: 10 + rand.Intn(30),
+		})
+	}
+	s.SeedSuppliersAt(sites)
+}
+
+// SeedSuppliersAt creates a supplier at each site, in order, and returns the ones created.
+func (s *SupplierSeeder) SeedSuppliersAt(sites []SupplierSite) []*domain.Supplier {
+	suppliers := make([]*domain.Supplier, 0, len(sites))
+	for _, site := range sites {
+		location := site.Location
+		supplier := &domain.Supplier{
+			Name:            faker.LastName() + " Kitchen",
+			Address:         fmt.Sprintf("%.4f, %.4f", location.Lat, location.Lng),
+			Description:     faker.Sentence(),
+			LogoUrl:         faker.URL(),
+			OpeningHour:     "08:00",
+			ClosingHour:     "22:00",
+			UserID:          7,
+			DeliveryTime:    "30-45 min",
+			TimeZone:        "UTC",
+			PrepTime:        site.PrepTime,
+			DeliveryFeeType: domain.DeliveryFeeFlat,
+			DeliveryFee:     2,
+			Location:        &location,
+		}
+		err := s.supplierRepository.CreateSupplier(supplier)
+		if err != nil {
+			log.Printf("Failed to create supplier: %v", err)
+			continue
+		}
+		suppliers = append(suppliers, supplier)
+	}
+
+	log.Println("Supplier seeding completed")
+	return suppliers
+}
+
+// OffsetKm returns the point northKm to the north and eastKm to the east of the center,
+// negative values going south and west. One degree of latitude is about 111 km, a degree
+// of longitude shrinks towards the poles.
+func OffsetKm(center domain.GeoPoint, northKm float64, eastKm float64) domain.GeoPoint {
+	const kmPerDegree = 6371 * math.Pi / 180
+	return domain.GeoPoint{
+		Lat: center.Lat + northKm/kmPerDegree,
+		Lng: center.Lng + eastKm/(kmPerDegree*math.Cos(center.Lat*math.Pi/180)),
+	}
+}
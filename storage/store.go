@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Store saves uploaded files and returns the URL they are served from.
+type Store interface {
+	Save(name string, content io.Reader) (string, error)
+}
+
+type localStore struct {
+	dir     string
+	baseURL string
+}
+
+// NewLocalStore keeps files in dir, served by the application under baseURL.
+func NewLocalStore(dir string, baseURL string) (Store, error) {
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, err
+	}
+	return &localStore{
+		dir:     dir,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}, nil
+}
+
+func (ls *localStore) Save(name string, content io.Reader) (string, error) {
+	path := filepath.Join(ls.dir, filepath.FromSlash(name))
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return "", err
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	_, err = io.Copy(file, content)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return "", err
+	}
+	return ls.baseURL + "/" + name, nil
+}
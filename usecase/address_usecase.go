@@ -3,6 +3,7 @@ package usecase
 import (
 	"errors"
 	"foodDelivery/domain"
+	"foodDelivery/geocoding"
 	"foodDelivery/repository"
 )
 
@@ -20,11 +21,13 @@ type AddressUseCase interface {
 
 type addressUseCase struct {
 	addressRepo repository.AddressRepository
+	geocoder    geocoding.Geocoder
 }
 
-func NewAddressUseCase(addressRepo repository.AddressRepository) AddressUseCase {
+func NewAddressUseCase(addressRepo repository.AddressRepository, geocoder geocoding.Geocoder) AddressUseCase {
 	return &addressUseCase{
 		addressRepo: addressRepo,
+		geocoder:    geocoder,
 	}
 }
 
@@ -45,7 +48,12 @@ func (au *addressUseCase) CreateAddress(address *domain.Address) error {
 		return ErrAddressValidation
 	}
 
-	err := au.addressRepo.CreateAddress(address)
+	err := au.geocode(address)
+	if err != nil {
+		return err
+	}
+
+	err = au.addressRepo.CreateAddress(address)
 	if err != nil {
 		return err
 	}
@@ -70,7 +78,12 @@ func (au *addressUseCase) UpdateAddress(addressID int64, address *domain.Address
 		return ErrAddressValidation
 	}
 
-	err := au.addressRepo.UpdateAddress(addressID, address)
+	err := au.geocode(address)
+	if err != nil {
+		return err
+	}
+
+	err = au.addressRepo.UpdateAddress(addressID, address)
 	if err != nil {
 		return err
 	}
@@ -108,3 +121,23 @@ func (au *addressUseCase) GetUsersAddresses(userID int64) ([]*domain.Address, er
 
 	return addresses, nil
 }
+
+// geocode sets the location of an address. Addresses the geocoder does not know
+// are stored without coordinates and only match zones by postcode.
+func (au *addressUseCase) geocode(address *domain.Address) error {
+	location, err := au.geocoder.Geocode(geocoding.Query{
+		Street:  address.Street,
+		City:    address.City,
+		Zip:     address.Zip,
+		Country: address.Country,
+	})
+	if errors.Is(err, geocoding.ErrNoMatch) {
+		address.Location = nil
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	address.Location = location
+	return nil
+}
@@ -0,0 +1,286 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"foodDelivery/domain"
+	"foodDelivery/repository"
+	"math"
+	"time"
+)
+
+var (
+	ErrCartEmpty            = errors.New("cart is empty")
+	ErrCartSupplierMismatch = errors.New("cart can only contain foods of one supplier")
+	ErrCartUnavailableItems = errors.New("cart contains items that are not available anymore")
+	ErrInvalidQuantity      = errors.New("quantity must be greater than zero")
+	ErrQuantityTooLarge     = errors.New("quantity of a cart line can not be more than 127")
+)
+
+type CartUseCase interface {
+	GetCart(userID int64) (*domain.Cart, error)
+	AddItem(userID int64, item *domain.CartItem) (*domain.Cart, error)
+	UpdateItem(userID int64, itemID int64, quantity int8) (*domain.Cart, error)
+	RemoveItem(userID int64, itemID int64) (*domain.Cart, error)
+	ClearCart(userID int64) error
+	Checkout(userID int64, checkout *domain.Checkout) (*domain.Order, error)
+}
+
+type cartUseCase struct {
+	cartRepo     repository.CartRepository
+	foodRepo     repository.FoodRepository
+	orderRepo    repository.OrderRepository
+	modifierRepo repository.ModifierRepository
+	orderUseCase OrderUseCase
+}
+
+func NewCartUseCase(cartRepo repository.CartRepository, foodRepo repository.FoodRepository,
+	orderRepo repository.OrderRepository, modifierRepo repository.ModifierRepository, orderUseCase OrderUseCase) CartUseCase {
+	return &cartUseCase{
+		cartRepo:     cartRepo,
+		foodRepo:     foodRepo,
+		orderRepo:    orderRepo,
+		modifierRepo: modifierRepo,
+		orderUseCase: orderUseCase,
+	}
+}
+
+func (cu *cartUseCase) GetCart(userID int64) (*domain.Cart, error) {
+	cart, err := cu.cartRepo.GetOrCreateCart(userID)
+	if err != nil {
+		return nil, err
+	}
+	err = cu.revalidate(cart, nil)
+	if err != nil {
+		return nil, err
+	}
+	return cart, nil
+}
+
+func (cu *cartUseCase) AddItem(userID int64, item *domain.CartItem) (*domain.Cart, error) {
+	if item.Quantity <= 0 {
+		return nil, ErrInvalidQuantity
+	}
+	food, err := cu.foodRepo.GetFoodByID(item.FoodID)
+	if err != nil {
+		if errors.Is(err, repository.ErrFoodNotFound) {
+			return nil, ErrFoodNotFound
+		}
+		return nil, err
+	}
+
+	groups, err := cu.modifierRepo.GetGroupsByFoodID(item.FoodID)
+	if err != nil {
+		return nil, err
+	}
+	err = resolveModifiers(groups, item.Modifiers, item.Note)
+	if err != nil {
+		return nil, err
+	}
+
+	cart, err := cu.cartRepo.GetOrCreateCart(userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(cart.Items) > 0 && cart.SupplierID != food.SupplierID {
+		return nil, ErrCartSupplierMismatch
+	}
+	if cart.SupplierID != food.SupplierID {
+		err = cu.cartRepo.SetCartSupplier(cart.ID, food.SupplierID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Adding the same food with the same choices again increases its quantity.
+	var existing *domain.CartItem
+	for _, cartItem := range cart.Items {
+		if cartItem.FoodID == item.FoodID && cartItem.Note == item.Note && sameModifiers(cartItem.Modifiers, item.Modifiers) {
+			existing = cartItem
+			break
+		}
+	}
+	quantity := int(item.Quantity)
+	if existing != nil {
+		quantity += int(existing.Quantity)
+	}
+	if quantity > math.MaxInt8 {
+		return nil, ErrQuantityTooLarge
+	}
+	sold, err := cu.orderRepo.GetFoodDailySales(item.FoodID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	remaining := int(food.DailyQuantity) - sold
+	if quantity > remaining {
+		return nil, fmt.Errorf("%w: only %d left today", repository.ErrOutOfStock, maxInt(remaining, 0))
+	}
+	if existing != nil {
+		err = cu.cartRepo.UpdateItemQuantity(cart.ID, existing.ID, int8(quantity))
+		if err != nil {
+			return nil, err
+		}
+		return cu.GetCart(userID)
+	}
+
+	item.CartID = cart.ID
+	item.UnitPrice = float32(food.Price) + modifiersTotal(item.Modifiers)
+	err = cu.cartRepo.AddItem(item)
+	if err != nil {
+		return nil, err
+	}
+	return cu.GetCart(userID)
+}
+
+func (cu *cartUseCase) UpdateItem(userID int64, itemID int64, quantity int8) (*domain.Cart, error) {
+	if quantity <= 0 {
+		return cu.RemoveItem(userID, itemID)
+	}
+	cart, err := cu.cartRepo.GetOrCreateCart(userID)
+	if err != nil {
+		return nil, err
+	}
+	err = cu.cartRepo.UpdateItemQuantity(cart.ID, itemID, quantity)
+	if err != nil {
+		return nil, err
+	}
+	return cu.GetCart(userID)
+}
+
+func (cu *cartUseCase) RemoveItem(userID int64, itemID int64) (*domain.Cart, error) {
+	cart, err := cu.cartRepo.GetOrCreateCart(userID)
+	if err != nil {
+		return nil, err
+	}
+	err = cu.cartRepo.RemoveItem(cart.ID, itemID)
+	if err != nil {
+		return nil, err
+	}
+	if len(cart.Items) == 1 {
+		// The last item is gone, the cart is free to take another supplier.
+		err = cu.cartRepo.SetCartSupplier(cart.ID, 0)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return cu.GetCart(userID)
+}
+
+func (cu *cartUseCase) ClearCart(userID int64) error {
+	cart, err := cu.cartRepo.GetOrCreateCart(userID)
+	if err != nil {
+		return err
+	}
+	return cu.cartRepo.ClearCart(cart.ID)
+}
+
+// Checkout turns the cart into an order and empties the cart once the order is placed.
+// The stock is checked for the day the order is delivered on.
+func (cu *cartUseCase) Checkout(userID int64, checkout *domain.Checkout) (*domain.Order, error) {
+	cart, err := cu.cartRepo.GetOrCreateCart(userID)
+	if err != nil {
+		return nil, err
+	}
+	err = cu.revalidate(cart, checkout.RequestedDeliveryAt)
+	if err != nil {
+		return nil, err
+	}
+	if len(cart.Items) == 0 {
+		return nil, ErrCartEmpty
+	}
+
+	items := make([]domain.OrderItem, 0, len(cart.Items))
+	for _, item := range cart.Items {
+		if !item.Available {
+			return nil, ErrCartUnavailableItems
+		}
+		items = append(items, domain.OrderItem{
+			FoodID:    item.FoodID,
+			Quantity:  item.Quantity,
+			Note:      item.Note,
+			Modifiers: item.Modifiers,
+		})
+	}
+
+	order := &domain.Order{
+		UserID:                 userID,
+		SupplierID:             cart.SupplierID,
+		FulfilmentType:         checkout.FulfilmentType,
+		AddressID:              checkout.AddressID,
+		TableNumber:            checkout.TableNumber,
+		RequestedDeliveryAt:    checkout.RequestedDeliveryAt,
+		Tips:                   checkout.Tips,
+		PaymentMethod:          checkout.PaymentMethod,
+		WalletAmount:           checkout.WalletAmount,
+		LoyaltyPoints:          checkout.LoyaltyPoints,
+		CouponCode:             checkout.CouponCode,
+		SubstitutionPreference: checkout.SubstitutionPreference,
+		Items:                  &items,
+	}
+	err = cu.orderUseCase.SubmitOrder(order)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cu.cartRepo.ClearCart(cart.ID)
+	if err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// revalidate refreshes prices and stock of the cart items and computes the subtotal. The
+// stock is the one of the day of deliverAt, today when it is not set.
+func (cu *cartUseCase) revalidate(cart *domain.Cart, deliverAt *time.Time) error {
+	day, dayName := time.Now(), "today"
+	if deliverAt != nil {
+		day, dayName = *deliverAt, "for the requested day"
+	}
+	cart.Subtotal = 0
+
+	for _, item := range cart.Items {
+		item.Available = true
+		item.Warning = ""
+
+		food, err := cu.foodRepo.GetFoodByID(item.FoodID)
+		if err != nil {
+			return err
+		}
+
+		groups, err := cu.modifierRepo.GetGroupsByFoodID(item.FoodID)
+		if err != nil {
+			return err
+		}
+		err = resolveModifiers(groups, item.Modifiers, item.Note)
+		if err != nil {
+			item.Available = false
+			item.Warning = "the options of this food have changed, please choose again"
+			continue
+		}
+
+		item.CurrentPrice = float32(food.Price) + modifiersTotal(item.Modifiers)
+		if item.CurrentPrice != item.UnitPrice {
+			item.Warning = fmt.Sprintf("price changed from %.2f to %.2f", item.UnitPrice, item.CurrentPrice)
+		}
+
+		sold, err := cu.orderRepo.GetFoodDailySales(item.FoodID, day)
+		if err != nil {
+			return err
+		}
+		remaining := int(food.DailyQuantity) - sold
+		if int(item.Quantity) > remaining {
+			item.Available = false
+			item.Warning = fmt.Sprintf("only %d left %s", maxInt(remaining, 0), dayName)
+		}
+
+		cart.Subtotal += item.CurrentPrice * float32(item.Quantity)
+	}
+	return nil
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,201 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"foodDelivery/domain"
+	"foodDelivery/repository"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidCoupon       = errors.New("coupon needs a code, a percentage up to 100 or a positive fixed amount and a valid period")
+	ErrUnknownCoupon       = errors.New("coupon code is not valid")
+	ErrCouponExpired       = errors.New("coupon is not valid at this time")
+	ErrCouponNotApplicable = errors.New("coupon does not apply to this order")
+)
+
+type CouponUseCase interface {
+	GetAllCoupons() ([]*domain.Coupon, error)
+	GetCouponByID(couponID int64) (*domain.Coupon, error)
+	CreateCoupon(coupon *domain.Coupon) error
+	UpdateCoupon(coupon *domain.Coupon) error
+	DeleteCoupon(couponID int64) error
+	ValidateCoupon(order *domain.Order) (*domain.CouponValidation, error)
+}
+
+type couponUseCase struct {
+	couponRepo   repository.CouponRepository
+	orderUseCase OrderUseCase
+}
+
+func NewCouponUseCase(couponRepo repository.CouponRepository, orderUseCase OrderUseCase) CouponUseCase {
+	return &couponUseCase{
+		couponRepo:   couponRepo,
+		orderUseCase: orderUseCase,
+	}
+}
+
+func (cu *couponUseCase) GetAllCoupons() ([]*domain.Coupon, error) {
+	return cu.couponRepo.GetAllCoupons()
+}
+
+func (cu *couponUseCase) GetCouponByID(couponID int64) (*domain.Coupon, error) {
+	return cu.couponRepo.GetCouponByID(couponID)
+}
+
+func (cu *couponUseCase) CreateCoupon(coupon *domain.Coupon) error {
+	err := validateCoupon(coupon)
+	if err != nil {
+		return err
+	}
+	return cu.couponRepo.CreateCoupon(coupon)
+}
+
+func (cu *couponUseCase) UpdateCoupon(coupon *domain.Coupon) error {
+	err := validateCoupon(coupon)
+	if err != nil {
+		return err
+	}
+	return cu.couponRepo.UpdateCoupon(coupon)
+}
+
+func (cu *couponUseCase) DeleteCoupon(couponID int64) error {
+	return cu.couponRepo.DeleteCoupon(couponID)
+}
+
+// ValidateCoupon prices the order with its coupon code for the checkout screen.
+// The code is only reserved when the order is placed.
+func (cu *couponUseCase) ValidateCoupon(order *domain.Order) (*domain.CouponValidation, error) {
+	if strings.TrimSpace(order.CouponCode) == "" {
+		return nil, ErrUnknownCoupon
+	}
+	breakdown, err := cu.orderUseCase.QuoteOrder(order)
+	if err != nil {
+		return nil, err
+	}
+
+	validation := &domain.CouponValidation{Code: order.Coupon.Code, Breakdown: breakdown}
+	for _, discount := range breakdown.Discounts {
+		if discount.Code == order.Coupon.Code {
+			validation.Discount = discount.Amount
+		}
+	}
+	return validation, nil
+}
+
+// resolveCoupon looks up the coupon of an order and checks the rules that do not depend
+// on its price. The limits are checked again when the coupon is redeemed.
+func resolveCoupon(couponRepo repository.CouponRepository, order *domain.Order, now time.Time) (*domain.Coupon, error) {
+	coupon, err := couponRepo.GetCouponByCode(normalizeCouponCode(order.CouponCode))
+	if err != nil {
+		if errors.Is(err, repository.ErrCouponNotFound) {
+			return nil, ErrUnknownCoupon
+		}
+		return nil, err
+	}
+	// The coupon of another user is not shown to exist.
+	if coupon.UserID != 0 && coupon.UserID != order.UserID {
+		return nil, ErrUnknownCoupon
+	}
+	if !coupon.Active || (coupon.StartsAt != nil && now.Before(*coupon.StartsAt)) ||
+		(coupon.EndsAt != nil && !now.Before(*coupon.EndsAt)) {
+		return nil, ErrCouponExpired
+	}
+	if len(coupon.SupplierIDs) > 0 && !containsID(coupon.SupplierIDs, order.SupplierID) {
+		return nil, fmt.Errorf("%w: not valid for this restaurant", ErrCouponNotApplicable)
+	}
+	if coupon.UsageLimit > 0 && coupon.UsedCount >= coupon.UsageLimit {
+		return nil, repository.ErrCouponUsedUp
+	}
+
+	if order.UserID != 0 && coupon.PerUserLimit > 0 {
+		used, err := couponRepo.CountUserRedemptions(coupon.ID, order.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if used >= coupon.PerUserLimit {
+			return nil, repository.ErrCouponUsedUp
+		}
+	}
+	if order.UserID != 0 && coupon.FirstOrderOnly {
+		count, err := couponRepo.CountUserOrders(order.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			return nil, repository.ErrNotFirstOrder
+		}
+	}
+	return coupon, nil
+}
+
+// checkCouponDiscount enforces the coupon rules that depend on the priced order.
+func checkCouponDiscount(coupon *domain.Coupon, breakdown *domain.PriceBreakdown) error {
+	if breakdown.Subtotal < coupon.MinSubtotal {
+		return fmt.Errorf("%w: minimum subtotal is %.2f", ErrCouponNotApplicable, coupon.MinSubtotal)
+	}
+	for _, discount := range breakdown.Discounts {
+		if discount.Code == coupon.Code && discount.Amount > 0 {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: no item of the order qualifies", ErrCouponNotApplicable)
+}
+
+// couponDiscount is the discount line of a coupon on the subtotal of the lines it covers.
+func couponDiscount(coupon *domain.Coupon, base float32) *domain.Discount {
+	amount := coupon.Value
+	if coupon.Type == domain.CouponTypePercentage {
+		amount = base * coupon.Value / 100
+		if coupon.MaxDiscount > 0 && amount > coupon.MaxDiscount {
+			amount = coupon.MaxDiscount
+		}
+	}
+	if amount > base {
+		amount = base
+	}
+	return &domain.Discount{Code: coupon.Code, Label: coupon.Description, Amount: roundMoney(amount)}
+}
+
+func couponCoversCategory(coupon *domain.Coupon, categoryID int64) bool {
+	return len(coupon.CategoryIDs) == 0 || containsID(coupon.CategoryIDs, categoryID)
+}
+
+func validateCoupon(coupon *domain.Coupon) error {
+	coupon.Code = normalizeCouponCode(coupon.Code)
+	if coupon.Code == "" || coupon.Value <= 0 || coupon.MaxDiscount < 0 || coupon.MinSubtotal < 0 ||
+		coupon.UsageLimit < 0 || coupon.PerUserLimit < 0 {
+		return ErrInvalidCoupon
+	}
+	if coupon.Type == domain.CouponTypePercentage && coupon.Value > 100 {
+		return ErrInvalidCoupon
+	}
+	if coupon.Type != domain.CouponTypePercentage && coupon.Type != domain.CouponTypeFixed {
+		return ErrInvalidCoupon
+	}
+	if coupon.StartsAt != nil && coupon.EndsAt != nil && !coupon.StartsAt.Before(*coupon.EndsAt) {
+		return ErrInvalidCoupon
+	}
+	if coupon.SupplierIDs == nil {
+		coupon.SupplierIDs = make([]int64, 0)
+	}
+	if coupon.CategoryIDs == nil {
+		coupon.CategoryIDs = make([]int64, 0)
+	}
+	return nil
+}
+
+func normalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func containsID(ids []int64, id int64) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
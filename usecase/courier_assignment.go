@@ -0,0 +1,38 @@
+package usecase
+
+import (
+	"foodDelivery/domain"
+	"foodDelivery/geocoding"
+)
+
+// AssignmentStrategy picks the courier for a ready order among the available ones.
+type AssignmentStrategy interface {
+	SelectCourier(supplier *domain.Supplier, couriers []*domain.Courier) *domain.Courier
+}
+
+type nearestCourierStrategy struct{}
+
+// NewNearestCourierStrategy picks the courier closest to the supplier. Couriers without
+// a known position, or any courier when the supplier has no position, come after in list order.
+func NewNearestCourierStrategy() AssignmentStrategy {
+	return &nearestCourierStrategy{}
+}
+
+func (ns *nearestCourierStrategy) SelectCourier(supplier *domain.Supplier, couriers []*domain.Courier) *domain.Courier {
+	var selected *domain.Courier
+	selectedDistance := -1.0
+	for _, courier := range couriers {
+		if supplier.Location == nil || courier.Location == nil {
+			if selected == nil {
+				selected = courier
+			}
+			continue
+		}
+		distance := geocoding.DistanceKm(*supplier.Location, *courier.Location)
+		if selectedDistance < 0 || distance < selectedDistance {
+			selected = courier
+			selectedDistance = distance
+		}
+	}
+	return selected
+}
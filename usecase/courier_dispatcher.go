@@ -0,0 +1,42 @@
+package usecase
+
+import (
+	"log"
+	"time"
+)
+
+// CourierDispatcher periodically assigns the ready orders still waiting for a courier,
+// for example because nobody was on shift when they became ready.
+type CourierDispatcher struct {
+	deliveryUseCase DeliveryUseCase
+	interval        time.Duration
+	stop            func()
+}
+
+func NewCourierDispatcher(deliveryUseCase DeliveryUseCase, interval time.Duration) *CourierDispatcher {
+	return &CourierDispatcher{
+		deliveryUseCase: deliveryUseCase,
+		interval:        interval,
+	}
+}
+
+// Start runs the dispatcher in the background until Stop is called.
+func (cd *CourierDispatcher) Start() {
+	cd.stop = runEvery(cd.interval, cd.assignWaitingOrders)
+}
+
+func (cd *CourierDispatcher) Stop() {
+	if cd.stop != nil {
+		cd.stop()
+	}
+}
+
+func (cd *CourierDispatcher) assignWaitingOrders() {
+	assigned, err := cd.deliveryUseCase.AssignReadyOrders()
+	if err != nil {
+		log.Printf("Failed to assign ready orders: %v", err)
+	}
+	if assigned > 0 {
+		log.Printf("Assigned %d ready orders to couriers", assigned)
+	}
+}
@@ -0,0 +1,114 @@
+package usecase
+
+import (
+	"errors"
+	"foodDelivery/domain"
+	"foodDelivery/repository"
+	"time"
+)
+
+var (
+	ErrInvalidCourier = errors.New("courier needs a user, a name and a phone")
+	ErrInvalidShift   = errors.New("shift must end after it starts and last at most 24 hours")
+)
+
+// maxShiftLength is the longest shift a courier can register.
+const maxShiftLength = 24 * time.Hour
+
+type CourierUseCase interface {
+	GetAllCouriers() ([]*domain.Courier, error)
+	GetCourierByID(courierID int64) (*domain.Courier, error)
+	CreateCourier(courier *domain.Courier) error
+	UpdateCourier(courier *domain.Courier) error
+	GetCourierShifts(courierID int64) ([]*domain.CourierShift, error)
+	CreateCourierShift(shift *domain.CourierShift) error
+	DeleteCourierShift(courierID int64, shiftID int64) error
+}
+
+type courierUseCase struct {
+	courierRepo repository.CourierRepository
+}
+
+func NewCourierUseCase(courierRepo repository.CourierRepository) CourierUseCase {
+	return &courierUseCase{
+		courierRepo: courierRepo,
+	}
+}
+
+// GetAllCouriers lists the couriers, flagging the ones that can take a delivery right now.
+func (cu *courierUseCase) GetAllCouriers() ([]*domain.Courier, error) {
+	couriers, err := cu.courierRepo.GetAllCouriers()
+	if err != nil {
+		return nil, err
+	}
+	available, err := cu.courierRepo.GetAvailableCouriers(time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	availableIDs := make(map[int64]bool, len(available))
+	for _, courier := range available {
+		availableIDs[courier.ID] = true
+	}
+	for _, courier := range couriers {
+		courier.Available = availableIDs[courier.ID]
+	}
+	return couriers, nil
+}
+
+func (cu *courierUseCase) GetCourierByID(courierID int64) (*domain.Courier, error) {
+	courier, err := cu.courierRepo.GetCourierByID(courierID)
+	if err != nil {
+		return nil, err
+	}
+	courier.Shifts, err = cu.courierRepo.GetShifts(courierID)
+	if err != nil {
+		return nil, err
+	}
+	return courier, nil
+}
+
+func (cu *courierUseCase) CreateCourier(courier *domain.Courier) error {
+	err := validateCourier(courier)
+	if err != nil {
+		return err
+	}
+	return cu.courierRepo.CreateCourier(courier)
+}
+
+func (cu *courierUseCase) UpdateCourier(courier *domain.Courier) error {
+	err := validateCourier(courier)
+	if err != nil {
+		return err
+	}
+	return cu.courierRepo.UpdateCourier(courier)
+}
+
+func (cu *courierUseCase) GetCourierShifts(courierID int64) ([]*domain.CourierShift, error) {
+	return cu.courierRepo.GetShifts(courierID)
+}
+
+func (cu *courierUseCase) CreateCourierShift(shift *domain.CourierShift) error {
+	if !shift.EndsAt.After(shift.StartsAt) || shift.EndsAt.Sub(shift.StartsAt) > maxShiftLength {
+		return ErrInvalidShift
+	}
+	_, err := cu.courierRepo.GetCourierByID(shift.CourierID)
+	if err != nil {
+		return err
+	}
+	return cu.courierRepo.CreateShift(shift)
+}
+
+func (cu *courierUseCase) DeleteCourierShift(courierID int64, shiftID int64) error {
+	return cu.courierRepo.DeleteShift(courierID, shiftID)
+}
+
+func validateCourier(courier *domain.Courier) error {
+	if courier.UserID <= 0 || courier.Name == "" || courier.Phone == "" {
+		return ErrInvalidCourier
+	}
+	if courier.Location != nil && !validLocation(*courier.Location) {
+		return ErrInvalidLocation
+	}
+	return nil
+}
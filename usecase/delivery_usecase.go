@@ -0,0 +1,462 @@
+package usecase
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"foodDelivery/domain"
+	"foodDelivery/geocoding"
+	"foodDelivery/notification"
+	"foodDelivery/repository"
+	"foodDelivery/storage"
+	"html"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNoCourierAvailable     = errors.New("no courier is available")
+	ErrCourierInactive        = errors.New("courier is not active")
+	ErrInvalidProofMethod     = errors.New("proof method must be pin, photo or geofence")
+	ErrInvalidDeliveryPin     = errors.New("delivery pin is not correct")
+	ErrDeliveryPinLocked      = errors.New("too many wrong pins, use a photo or the geofence instead")
+	ErrDeliveryPinUnavailable = errors.New("order has no delivery pin, use a photo or the geofence instead")
+	ErrInvalidProofPhoto      = errors.New("proof photo must be a jpeg or png image of at most 5 MB")
+	ErrGeofenceUnavailable    = errors.New("delivery address has no coordinates for a geofence check")
+	ErrOutsideGeofence        = errors.New("courier is too far from the delivery address")
+	ErrInvalidPickupCode      = errors.New("pickup code is not correct")
+)
+
+// activeDeliveryStatuses are the statuses of the orders a courier still has to finish.
+var activeDeliveryStatuses = []string{domain.OrderStatusAssigned, domain.OrderStatusAccepted, domain.OrderStatusPickedUp}
+
+const (
+	maxPinAttempts = 5
+	maxProofPhoto  = 5 << 20
+	// geofenceRadiusM is how close to the address a geofence delivery has to be confirmed.
+	geofenceRadiusM = 150
+)
+
+// DeliveryUseCase drives an order from the supplier marking it ready to its delivery, or to
+// its handover at the supplier for pickup and dine-in orders.
+type DeliveryUseCase interface {
+	MarkOrderReady(supplierID int64, orderID int64) (*domain.Order, error)
+	HandOverOrder(supplierID int64, orderID int64, handover *domain.OrderHandover) (*domain.Order, error)
+	AssignOrder(orderID int64, courierID int64) (*domain.Order, error)
+	AssignReadyOrders() (int, error)
+	GetCourierOrders(userID int64, status string) ([]*domain.Order, error)
+	AcceptOrder(userID int64, orderID int64) (*domain.Order, error)
+	DeclineOrder(userID int64, orderID int64) (*domain.Order, error)
+	PickUpOrder(userID int64, orderID int64) (*domain.Order, error)
+	DeliverOrder(userID int64, orderID int64, confirmation *domain.DeliveryConfirmation) (*domain.Order, error)
+}
+
+type deliveryUseCase struct {
+	orderRepo    repository.OrderRepository
+	courierRepo  repository.CourierRepository
+	supplierRepo repository.SupplierRepository
+	addressRepo  repository.AddressRepository
+	photoStore   storage.Store
+	strategy     AssignmentStrategy
+	payments     PaymentUseCase
+	loyalty      LoyaltyUseCase
+	referrals    ReferralUseCase
+	invoices     InvoiceUseCase
+	userRepo     repository.UserRepository
+	notifier     notification.Notifier
+	tracker      *LocationTracker
+}
+
+func NewDeliveryUseCase(orderRepo repository.OrderRepository, courierRepo repository.CourierRepository,
+	supplierRepo repository.SupplierRepository, addressRepo repository.AddressRepository, photoStore storage.Store,
+	strategy AssignmentStrategy, payments PaymentUseCase, loyalty LoyaltyUseCase, referrals ReferralUseCase,
+	invoices InvoiceUseCase, userRepo repository.UserRepository, notifier notification.Notifier, tracker *LocationTracker) DeliveryUseCase {
+	return &deliveryUseCase{
+		orderRepo:    orderRepo,
+		courierRepo:  courierRepo,
+		supplierRepo: supplierRepo,
+		addressRepo:  addressRepo,
+		photoStore:   photoStore,
+		strategy:     strategy,
+		payments:     payments,
+		loyalty:      loyalty,
+		referrals:    referrals,
+		invoices:     invoices,
+		userRepo:     userRepo,
+		notifier:     notifier,
+		tracker:      tracker,
+	}
+}
+
+// MarkOrderReady is called by the supplier once the food is prepared. The order is then
+// offered to the nearest available courier; without one it waits for the dispatcher.
+// Pickup and dine-in orders need no courier, the customer is told they are ready instead.
+func (du *deliveryUseCase) MarkOrderReady(supplierID int64, orderID int64) (*domain.Order, error) {
+	order, err := du.orderRepo.GetOrderWithItems(orderID)
+	if err != nil {
+		return nil, err
+	}
+	if order.SupplierID != supplierID {
+		return nil, repository.ErrOrderNotFound
+	}
+
+	err = du.orderRepo.UpdateOrderStatus(orderID, 0, []string{domain.OrderStatusPending}, domain.OrderStatusReady)
+	if err != nil {
+		return nil, err
+	}
+	if order.FulfilmentType != domain.FulfilmentTypeDelivery {
+		du.notifyOrderReady(order)
+		return du.getOrder(orderID)
+	}
+
+	err = du.autoAssign(orderID)
+	if err != nil && !errors.Is(err, ErrNoCourierAvailable) {
+		return nil, err
+	}
+	return du.getOrder(orderID)
+}
+
+// HandOverOrder completes a ready pickup or dine-in order when the supplier gives it to the
+// customer. Pickup orders are only handed over against their pickup code.
+func (du *deliveryUseCase) HandOverOrder(supplierID int64, orderID int64, handover *domain.OrderHandover) (*domain.Order, error) {
+	order, err := du.orderRepo.GetOrderWithItems(orderID)
+	if err != nil {
+		return nil, err
+	}
+	if order.SupplierID != supplierID {
+		return nil, repository.ErrOrderNotFound
+	}
+	if order.FulfilmentType == domain.FulfilmentTypeDelivery || order.Status != domain.OrderStatusReady {
+		return nil, repository.ErrInvalidStatusTransition
+	}
+	if order.FulfilmentType == domain.FulfilmentTypePickup &&
+		subtle.ConstantTimeCompare([]byte(strings.TrimSpace(handover.PickupCode)), []byte(order.PickupCode)) != 1 {
+		return nil, ErrInvalidPickupCode
+	}
+
+	err = du.orderRepo.UpdateOrderStatus(orderID, 0, []string{domain.OrderStatusReady}, domain.OrderStatusDelivered)
+	if err != nil {
+		return nil, err
+	}
+	du.completeOrder(orderID)
+	return du.getOrder(orderID)
+}
+
+// AssignOrder gives a ready order to a courier chosen by an admin, or to the nearest
+// available courier when courierID is 0.
+func (du *deliveryUseCase) AssignOrder(orderID int64, courierID int64) (*domain.Order, error) {
+	if courierID == 0 {
+		err := du.autoAssign(orderID)
+		if err != nil {
+			return nil, err
+		}
+		return du.getOrder(orderID)
+	}
+
+	courier, err := du.courierRepo.GetCourierByID(courierID)
+	if err != nil {
+		return nil, err
+	}
+	if !courier.Active {
+		return nil, ErrCourierInactive
+	}
+	err = du.orderRepo.AssignCourier(orderID, courierID)
+	if err != nil {
+		return nil, err
+	}
+	return du.getOrder(orderID)
+}
+
+// AssignReadyOrders offers every waiting ready order to the available couriers and
+// returns how many were assigned.
+func (du *deliveryUseCase) AssignReadyOrders() (int, error) {
+	orderIDs, err := du.orderRepo.GetUnassignedReadyOrders()
+	if err != nil {
+		return 0, err
+	}
+
+	assigned := 0
+	for _, orderID := range orderIDs {
+		err = du.autoAssign(orderID)
+		if errors.Is(err, ErrNoCourierAvailable) {
+			break
+		}
+		// Someone else assigned the order in the meantime.
+		if errors.Is(err, repository.ErrInvalidStatusTransition) {
+			continue
+		}
+		if err != nil {
+			return assigned, err
+		}
+		assigned++
+	}
+	return assigned, nil
+}
+
+func (du *deliveryUseCase) autoAssign(orderID int64) error {
+	order, err := du.orderRepo.GetOrderWithItems(orderID)
+	if err != nil {
+		return err
+	}
+	if order.Status != domain.OrderStatusReady || order.CourierID != 0 {
+		return repository.ErrInvalidStatusTransition
+	}
+	supplier, err := du.supplierRepo.GetSupplierByID(order.SupplierID)
+	if err != nil {
+		return err
+	}
+	couriers, err := du.courierRepo.GetAvailableCouriers(time.Now())
+	if err != nil {
+		return err
+	}
+
+	courier := du.strategy.SelectCourier(supplier, couriers)
+	if courier == nil {
+		return ErrNoCourierAvailable
+	}
+	return du.orderRepo.AssignCourier(orderID, courier.ID)
+}
+
+// GetCourierOrders lists the orders of the signed in courier. The "active" status
+// selects every order the courier still has to finish.
+func (du *deliveryUseCase) GetCourierOrders(userID int64, status string) ([]*domain.Order, error) {
+	courier, err := du.courierRepo.GetCourierByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []string
+	switch status {
+	case "":
+	case "active":
+		statuses = activeDeliveryStatuses
+	default:
+		statuses = []string{status}
+	}
+	orders, err := du.orderRepo.GetCourierOrders(courier.ID, statuses)
+	if err != nil {
+		return nil, err
+	}
+	hideDeliveryPins(orders)
+	return orders, nil
+}
+
+func (du *deliveryUseCase) AcceptOrder(userID int64, orderID int64) (*domain.Order, error) {
+	return du.courierTransition(userID, orderID, domain.OrderStatusAssigned, domain.OrderStatusAccepted)
+}
+
+// DeclineOrder hands an order the courier has not accepted yet back to the dispatcher.
+func (du *deliveryUseCase) DeclineOrder(userID int64, orderID int64) (*domain.Order, error) {
+	courier, err := du.courierRepo.GetCourierByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	err = du.orderRepo.UnassignCourier(orderID, courier.ID)
+	if err != nil {
+		return nil, err
+	}
+	du.tracker.Forget(orderID)
+	return du.getOrder(orderID)
+}
+
+func (du *deliveryUseCase) PickUpOrder(userID int64, orderID int64) (*domain.Order, error) {
+	return du.courierTransition(userID, orderID, domain.OrderStatusAccepted, domain.OrderStatusPickedUp)
+}
+
+// DeliverOrder completes a picked up order once the proof of delivery checks out.
+func (du *deliveryUseCase) DeliverOrder(userID int64, orderID int64, confirmation *domain.DeliveryConfirmation) (*domain.Order, error) {
+	courier, err := du.courierRepo.GetCourierByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	order, err := du.orderRepo.GetOrderWithItems(orderID)
+	if err != nil {
+		return nil, err
+	}
+	if order.CourierID != courier.ID || order.Status != domain.OrderStatusPickedUp {
+		return nil, repository.ErrInvalidStatusTransition
+	}
+
+	proof, err := du.verifyProof(order, confirmation)
+	if err != nil {
+		return nil, err
+	}
+	err = du.orderRepo.CompleteDelivery(orderID, courier.ID, proof)
+	if err != nil {
+		return nil, err
+	}
+	du.tracker.Forget(orderID)
+	du.completeOrder(orderID)
+	return du.getOrder(orderID)
+}
+
+// completeOrder captures the payment of a delivered order, awards its loyalty points and
+// referral reward and issues its invoice. The food is handed over either way, so failures
+// are only logged and left for support to settle.
+func (du *deliveryUseCase) completeOrder(orderID int64) {
+	err := du.payments.CaptureOrder(orderID)
+	if err != nil {
+		log.Printf("Failed to capture payment of order %d: %v", orderID, err)
+	}
+	err = du.loyalty.AwardOrderPoints(orderID)
+	if err != nil {
+		log.Printf("Failed to award loyalty points of order %d: %v", orderID, err)
+	}
+	err = du.referrals.RewardOrder(orderID)
+	if err != nil {
+		log.Printf("Failed to reward the referral of order %d: %v", orderID, err)
+	}
+	_, err = du.invoices.IssueInvoice(orderID)
+	if err != nil {
+		log.Printf("Failed to issue the invoice of order %d: %v", orderID, err)
+	}
+}
+
+// notifyOrderReady e-mails the customer that a pickup or dine-in order is ready. A failure
+// is only logged, the order is ready either way.
+func (du *deliveryUseCase) notifyOrderReady(order *domain.Order) {
+	user, err := du.userRepo.GetUserByID(order.UserID)
+	if err != nil {
+		log.Printf("Failed to notify the customer of order %d: %v", order.ID, err)
+		return
+	}
+
+	subject := fmt.Sprintf("Your order #%d is ready", order.ID)
+	body := fmt.Sprintf("<p>Your order from %s is ready and on its way to table %s.</p>\n",
+		html.EscapeString(order.SupplierName), html.EscapeString(order.TableNumber))
+	if order.FulfilmentType == domain.FulfilmentTypePickup {
+		subject = fmt.Sprintf("Your order #%d is ready for pickup", order.ID)
+		body = fmt.Sprintf("<p>Your order is ready at %s.</p>\n<p>Show the pickup code <strong>%s</strong> at the counter to collect it.</p>\n",
+			html.EscapeString(order.SupplierName), order.PickupCode)
+	}
+
+	err = du.notifier.Send(&notification.Message{
+		To:      user.Email,
+		Subject: subject,
+		HTML:    body,
+	})
+	if err != nil {
+		log.Printf("Failed to notify the customer of order %d: %v", order.ID, err)
+	}
+}
+
+func (du *deliveryUseCase) verifyProof(order *domain.Order, confirmation *domain.DeliveryConfirmation) (*domain.DeliveryProof, error) {
+	proof := &domain.DeliveryProof{Method: confirmation.Method, Location: confirmation.Location}
+	if proof.Location != nil && !validLocation(*proof.Location) {
+		return nil, ErrInvalidLocation
+	}
+
+	switch confirmation.Method {
+	case domain.ProofMethodPin:
+		// Orders placed before delivery pins existed have none, they need another proof.
+		if order.DeliveryPin == "" {
+			return nil, ErrDeliveryPinUnavailable
+		}
+		if order.DeliveryPinAttempts >= maxPinAttempts {
+			return nil, ErrDeliveryPinLocked
+		}
+		if !validDeliveryPin(confirmation.Pin) ||
+			subtle.ConstantTimeCompare([]byte(confirmation.Pin), []byte(order.DeliveryPin)) != 1 {
+			err := du.orderRepo.RecordPinAttempt(order.ID)
+			if err != nil {
+				return nil, err
+			}
+			return nil, ErrInvalidDeliveryPin
+		}
+
+	case domain.ProofMethodPhoto:
+		if confirmation.Photo == nil {
+			return nil, ErrInvalidProofPhoto
+		}
+		photo, err := io.ReadAll(io.LimitReader(confirmation.Photo, maxProofPhoto+1))
+		if err != nil {
+			return nil, err
+		}
+		extension := ""
+		switch http.DetectContentType(photo) {
+		case "image/jpeg":
+			extension = ".jpg"
+		case "image/png":
+			extension = ".png"
+		}
+		if len(photo) > maxProofPhoto || extension == "" {
+			return nil, ErrInvalidProofPhoto
+		}
+		name := fmt.Sprintf("proofs/%d-%d%s", order.ID, time.Now().Unix(), extension)
+		proof.PhotoURL, err = du.photoStore.Save(name, bytes.NewReader(photo))
+		if err != nil {
+			return nil, err
+		}
+
+	case domain.ProofMethodGeofence:
+		if proof.Location == nil {
+			return nil, ErrInvalidLocation
+		}
+		address, err := du.addressRepo.GetAddressByID(order.AddressID)
+		if err != nil {
+			return nil, err
+		}
+		if address.Location == nil {
+			return nil, ErrGeofenceUnavailable
+		}
+		proof.DistanceM = math.Round(geocoding.DistanceKm(*proof.Location, *address.Location) * 1000)
+		if proof.DistanceM > geofenceRadiusM {
+			return nil, fmt.Errorf("%w: %.0f m away, at most %d m allowed", ErrOutsideGeofence, proof.DistanceM, geofenceRadiusM)
+		}
+
+	default:
+		return nil, ErrInvalidProofMethod
+	}
+	return proof, nil
+}
+
+// validDeliveryPin tells whether a pin has the 4 digits of the pins given to customers.
+func validDeliveryPin(pin string) bool {
+	if len(pin) != 4 {
+		return false
+	}
+	for _, r := range pin {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// courierTransition moves an order of the signed in courier from one status to the next.
+func (du *deliveryUseCase) courierTransition(userID int64, orderID int64, from string, to string) (*domain.Order, error) {
+	courier, err := du.courierRepo.GetCourierByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	err = du.orderRepo.UpdateOrderStatus(orderID, courier.ID, []string{from}, to)
+	if err != nil {
+		return nil, err
+	}
+	return du.getOrder(orderID)
+}
+
+// getOrder loads an order for suppliers and couriers, who must not see the delivery pin
+// or the pickup code.
+func (du *deliveryUseCase) getOrder(orderID int64) (*domain.Order, error) {
+	order, err := du.orderRepo.GetOrderWithItems(orderID)
+	if err != nil {
+		return nil, err
+	}
+	hideDeliveryPins([]*domain.Order{order})
+	return order, nil
+}
+
+// hideDeliveryPins removes the pin the customer has to give the courier at the door and
+// the code shown at the counter for pickup orders.
+func hideDeliveryPins(orders []*domain.Order) {
+	for _, order := range orders {
+		order.DeliveryPin = ""
+		order.PickupCode = ""
+	}
+}
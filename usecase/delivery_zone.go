@@ -0,0 +1,87 @@
+package usecase
+
+import (
+	"errors"
+	"foodDelivery/domain"
+	"foodDelivery/repository"
+	"strings"
+)
+
+var (
+	ErrInvalidDeliveryZone = errors.New("delivery zone needs postcodes or a polygon of at least 3 points and can not have negative fee or time")
+	ErrAddressOutsideZone  = errors.New("supplier does not deliver to this address")
+)
+
+func normalizePostcode(postcode string) string {
+	return strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(postcode), " ", ""))
+}
+
+func validateDeliveryZones(zones []*domain.DeliveryZone) error {
+	for _, zone := range zones {
+		if len(zone.Postcodes) == 0 && len(zone.Polygon) < 3 {
+			return ErrInvalidDeliveryZone
+		}
+		if len(zone.Polygon) > 0 && len(zone.Polygon) < 3 {
+			return ErrInvalidDeliveryZone
+		}
+		if zone.DeliveryFee < 0 || zone.DeliveryTime < 0 {
+			return ErrInvalidDeliveryZone
+		}
+		for i, postcode := range zone.Postcodes {
+			zone.Postcodes[i] = normalizePostcode(postcode)
+			if zone.Postcodes[i] == "" {
+				return ErrInvalidDeliveryZone
+			}
+		}
+	}
+	return nil
+}
+
+// pointInPolygon uses ray casting, which is accurate enough at city scale.
+func pointInPolygon(point domain.GeoPoint, polygon []domain.GeoPoint) bool {
+	inside := false
+	for i, j := 0, len(polygon)-1; i < len(polygon); j, i = i, i+1 {
+		a, b := polygon[i], polygon[j]
+		if (a.Lat > point.Lat) != (b.Lat > point.Lat) &&
+			point.Lng < (b.Lng-a.Lng)*(point.Lat-a.Lat)/(b.Lat-a.Lat)+a.Lng {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+func zoneCovers(zone *domain.DeliveryZone, address *domain.Address) bool {
+	zip := normalizePostcode(address.Zip)
+	for _, postcode := range zone.Postcodes {
+		if postcode == zip {
+			return true
+		}
+	}
+	if len(zone.Polygon) >= 3 && address.Location != nil {
+		return pointInPolygon(*address.Location, zone.Polygon)
+	}
+	return false
+}
+
+// applyDeliveryZone loads the zones of a supplier and sets the one covering the address.
+// Suppliers without zones deliver everywhere.
+func applyDeliveryZone(zoneRepo repository.DeliveryZoneRepository, supplier *domain.Supplier, address *domain.Address) error {
+	if supplier.DeliveryZones == nil {
+		zones, err := zoneRepo.GetZonesBySupplierID(supplier.ID)
+		if err != nil {
+			return err
+		}
+		supplier.DeliveryZones = zones
+	}
+	supplier.DeliveryZone = nil
+	if len(supplier.DeliveryZones) == 0 {
+		return nil
+	}
+	for _, zone := range supplier.DeliveryZones {
+		if zoneCovers(zone, address) {
+			supplier.DeliveryZone = zone
+			return nil
+		}
+	}
+	return ErrAddressOutsideZone
+}
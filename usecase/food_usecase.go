@@ -25,8 +25,8 @@ type FoodUseCase interface {
 	UpdateFood(food *domain.Food) error
 	SyncGallery(foodID int64, images []*domain.Image) error
 	DeleteFood(foodID int64) error
-	GetAllFoodsWithImages() ([]*domain.Food, error)
-	GetFoodsByCategoryAndSupplier(categoryID, supplierID int64) ([]*domain.Food, error)
+	GetAllFoodsWithImages(sortBy string) ([]*domain.Food, error)
+	GetFoodsByCategoryAndSupplier(categoryID, supplierID int64, sortBy string) ([]*domain.Food, error)
 }
 
 type foodUseCase struct {
@@ -34,15 +34,18 @@ type foodUseCase struct {
 	categoryRepo repository.CategoryRepository
 	supplierRepo repository.SupplierRepository
 	galleryRepo  repository.GalleryRepository
+	modifierRepo repository.ModifierRepository
 }
 
 func NewFoodUseCase(foodRepo repository.FoodRepository, categoryRepo repository.CategoryRepository,
-	supplierRepo repository.SupplierRepository, galleryRepo repository.GalleryRepository) *foodUseCase {
+	supplierRepo repository.SupplierRepository, galleryRepo repository.GalleryRepository,
+	modifierRepo repository.ModifierRepository) *foodUseCase {
 	return &foodUseCase{
 		foodRepo:     foodRepo,
 		categoryRepo: categoryRepo,
 		supplierRepo: supplierRepo,
 		galleryRepo:  galleryRepo,
+		modifierRepo: modifierRepo,
 	}
 }
 
@@ -52,6 +55,11 @@ func (fu *foodUseCase) GetFoodByID(foodID int64) (*domain.Food, error) {
 		return nil, ErrFoodNotFound
 	}
 
+	food.ModifierGroups, err = fu.modifierRepo.GetGroupsByFoodID(foodID)
+	if err != nil {
+		return nil, err
+	}
+
 	return food, nil
 }
 
@@ -80,7 +88,12 @@ func (fu *foodUseCase) CreateFood(food *domain.Food) error {
 		return ErrGalleryRequired
 	}
 
-	_, err := fu.categoryRepo.GetCategoryByID(food.CategoryID)
+	err := validateModifierGroups(food.ModifierGroups)
+	if err != nil {
+		return err
+	}
+
+	_, err = fu.categoryRepo.GetCategoryByID(food.CategoryID)
 	if err != nil {
 		return ErrCategoryNotFound
 	}
@@ -95,11 +108,23 @@ func (fu *foodUseCase) CreateFood(food *domain.Food) error {
 		return err
 	}
 
+	if len(food.ModifierGroups) > 0 {
+		err = fu.modifierRepo.SyncGroups(food.ID, food.ModifierGroups)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
 func (fu *foodUseCase) UpdateFood(food *domain.Food) error {
-	_, err := fu.supplierRepo.GetSupplierByID(food.SupplierID)
+	err := validateModifierGroups(food.ModifierGroups)
+	if err != nil {
+		return err
+	}
+
+	_, err = fu.supplierRepo.GetSupplierByID(food.SupplierID)
 	if err != nil {
 		return ErrSupplierNotFound
 	}
@@ -114,6 +139,14 @@ func (fu *foodUseCase) UpdateFood(food *domain.Food) error {
 		return err
 	}
 
+	// Modifier groups are only replaced when the request carries them.
+	if food.ModifierGroups != nil {
+		err = fu.modifierRepo.SyncGroups(food.ID, food.ModifierGroups)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -208,14 +241,32 @@ func (fu *foodUseCase) DeleteFood(foodID int64) error {
 	return nil
 }
 
-func (fu *foodUseCase) GetAllFoodsWithImages() ([]*domain.Food, error) {
+func (fu *foodUseCase) GetAllFoodsWithImages(sortBy string) ([]*domain.Food, error) {
+	err := validateSort(sortBy)
+	if err != nil {
+		return nil, err
+	}
 	foods, err := fu.foodRepo.GetAllFoodsWithImages()
 	if err != nil {
 		return nil, err
 	}
+	if sortBy == domain.SortByRating {
+		sortFoodsByRating(foods)
+	}
 	return foods, nil
 }
 
-func (fu *foodUseCase) GetFoodsByCategoryAndSupplier(categoryID, supplierID int64) ([]*domain.Food, error) {
-	return fu.foodRepo.GetFoodsByCategoryAndSupplier(categoryID, supplierID)
+func (fu *foodUseCase) GetFoodsByCategoryAndSupplier(categoryID, supplierID int64, sortBy string) ([]*domain.Food, error) {
+	err := validateSort(sortBy)
+	if err != nil {
+		return nil, err
+	}
+	foods, err := fu.foodRepo.GetFoodsByCategoryAndSupplier(categoryID, supplierID)
+	if err != nil {
+		return nil, err
+	}
+	if sortBy == domain.SortByRating {
+		sortFoodsByRating(foods)
+	}
+	return foods, nil
 }
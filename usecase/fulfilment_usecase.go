@@ -0,0 +1,306 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"foodDelivery/domain"
+	"foodDelivery/notification"
+	"foodDelivery/repository"
+	"html"
+	"log"
+	"strings"
+)
+
+var (
+	ErrInvalidSubstitution = errors.New("substitution preference must be cancel, remove or replace")
+	ErrUnknownOrderItem    = errors.New("unavailable item is not a line of the order")
+	ErrInvalidReplacement  = errors.New("replacement must be another food of the same supplier")
+)
+
+type FulfilmentUseCase interface {
+	AcceptOrder(supplierID int64, orderID int64, acceptance *domain.OrderAcceptance) (*domain.Order, error)
+}
+
+type fulfilmentUseCase struct {
+	orderRepo     repository.OrderRepository
+	supplierRepo  repository.SupplierRepository
+	foodRepo      repository.FoodRepository
+	couponRepo    repository.CouponRepository
+	userRepo      repository.UserRepository
+	pricingEngine PricingEngine
+	payments      PaymentUseCase
+	notifier      notification.Notifier
+}
+
+func NewFulfilmentUseCase(orderRepo repository.OrderRepository, supplierRepo repository.SupplierRepository,
+	foodRepo repository.FoodRepository, couponRepo repository.CouponRepository, userRepo repository.UserRepository,
+	pricingEngine PricingEngine, payments PaymentUseCase, notifier notification.Notifier) FulfilmentUseCase {
+	return &fulfilmentUseCase{
+		orderRepo:     orderRepo,
+		supplierRepo:  supplierRepo,
+		foodRepo:      foodRepo,
+		couponRepo:    couponRepo,
+		userRepo:      userRepo,
+		pricingEngine: pricingEngine,
+		payments:      payments,
+		notifier:      notifier,
+	}
+}
+
+// AcceptOrder confirms a pending order, without the items the supplier cannot make. They are
+// handled by the substitution preference of the customer: the order is cancelled, or the items
+// are removed or replaced and the order is priced again at the prices it was placed with. The
+// card payment is then captured for the new total on delivery, and the wallet part above it is
+// credited back. The customer is told about any change.
+func (fu *fulfilmentUseCase) AcceptOrder(supplierID int64, orderID int64, acceptance *domain.OrderAcceptance) (*domain.Order, error) {
+	order, err := fu.orderRepo.GetOrderWithItems(orderID)
+	if err != nil {
+		return nil, err
+	}
+	if order.SupplierID != supplierID {
+		return nil, repository.ErrOrderNotFound
+	}
+	if order.Status != domain.OrderStatusPending || order.ConfirmedAt != nil {
+		return nil, repository.ErrInvalidStatusTransition
+	}
+
+	changes, err := fu.planChanges(order, acceptance.UnavailableItems)
+	if err != nil {
+		return nil, err
+	}
+	remaining := make([]domain.OrderItem, 0, len(*order.Items))
+	for _, item := range *order.Items {
+		if !removedItem(changes, item.ID) {
+			remaining = append(remaining, item)
+		}
+	}
+	if len(changes) > 0 && (order.SubstitutionPreference == domain.SubstitutionCancel || len(remaining) == 0) {
+		err = fu.payments.CancelOrder(orderID)
+		if err != nil {
+			return nil, err
+		}
+		order.Status = domain.OrderStatusCancelled
+		fu.notifyCustomer(order, changes, 0)
+		return fu.getOrder(orderID)
+	}
+
+	var walletRefund float32
+	if len(changes) > 0 {
+		previousTotal := order.Price
+		order.Items = &remaining
+		err = fu.reprice(order)
+		if err != nil {
+			return nil, err
+		}
+		capTotal(order, previousTotal)
+		if order.WalletAmount > order.Price {
+			walletRefund = roundMoney(order.WalletAmount - order.Price)
+			order.WalletAmount = order.Price
+		}
+	}
+	err = fu.orderRepo.ApplyFulfilment(order, changes, walletRefund)
+	if err != nil {
+		return nil, err
+	}
+	if len(changes) > 0 {
+		fu.notifyCustomer(order, changes, walletRefund)
+	}
+	return fu.getOrder(orderID)
+}
+
+// planChanges checks the unavailable lines and decides what happens to each of them. Lines
+// taking a replacement get its food and the lower of the two prices, so that the customer
+// never pays more than authorized.
+func (fu *fulfilmentUseCase) planChanges(order *domain.Order, unavailable []*domain.UnavailableItem) ([]*domain.FulfilmentChange, error) {
+	changes := make([]*domain.FulfilmentChange, 0, len(unavailable))
+	for _, entry := range unavailable {
+		var item *domain.OrderItem
+		for i := range *order.Items {
+			if (*order.Items)[i].ID == entry.OrderItemID {
+				item = &(*order.Items)[i]
+			}
+		}
+		if item == nil {
+			return nil, fmt.Errorf("%w: %d", ErrUnknownOrderItem, entry.OrderItemID)
+		}
+		for _, change := range changes {
+			if change.OrderItemID == item.ID {
+				return nil, fmt.Errorf("%w: %d is listed twice", ErrUnknownOrderItem, item.ID)
+			}
+		}
+
+		unitPrice := roundMoney(item.SinglePrice + modifiersTotal(item.Modifiers))
+		change := &domain.FulfilmentChange{
+			OrderItemID: item.ID,
+			FoodID:      item.FoodID,
+			FoodName:    item.FoodName,
+			Quantity:    item.Quantity,
+			UnitPrice:   unitPrice,
+			Action:      domain.FulfilmentActionRemoved,
+		}
+		if order.SubstitutionPreference == domain.SubstitutionReplace && entry.ReplacementFoodID != 0 {
+			food, err := fu.foodRepo.GetFoodByID(entry.ReplacementFoodID)
+			if err != nil {
+				if errors.Is(err, repository.ErrFoodNotFound) {
+					return nil, ErrFoodNotFound
+				}
+				return nil, err
+			}
+			if food.SupplierID != order.SupplierID || food.ID == item.FoodID {
+				return nil, ErrInvalidReplacement
+			}
+			change.Action = domain.FulfilmentActionReplaced
+			change.ReplacementFoodID = food.ID
+			change.ReplacementFoodName = food.Name
+
+			item.FoodID = food.ID
+			item.FoodName = food.Name
+			item.Modifiers = nil
+			item.SinglePrice = unitPrice
+			if float32(food.Price) < unitPrice {
+				item.SinglePrice = float32(food.Price)
+			}
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// reprice calculates the totals of the remaining lines. The delivery fee stays as charged,
+// the coupon applies to the new lines and the loyalty points keep their value, up to the
+// new subtotal.
+func (fu *fulfilmentUseCase) reprice(order *domain.Order) error {
+	supplier, err := fu.supplierRepo.GetSupplierByID(order.SupplierID)
+	if err != nil {
+		return err
+	}
+	request := &PricingRequest{
+		Supplier:     supplier,
+		Items:        order.Items,
+		Tips:         order.Tips,
+		FreeDelivery: true,
+		KeepPrices:   true,
+	}
+	if order.CouponCode != "" {
+		request.Coupon, err = fu.couponRepo.GetCouponByCode(order.CouponCode)
+		if err != nil {
+			return err
+		}
+	}
+	if pointsValue := roundMoney(order.Breakdown.Discount - order.CouponDiscount); order.LoyaltyPoints > 0 && pointsValue > 0 {
+		request.Discounts = append(request.Discounts, &domain.Discount{
+			Code:   domain.DiscountCodeLoyalty,
+			Label:  fmt.Sprintf("%d loyalty points", order.LoyaltyPoints),
+			Amount: pointsValue,
+		})
+	}
+
+	breakdown, err := fu.pricingEngine.Calculate(request)
+	if err != nil {
+		return err
+	}
+	breakdown.DeliveryFee = order.Breakdown.DeliveryFee
+	breakdown.Total = roundMoney(breakdown.Total + breakdown.DeliveryFee)
+	order.CouponDiscount = 0
+	for _, discount := range breakdown.Discounts {
+		if request.Coupon != nil && discount.Code == request.Coupon.Code {
+			order.CouponDiscount += discount.Amount
+		}
+	}
+	order.Breakdown = breakdown
+	order.Price = breakdown.Total
+	return nil
+}
+
+// capTotal keeps a repriced order from costing more than before, which happens when its
+// coupon no longer applies to what is left. The difference is taken off with a discount
+// line of its own, so that the breakdown still adds up to the price.
+func capTotal(order *domain.Order, previousTotal float32) {
+	excess := roundMoney(order.Price - previousTotal)
+	if excess <= 0 {
+		return
+	}
+	breakdown := order.Breakdown
+	breakdown.Discounts = append(breakdown.Discounts, &domain.Discount{
+		Code:   domain.DiscountCodeFulfilment,
+		Label:  "Changes by the supplier",
+		Amount: excess,
+	})
+	breakdown.Discount = roundMoney(breakdown.Discount + excess)
+	breakdown.Total = previousTotal
+	order.Price = previousTotal
+}
+
+// notifyCustomer e-mails the customer what changed in the order. A failure is only logged,
+// the order stands either way.
+func (fu *fulfilmentUseCase) notifyCustomer(order *domain.Order, changes []*domain.FulfilmentChange, walletRefund float32) {
+	user, err := fu.userRepo.GetUserByID(order.UserID)
+	if err != nil {
+		log.Printf("Failed to notify the customer of order %d: %v", order.ID, err)
+		return
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "<p>%s could not prepare everything in your order #%d.</p>\n<ul>\n",
+		html.EscapeString(order.SupplierName), order.ID)
+	for _, change := range changes {
+		line := fmt.Sprintf("%d x %s is not available", change.Quantity, change.FoodName)
+		if change.Action == domain.FulfilmentActionReplaced {
+			line += ", replaced with " + change.ReplacementFoodName
+		}
+		fmt.Fprintf(&body, "<li>%s</li>\n", html.EscapeString(line))
+	}
+	body.WriteString("</ul>\n")
+	subject := fmt.Sprintf("Your order #%d was changed", order.ID)
+	if order.Status == domain.OrderStatusCancelled {
+		subject = fmt.Sprintf("Your order #%d was cancelled", order.ID)
+		body.WriteString("<p>The order was cancelled and you will not be charged for it.</p>\n")
+	} else {
+		fmt.Fprintf(&body, "<p>The new total is %s.", money(order.Price))
+		if walletRefund > 0 {
+			fmt.Fprintf(&body, " %s was credited back to your wallet.", money(walletRefund))
+		}
+		body.WriteString("</p>\n")
+	}
+
+	err = fu.notifier.Send(&notification.Message{
+		To:      user.Email,
+		Subject: subject,
+		HTML:    body.String(),
+	})
+	if err != nil {
+		log.Printf("Failed to notify the customer of order %d: %v", order.ID, err)
+	}
+}
+
+func (fu *fulfilmentUseCase) getOrder(orderID int64) (*domain.Order, error) {
+	order, err := fu.orderRepo.GetOrderWithItems(orderID)
+	if err != nil {
+		return nil, err
+	}
+	hideDeliveryPins([]*domain.Order{order})
+	return order, nil
+}
+
+// removedItem reports whether a line of the order is removed by the changes.
+func removedItem(changes []*domain.FulfilmentChange, orderItemID int64) bool {
+	for _, change := range changes {
+		if change.OrderItemID == orderItemID {
+			return change.Action == domain.FulfilmentActionRemoved
+		}
+	}
+	return false
+}
+
+// checkSubstitutionPreference defaults the preference of a new order to removing the
+// unavailable items.
+func checkSubstitutionPreference(order *domain.Order) error {
+	switch order.SubstitutionPreference {
+	case "":
+		order.SubstitutionPreference = domain.SubstitutionRemove
+	case domain.SubstitutionCancel, domain.SubstitutionRemove, domain.SubstitutionReplace:
+	default:
+		return ErrInvalidSubstitution
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+package usecase
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"foodDelivery/document"
+	"foodDelivery/domain"
+	"html/template"
+	"strings"
+)
+
+var (
+	ErrInvalidInvoiceFormat = errors.New("invoice format must be html or pdf")
+)
+
+const (
+	InvoiceFormatHTML = "html"
+	InvoiceFormatPDF  = "pdf"
+)
+
+// invoiceLine is an item of the order as printed on the invoice, the unit price
+// includes the chosen modifiers.
+type invoiceLine struct {
+	Description string
+	Details     string
+	Quantity    int8
+	UnitPrice   float32
+	Amount      float32
+}
+
+// invoiceTotal is a row of the totals block.
+type invoiceTotal struct {
+	Label  string
+	Amount float32
+}
+
+// RenderInvoice returns an invoice as a file with its content type.
+func (iu *invoiceUseCase) RenderInvoice(invoice *domain.Invoice, format string) ([]byte, string, error) {
+	switch format {
+	case InvoiceFormatHTML:
+		content, err := invoiceHTML(invoice)
+		return content, "text/html; charset=utf-8", err
+	case InvoiceFormatPDF:
+		return invoicePDF(invoice), "application/pdf", nil
+	default:
+		return nil, "", ErrInvalidInvoiceFormat
+	}
+}
+
+func invoiceLines(order *domain.Order) []invoiceLine {
+	lines := make([]invoiceLine, 0)
+	if order.Items == nil {
+		return lines
+	}
+	for _, item := range *order.Items {
+		details := make([]string, 0, len(item.Modifiers)+1)
+		for _, modifier := range item.Modifiers {
+			details = append(details, modifier.GroupName+": "+modifier.Name)
+		}
+		if item.Note != "" {
+			details = append(details, "Note: "+item.Note)
+		}
+		unitPrice := roundMoney(item.SinglePrice + modifiersTotal(item.Modifiers))
+		lines = append(lines, invoiceLine{
+			Description: item.FoodName,
+			Details:     strings.Join(details, ", "),
+			Quantity:    item.Quantity,
+			UnitPrice:   unitPrice,
+			Amount:      roundMoney(unitPrice * float32(item.Quantity)),
+		})
+	}
+	return lines
+}
+
+// invoiceTotals lists the amounts between the subtotal and the total, with one row per
+// tax rate. Orders placed before the tax lines were stored show the tax as a single row.
+func invoiceTotals(order *domain.Order) []invoiceTotal {
+	breakdown := order.Breakdown
+	totals := []invoiceTotal{{"Subtotal", breakdown.Subtotal}}
+	if breakdown.Discount > 0 {
+		label := "Discount"
+		if order.CouponCode != "" {
+			label += " (" + order.CouponCode + ")"
+		}
+		totals = append(totals, invoiceTotal{label, -breakdown.Discount})
+	}
+	totals = append(totals, invoiceTotal{"Delivery fee", breakdown.DeliveryFee},
+		invoiceTotal{"Service fee", breakdown.ServiceFee})
+	if len(breakdown.Taxes) == 0 {
+		totals = append(totals, invoiceTotal{"Tax", breakdown.Tax})
+	}
+	for _, tax := range breakdown.Taxes {
+		totals = append(totals, invoiceTotal{
+			fmt.Sprintf("%s %s%% on %s", tax.Name, percent(tax.Rate), money(tax.Base)), tax.Amount,
+		})
+	}
+	if breakdown.Tip > 0 {
+		totals = append(totals, invoiceTotal{"Tip", breakdown.Tip})
+	}
+	return totals
+}
+
+// invoicePayments lists how the total was paid.
+func invoicePayments(order *domain.Order) []invoiceTotal {
+	payments := make([]invoiceTotal, 0, 2)
+	if order.WalletAmount > 0 {
+		payments = append(payments, invoiceTotal{"Wallet", order.WalletAmount})
+	}
+	if card := roundMoney(order.Price - order.WalletAmount); card > 0 {
+		payments = append(payments, invoiceTotal{"Card", card})
+	}
+	return payments
+}
+
+var invoiceTemplate = template.Must(template.New("invoice").Funcs(template.FuncMap{"money": money}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Invoice {{.Invoice.Number}}</title>
+<style>
+body { font-family: Helvetica, Arial, sans-serif; color: #222; max-width: 720px; margin: 24px auto; }
+table { width: 100%; border-collapse: collapse; margin-top: 16px; }
+th, td { padding: 6px 4px; text-align: left; }
+th { border-bottom: 1px solid #999; }
+.amount { text-align: right; }
+.details { color: #666; font-size: 12px; }
+.parties td { vertical-align: top; width: 50%; }
+.total td { font-weight: bold; border-top: 1px solid #999; }
+</style>
+</head>
+<body>
+<h1>Invoice {{.Invoice.Number}}</h1>
+<p>Issued on {{.Invoice.IssuedAt.Format "2006-01-02"}} for order #{{.Invoice.OrderID}}{{with .Invoice.Order.DeliveredAt}}, delivered on {{.Format "2006-01-02 15:04"}}{{end}}</p>
+<table class="parties">
+<tr>
+<td><strong>From</strong><br>{{.Invoice.Supplier.Name}}<br>{{.Invoice.Supplier.Address}}</td>
+<td><strong>Billed to</strong><br>{{.Invoice.Customer.Name}}{{with .Invoice.Customer.Address}}<br>{{.}}{{end}}{{with .Invoice.Customer.Email}}<br>{{.}}{{end}}{{with .Invoice.Customer.Phone}}<br>{{.}}{{end}}</td>
+</tr>
+</table>
+<table>
+<tr><th>Item</th><th class="amount">Quantity</th><th class="amount">Unit price</th><th class="amount">Amount</th></tr>
+{{range .Lines}}<tr>
+<td>{{.Description}}{{if .Details}}<div class="details">{{.Details}}</div>{{end}}</td>
+<td class="amount">{{.Quantity}}</td>
+<td class="amount">{{money .UnitPrice}}</td>
+<td class="amount">{{money .Amount}}</td>
+</tr>
+{{end}}</table>
+<table>
+{{range .Totals}}<tr><td>{{.Label}}</td><td class="amount">{{money .Amount}}</td></tr>
+{{end}}<tr class="total"><td>Total</td><td class="amount">{{money .Invoice.Order.Price}}</td></tr>
+{{range .Payments}}<tr><td>Paid by {{.Label}}</td><td class="amount">{{money .Amount}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+func invoiceHTML(invoice *domain.Invoice) ([]byte, error) {
+	var out bytes.Buffer
+	err := invoiceTemplate.Execute(&out, map[string]interface{}{
+		"Invoice":  invoice,
+		"Lines":    invoiceLines(invoice.Order),
+		"Totals":   invoiceTotals(invoice.Order),
+		"Payments": invoicePayments(invoice.Order),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func invoicePDF(invoice *domain.Invoice) []byte {
+	order := invoice.Order
+	pdf := document.NewPDF()
+	pdf.Title("Invoice " + invoice.Number)
+	issued := fmt.Sprintf("Issued on %s for order #%d", invoice.IssuedAt.Format("2006-01-02"), invoice.OrderID)
+	if order.DeliveredAt != nil {
+		issued += ", delivered on " + order.DeliveredAt.UTC().Format("2006-01-02 15:04")
+	}
+	pdf.Text(issued)
+
+	pdf.Space()
+	pdf.Heading("From")
+	pdf.Text(invoice.Supplier.Name)
+	pdf.Text(invoice.Supplier.Address)
+	pdf.Space()
+	pdf.Heading("Billed to")
+	for _, text := range []string{invoice.Customer.Name, invoice.Customer.Address, invoice.Customer.Email,
+		invoice.Customer.Phone} {
+		if text != "" {
+			pdf.Text(text)
+		}
+	}
+
+	pdf.Space()
+	pdf.Mono(fmt.Sprintf("%-44s %8s %12s %12s", "Item", "Quantity", "Unit price", "Amount"))
+	for _, line := range invoiceLines(order) {
+		pdf.Mono(fmt.Sprintf("%-44.44s %8d %12s %12s", line.Description, line.Quantity, money(line.UnitPrice),
+			money(line.Amount)))
+		if line.Details != "" {
+			pdf.Mono(fmt.Sprintf("  %-.76s", line.Details))
+		}
+	}
+
+	pdf.Space()
+	for _, total := range invoiceTotals(order) {
+		pdf.Mono(fmt.Sprintf("%-66.66s %12s", total.Label, money(total.Amount)))
+	}
+	pdf.Heading(fmt.Sprintf("Total: %s", money(order.Price)))
+	for _, payment := range invoicePayments(order) {
+		pdf.Mono(fmt.Sprintf("%-66.66s %12s", "Paid by "+payment.Label, money(payment.Amount)))
+	}
+	return pdf.Bytes()
+}
+
+func percent(rate float32) string {
+	return strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.2f", rate*100), "0"), ".")
+}
@@ -0,0 +1,173 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"foodDelivery/domain"
+	"foodDelivery/notification"
+	"foodDelivery/repository"
+	"log"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvoiceNotAvailable = errors.New("invoices are issued once the order is delivered")
+)
+
+type InvoiceUseCase interface {
+	IssueInvoice(orderID int64) (*domain.Invoice, error)
+	GetInvoice(userID int64, orderID int64) (*domain.Invoice, error)
+	RenderInvoice(invoice *domain.Invoice, format string) ([]byte, string, error)
+}
+
+type invoiceUseCase struct {
+	invoiceRepo  repository.InvoiceRepository
+	orderRepo    repository.OrderRepository
+	supplierRepo repository.SupplierRepository
+	userRepo     repository.UserRepository
+	addressRepo  repository.AddressRepository
+	notifier     notification.Notifier
+}
+
+func NewInvoiceUseCase(invoiceRepo repository.InvoiceRepository, orderRepo repository.OrderRepository,
+	supplierRepo repository.SupplierRepository, userRepo repository.UserRepository, addressRepo repository.AddressRepository,
+	notifier notification.Notifier) InvoiceUseCase {
+	return &invoiceUseCase{
+		invoiceRepo:  invoiceRepo,
+		orderRepo:    orderRepo,
+		supplierRepo: supplierRepo,
+		userRepo:     userRepo,
+		addressRepo:  addressRepo,
+		notifier:     notifier,
+	}
+}
+
+// IssueInvoice creates the invoice of a delivered order and e-mails it to the customer.
+// An order has a single invoice, issuing it again returns the existing one.
+func (iu *invoiceUseCase) IssueInvoice(orderID int64) (*domain.Invoice, error) {
+	order, err := iu.orderRepo.GetOrderWithItems(orderID)
+	if err != nil {
+		return nil, err
+	}
+	invoice, err := iu.invoiceRepo.GetInvoiceByOrderID(orderID)
+	if err == nil {
+		invoice.Order = order
+		return invoice, nil
+	}
+	if !errors.Is(err, repository.ErrInvoiceNotFound) {
+		return nil, err
+	}
+	if order.Status != domain.OrderStatusDelivered {
+		return nil, ErrInvoiceNotAvailable
+	}
+
+	supplier, err := iu.supplierRepo.GetSupplierByID(order.SupplierID)
+	if err != nil {
+		return nil, err
+	}
+	user, err := iu.userRepo.GetUserByID(order.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	invoice = &domain.Invoice{
+		OrderID:  orderID,
+		Supplier: &domain.InvoiceParty{Name: supplier.Name, Address: supplier.Address},
+		Customer: &domain.InvoiceParty{
+			Name:  strings.TrimSpace(user.Name + " " + user.LastName),
+			Email: user.Email,
+			Phone: user.Phone,
+		},
+		IssuedAt: time.Now().UTC(),
+		Order:    order,
+	}
+	// Pickup and dine-in orders have no address to bill to.
+	if order.AddressID != 0 {
+		address, err := iu.addressRepo.GetAddressByID(order.AddressID)
+		if err != nil {
+			return nil, err
+		}
+		invoice.Customer.Address = formatAddress(address)
+	}
+	err = iu.invoiceRepo.CreateInvoice(invoice)
+	if errors.Is(err, repository.ErrInvoiceExists) {
+		// Issued concurrently, the other request sends the e-mail.
+		invoice, err = iu.invoiceRepo.GetInvoiceByOrderID(orderID)
+		if err != nil {
+			return nil, err
+		}
+		invoice.Order = order
+		return invoice, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// The invoice stays downloadable when the e-mail cannot be sent.
+	err = iu.sendInvoice(invoice)
+	if err != nil {
+		log.Printf("Failed to e-mail invoice %s: %v", invoice.Number, err)
+	}
+	return invoice, nil
+}
+
+// GetInvoice returns the invoice of an order of the user, issuing it if the order was
+// delivered without one. A userID of 0 skips the ownership check.
+func (iu *invoiceUseCase) GetInvoice(userID int64, orderID int64) (*domain.Invoice, error) {
+	order, err := iu.orderRepo.GetOrderWithItems(orderID)
+	if err != nil {
+		return nil, err
+	}
+	if userID != 0 && order.UserID != userID {
+		return nil, repository.ErrOrderNotFound
+	}
+	invoice, err := iu.invoiceRepo.GetInvoiceByOrderID(orderID)
+	if errors.Is(err, repository.ErrInvoiceNotFound) {
+		return iu.IssueInvoice(orderID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	invoice.Order = order
+	return invoice, nil
+}
+
+func (iu *invoiceUseCase) sendInvoice(invoice *domain.Invoice) error {
+	html, err := invoiceHTML(invoice)
+	if err != nil {
+		return err
+	}
+	err = iu.notifier.Send(&notification.Message{
+		To:      invoice.Customer.Email,
+		Subject: fmt.Sprintf("Your invoice %s for order #%d", invoice.Number, invoice.OrderID),
+		HTML:    string(html),
+		Attachments: []notification.Attachment{{
+			Filename:    invoice.Number + ".pdf",
+			ContentType: "application/pdf",
+			Content:     invoicePDF(invoice),
+		}},
+	})
+	if err != nil {
+		return err
+	}
+	emailedAt := time.Now().UTC()
+	err = iu.invoiceRepo.MarkInvoiceEmailed(invoice.ID, emailedAt)
+	if err != nil {
+		return err
+	}
+	invoice.EmailedAt = &emailedAt
+	return nil
+}
+
+// formatAddress writes an address on one line, leaving out the empty parts.
+func formatAddress(address *domain.Address) string {
+	parts := make([]string, 0, 4)
+	for _, part := range []string{address.Address, address.Street,
+		strings.TrimSpace(address.Zip + " " + address.City), address.Country} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,157 @@
+package usecase
+
+import (
+	"foodDelivery/domain"
+	"foodDelivery/repository"
+	"log"
+	"sync"
+	"time"
+)
+
+// courierOfflineAfter is how long a courier can go without a position before the
+// positions of its deliveries are dropped.
+const courierOfflineAfter = 10 * time.Minute
+
+type deliveryPosition struct {
+	courierID int64
+	location  domain.GeoPoint
+	at        time.Time
+	dirty     bool
+}
+
+// LocationTracker keeps the latest courier position of every active delivery in memory,
+// so pings never wait on the database, and periodically writes the changed ones to it.
+// A position is dropped when its order is delivered, when the next ping of the courier
+// shows the order was cancelled or handed back, and when the courier goes offline.
+type LocationTracker struct {
+	courierRepository repository.CourierRepository
+	interval          time.Duration
+	mu                sync.RWMutex
+	positions         map[int64]*deliveryPosition
+	stop              chan struct{}
+	done              chan struct{}
+}
+
+func NewLocationTracker(courierRepository repository.CourierRepository, interval time.Duration) *LocationTracker {
+	return &LocationTracker{
+		courierRepository: courierRepository,
+		interval:          interval,
+		positions:         make(map[int64]*deliveryPosition),
+		stop:              make(chan struct{}),
+		done:              make(chan struct{}),
+	}
+}
+
+// Record stores a position of a courier for the orders it is delivering, unless a newer
+// one is already known. Orders the courier no longer has are forgotten.
+func (lt *LocationTracker) Record(courierID int64, orderIDs []int64, location domain.GeoPoint, at time.Time) {
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+
+	active := make(map[int64]bool, len(orderIDs))
+	for _, orderID := range orderIDs {
+		active[orderID] = true
+		position, found := lt.positions[orderID]
+		if found && position.courierID == courierID && position.at.After(at) {
+			continue
+		}
+		lt.positions[orderID] = &deliveryPosition{courierID: courierID, location: location, at: at, dirty: true}
+	}
+	for orderID, position := range lt.positions {
+		if position.courierID == courierID && !active[orderID] {
+			delete(lt.positions, orderID)
+		}
+	}
+}
+
+// Forget drops the position of an order that is not on its way anymore.
+func (lt *LocationTracker) Forget(orderID int64) {
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+
+	delete(lt.positions, orderID)
+}
+
+// Latest returns the last courier position recorded for an order since the server started.
+func (lt *LocationTracker) Latest(orderID int64) (*domain.GeoPoint, *time.Time, bool) {
+	lt.mu.RLock()
+	defer lt.mu.RUnlock()
+
+	position, found := lt.positions[orderID]
+	if !found {
+		return nil, nil, false
+	}
+	location, at := position.location, position.at
+	return &location, &at, true
+}
+
+// Start persists the positions in the background until Stop is called.
+func (lt *LocationTracker) Start() {
+	go func() {
+		defer close(lt.done)
+		ticker := time.NewTicker(lt.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				lt.flush()
+			case <-lt.stop:
+				lt.flush()
+				return
+			}
+		}
+	}()
+}
+
+// Stop writes the pending positions and stops the background loop.
+func (lt *LocationTracker) Stop() {
+	close(lt.stop)
+	<-lt.done
+}
+
+// flush writes the changed positions, once per courier, and drops those of couriers that
+// went offline once they are written.
+func (lt *LocationTracker) flush() {
+	type pending struct {
+		location domain.GeoPoint
+		at       time.Time
+	}
+
+	lt.mu.Lock()
+	offlineBefore := time.Now().Add(-courierOfflineAfter)
+	changed := make(map[int64]pending)
+	for orderID, position := range lt.positions {
+		if !position.dirty {
+			if position.at.Before(offlineBefore) {
+				delete(lt.positions, orderID)
+			}
+			continue
+		}
+		if latest, found := changed[position.courierID]; !found || position.at.After(latest.at) {
+			changed[position.courierID] = pending{location: position.location, at: position.at}
+		}
+		position.dirty = false
+	}
+	lt.mu.Unlock()
+
+	for courierID, position := range changed {
+		err := lt.courierRepository.UpdateCourierLocation(courierID, position.location, position.at)
+		if err != nil {
+			log.Printf("Failed to store location of courier %d: %v", courierID, err)
+			lt.markDirty(courierID, position.at)
+		}
+	}
+}
+
+// markDirty retries a failed write on the next flush, unless a newer position came in.
+func (lt *LocationTracker) markDirty(courierID int64, at time.Time) {
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+
+	for _, position := range lt.positions {
+		if position.courierID == courierID && position.at.Equal(at) {
+			position.dirty = true
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"foodDelivery/domain"
+	"foodDelivery/repository"
+	"math"
+	"time"
+)
+
+var (
+	ErrInvalidLoyaltyPoints = errors.New("loyalty points to redeem are below the minimum")
+	ErrTooManyPoints        = errors.New("loyalty points are worth more than the order subtotal")
+)
+
+// LoyaltyConfig holds the rules of the loyalty program. Tiers are sorted by MinPoints
+// and the first one starts at 0.
+type LoyaltyConfig struct {
+	PointsPerUnit   float32
+	PointValue      float32
+	MinRedeemPoints int
+	ExpiryPeriod    time.Duration
+	TierPeriod      time.Duration
+	Tiers           []domain.LoyaltyTier
+}
+
+type LoyaltyUseCase interface {
+	GetAccount(userID int64) (*domain.LoyaltyAccount, error)
+	GetTransactions(userID int64) ([]*domain.LoyaltyTransaction, error)
+	GetTiers() []domain.LoyaltyTier
+	GetTier(userID int64) (*domain.LoyaltyTier, error)
+	RedemptionDiscount(userID int64, points int) (*domain.Discount, error)
+	PointsDiscount(points int) *domain.Discount
+	AwardOrderPoints(orderID int64) error
+	ReverseOrderPoints(order *domain.Order, refunded float32) error
+	RestoreOrderPoints(orderID int64) error
+}
+
+type loyaltyUseCase struct {
+	loyaltyRepo repository.LoyaltyRepository
+	orderRepo   repository.OrderRepository
+	config      LoyaltyConfig
+}
+
+func NewLoyaltyUseCase(loyaltyRepo repository.LoyaltyRepository, orderRepo repository.OrderRepository,
+	config LoyaltyConfig) LoyaltyUseCase {
+	return &loyaltyUseCase{
+		loyaltyRepo: loyaltyRepo,
+		orderRepo:   orderRepo,
+		config:      config,
+	}
+}
+
+// GetAccount returns the points of a user with their value, tier and next expiry.
+func (lu *loyaltyUseCase) GetAccount(userID int64) (*domain.LoyaltyAccount, error) {
+	balance, err := lu.loyaltyRepo.GetBalance(userID)
+	if err != nil {
+		return nil, err
+	}
+	tierPoints, err := lu.tierPoints(userID)
+	if err != nil {
+		return nil, err
+	}
+	expiring, expiresAt, err := lu.loyaltyRepo.GetNextExpiry(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	account := &domain.LoyaltyAccount{
+		UserID:         userID,
+		Points:         balance,
+		TierPoints:     tierPoints,
+		ExpiringPoints: expiring,
+		ExpiresAt:      expiresAt,
+	}
+	if balance > 0 {
+		account.PointsValue = lu.pointsValue(balance)
+	}
+	account.Tier, account.NextTier = lu.tierFor(tierPoints)
+	if account.NextTier != nil {
+		account.PointsToNextTier = account.NextTier.MinPoints - tierPoints
+	}
+	return account, nil
+}
+
+func (lu *loyaltyUseCase) GetTransactions(userID int64) ([]*domain.LoyaltyTransaction, error) {
+	return lu.loyaltyRepo.GetTransactions(userID)
+}
+
+func (lu *loyaltyUseCase) GetTiers() []domain.LoyaltyTier {
+	return lu.config.Tiers
+}
+
+// GetTier returns the tier a user reached with the points earned over the tier period.
+func (lu *loyaltyUseCase) GetTier(userID int64) (*domain.LoyaltyTier, error) {
+	tierPoints, err := lu.tierPoints(userID)
+	if err != nil {
+		return nil, err
+	}
+	tier, _ := lu.tierFor(tierPoints)
+	return tier, nil
+}
+
+// RedemptionDiscount is the discount line paying part of an order with points. The
+// balance is checked again when the order is placed.
+func (lu *loyaltyUseCase) RedemptionDiscount(userID int64, points int) (*domain.Discount, error) {
+	if points <= 0 || points < lu.config.MinRedeemPoints {
+		return nil, fmt.Errorf("%w: at least %d points", ErrInvalidLoyaltyPoints, lu.config.MinRedeemPoints)
+	}
+	balance, err := lu.loyaltyRepo.GetBalance(userID)
+	if err != nil {
+		return nil, err
+	}
+	if balance < points {
+		return nil, repository.ErrInsufficientPoints
+	}
+	return lu.PointsDiscount(points), nil
+}
+
+// PointsDiscount is the discount line of points, for orders that already redeemed them.
+func (lu *loyaltyUseCase) PointsDiscount(points int) *domain.Discount {
+	return &domain.Discount{
+		Code:   domain.DiscountCodeLoyalty,
+		Label:  fmt.Sprintf("%d loyalty points", points),
+		Amount: lu.pointsValue(points),
+	}
+}
+
+// AwardOrderPoints credits the points of a delivered order: the points per unit of the
+// order total without tips, scaled by the tier of the customer.
+func (lu *loyaltyUseCase) AwardOrderPoints(orderID int64) error {
+	order, err := lu.orderRepo.GetOrderWithItems(orderID)
+	if err != nil {
+		return err
+	}
+	if order.Status != domain.OrderStatusDelivered {
+		return nil
+	}
+	tier, err := lu.GetTier(order.UserID)
+	if err != nil {
+		return err
+	}
+
+	spent := order.Price
+	if order.Breakdown != nil {
+		spent -= order.Breakdown.Tip
+	}
+	points := int(math.Floor(float64(spent * lu.config.PointsPerUnit * tier.Multiplier)))
+	if points <= 0 {
+		return nil
+	}
+	return lu.loyaltyRepo.AwardPoints(order.UserID, order.ID, points, time.Now().Add(lu.config.ExpiryPeriod))
+}
+
+// ReverseOrderPoints takes back the points earned with the refunded share of an order.
+func (lu *loyaltyUseCase) ReverseOrderPoints(order *domain.Order, refunded float32) error {
+	if order.Price <= 0 {
+		return nil
+	}
+	share := float64(refunded / order.Price)
+	if share > 1 {
+		share = 1
+	}
+	return lu.loyaltyRepo.ReverseOrderPoints(order.ID, share)
+}
+
+// RestoreOrderPoints gives back the points spent on an order that failed or was cancelled.
+func (lu *loyaltyUseCase) RestoreOrderPoints(orderID int64) error {
+	return lu.loyaltyRepo.RestoreRedeemedPoints(orderID, time.Now().Add(lu.config.ExpiryPeriod))
+}
+
+func (lu *loyaltyUseCase) tierPoints(userID int64) (int, error) {
+	points, err := lu.loyaltyRepo.GetEarnedSince(userID, time.Now().Add(-lu.config.TierPeriod))
+	if err != nil {
+		return 0, err
+	}
+	if points < 0 {
+		points = 0
+	}
+	return points, nil
+}
+
+// tierFor returns the highest tier reached with the points and the one after it.
+func (lu *loyaltyUseCase) tierFor(points int) (*domain.LoyaltyTier, *domain.LoyaltyTier) {
+	tier := &domain.LoyaltyTier{Multiplier: 1}
+	var next *domain.LoyaltyTier
+	for i := range lu.config.Tiers {
+		candidate := &lu.config.Tiers[i]
+		if points >= candidate.MinPoints {
+			tier = candidate
+			continue
+		}
+		next = candidate
+		break
+	}
+	return tier, next
+}
+
+func (lu *loyaltyUseCase) pointsValue(points int) float32 {
+	return roundMoney(float32(points) * lu.config.PointValue)
+}
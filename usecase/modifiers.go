@@ -0,0 +1,113 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"foodDelivery/domain"
+)
+
+var (
+	ErrInvalidModifierGroup = errors.New("modifier groups need a name, options and min selections not above max selections")
+	ErrUnknownModifier      = errors.New("modifier option is not offered on this food")
+	ErrModifierSelection    = errors.New("invalid modifier selection")
+	ErrNoteTooLong          = errors.New("item note must be at most 255 characters")
+)
+
+const maxItemNoteLength = 255
+
+func validateModifierGroups(groups []*domain.ModifierGroup) error {
+	for _, group := range groups {
+		if group.Name == "" || len(group.Options) == 0 || group.MinSelections < 0 || group.MaxSelections < 0 {
+			return ErrInvalidModifierGroup
+		}
+		if group.MaxSelections > 0 && group.MinSelections > group.MaxSelections {
+			return ErrInvalidModifierGroup
+		}
+		for _, option := range group.Options {
+			if option.Name == "" {
+				return ErrInvalidModifierGroup
+			}
+		}
+	}
+	return nil
+}
+
+// resolveModifiers checks the chosen options of a line against the modifier groups of
+// its food and fills in their names and price deltas.
+func resolveModifiers(groups []*domain.ModifierGroup, selected []*domain.OrderItemModifier, note string) error {
+	if len(note) > maxItemNoteLength {
+		return ErrNoteTooLong
+	}
+
+	options := make(map[int64]*domain.ModifierOption)
+	optionGroups := make(map[int64]*domain.ModifierGroup)
+	for _, group := range groups {
+		for _, option := range group.Options {
+			options[option.ID] = option
+			optionGroups[option.ID] = group
+		}
+	}
+
+	counts := make(map[int64]int)
+	seen := make(map[int64]bool)
+	for _, modifier := range selected {
+		option, found := options[modifier.ModifierOptionID]
+		if !found {
+			return ErrUnknownModifier
+		}
+		if seen[option.ID] {
+			return fmt.Errorf("%w: %s is selected twice", ErrModifierSelection, option.Name)
+		}
+		seen[option.ID] = true
+
+		group := optionGroups[option.ID]
+		counts[group.ID]++
+		modifier.GroupName = group.Name
+		modifier.Name = option.Name
+		modifier.PriceDelta = option.PriceDelta
+	}
+
+	for _, group := range groups {
+		count := counts[group.ID]
+		minimum := group.MinSelections
+		if group.Required && minimum < 1 {
+			minimum = 1
+		}
+		if count == 0 && !group.Required {
+			continue
+		}
+		if count < minimum {
+			return fmt.Errorf("%w: choose at least %d of %s", ErrModifierSelection, minimum, group.Name)
+		}
+		if group.MaxSelections > 0 && count > group.MaxSelections {
+			return fmt.Errorf("%w: choose at most %d of %s", ErrModifierSelection, group.MaxSelections, group.Name)
+		}
+	}
+	return nil
+}
+
+// modifiersTotal is the price added to one unit of a line by its modifiers.
+func modifiersTotal(modifiers []*domain.OrderItemModifier) float32 {
+	var total float32
+	for _, modifier := range modifiers {
+		total += modifier.PriceDelta
+	}
+	return total
+}
+
+// sameModifiers reports whether two lines have the same chosen options.
+func sameModifiers(a, b []*domain.OrderItemModifier) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	chosen := make(map[int64]bool)
+	for _, modifier := range a {
+		chosen[modifier.ModifierOptionID] = true
+	}
+	for _, modifier := range b {
+		if !chosen[modifier.ModifierOptionID] {
+			return false
+		}
+	}
+	return true
+}
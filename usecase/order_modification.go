@@ -0,0 +1,233 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"foodDelivery/domain"
+	"foodDelivery/geocoding"
+	"foodDelivery/repository"
+	"log"
+)
+
+var (
+	ErrOrderNotModifiable = errors.New("order can only be changed until the supplier accepts it")
+	ErrNothingToModify    = errors.New("modification changes nothing")
+	ErrAddressNotNeeded   = errors.New("pickup and dine-in orders have no delivery address")
+)
+
+// ModifyOrder changes the lines or the address of an order of the user that is scheduled or
+// pending and not accepted by the supplier yet. The order is priced again at today's prices
+// with its coupon and loyalty points. When the total goes up, the difference is authorized on
+// the card of the order, or paid from the wallet for orders paid with the wallet only. When it
+// goes down, the card is captured for less on delivery and the wallet part above the new total
+// is credited back.
+func (ou *orderUseCase) ModifyOrder(userID int64, orderID int64, modification *domain.OrderModification) (*domain.Order, error) {
+	order, err := ou.orderRepository.GetOrderWithItems(orderID)
+	if err != nil {
+		return nil, err
+	}
+	if order.UserID != userID {
+		return nil, repository.ErrOrderNotFound
+	}
+	if (order.Status != domain.OrderStatusPending && order.Status != domain.OrderStatusScheduled) || order.ConfirmedAt != nil {
+		return nil, ErrOrderNotModifiable
+	}
+	if modification.Items == nil && (modification.AddressID == 0 || modification.AddressID == order.AddressID) {
+		return nil, ErrNothingToModify
+	}
+	if modification.AddressID != 0 && order.FulfilmentType != domain.FulfilmentTypeDelivery {
+		return nil, ErrAddressNotNeeded
+	}
+
+	entry := &domain.OrderHistoryEntry{
+		PreviousAddressID: order.AddressID,
+		AddressID:         order.AddressID,
+		PreviousTotal:     order.Price,
+	}
+	previousItems := *order.Items
+	if modification.Items != nil {
+		if len(*modification.Items) == 0 {
+			return nil, repository.ErrItemsNotFound
+		}
+		items := make([]domain.OrderItem, 0, len(*modification.Items))
+		for _, item := range *modification.Items {
+			items = append(items, domain.OrderItem{
+				FoodID:    item.FoodID,
+				Quantity:  item.Quantity,
+				Note:      item.Note,
+				Modifiers: item.Modifiers,
+			})
+		}
+		order.Items = &items
+	}
+	if modification.AddressID != 0 {
+		order.AddressID = modification.AddressID
+		entry.AddressID = modification.AddressID
+	}
+
+	supplier, err := ou.supplierRepository.GetSupplierByID(order.SupplierID)
+	if err != nil {
+		return nil, err
+	}
+	var address *domain.Address
+	if order.FulfilmentType == domain.FulfilmentTypeDelivery {
+		address, err = ou.matchDeliveryZone(order, supplier)
+		if err != nil {
+			return nil, err
+		}
+		if address.UserID != order.UserID {
+			return nil, repository.ErrAddressNotFound
+		}
+	}
+	breakdown, err := ou.repriceOrder(order, supplier, address)
+	if err != nil {
+		return nil, err
+	}
+	err = checkOrderRules(order, supplier, breakdown.Subtotal)
+	if err != nil {
+		return nil, err
+	}
+
+	entry.Changes = describeItemChanges(previousItems, *order.Items)
+	if entry.AddressID != entry.PreviousAddressID {
+		entry.Changes = append(entry.Changes, "Delivery address changed")
+	}
+	if len(entry.Changes) == 0 {
+		return nil, ErrNothingToModify
+	}
+	order.Breakdown = breakdown
+	order.Price = breakdown.Total
+	entry.Total = order.Price
+
+	var walletAdjustment float32
+	var extra *domain.Payment
+	difference := roundMoney(order.Price - entry.PreviousTotal)
+	switch {
+	case difference < 0 && order.WalletAmount > order.Price:
+		walletAdjustment = roundMoney(order.WalletAmount - order.Price)
+		order.WalletAmount = order.Price
+	case difference > 0:
+		payments, err := ou.paymentUseCase.GetOrderPayments(orderID)
+		if err != nil {
+			return nil, err
+		}
+		if len(payments) == 0 {
+			walletAdjustment = -difference
+			order.WalletAmount = roundMoney(order.WalletAmount + difference)
+			break
+		}
+		extra, err = ou.paymentUseCase.AuthorizeExtra(orderID, difference)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = ou.orderRepository.ModifyOrder(order, entry, walletAdjustment)
+	if err != nil {
+		if extra != nil {
+			releaseErr := ou.paymentUseCase.ReleasePayment(extra.ID)
+			if releaseErr != nil {
+				log.Printf("Failed to release payment %d of order %d: %v", extra.ID, orderID, releaseErr)
+			}
+		}
+		return nil, err
+	}
+	return ou.orderRepository.GetOrderWithItems(orderID)
+}
+
+func (ou *orderUseCase) GetOrderHistory(userID int64, orderID int64) ([]*domain.OrderHistoryEntry, error) {
+	order, err := ou.orderRepository.GetOrderWithItems(orderID)
+	if err != nil {
+		return nil, err
+	}
+	if order.UserID != userID {
+		return nil, repository.ErrOrderNotFound
+	}
+	return ou.orderRepository.GetOrderHistory(orderID)
+}
+
+// repriceOrder prices a placed order again. Its coupon and loyalty points are already
+// redeemed, so only the rules depending on the price are checked again. Pickup and dine-in
+// orders have no address.
+func (ou *orderUseCase) repriceOrder(order *domain.Order, supplier *domain.Supplier, address *domain.Address) (*domain.PriceBreakdown, error) {
+	err := ou.resolveItems(order)
+	if err != nil {
+		return nil, err
+	}
+	request := &PricingRequest{
+		Supplier: supplier,
+		Items:    order.Items,
+		Tips:     order.Tips,
+	}
+	if address != nil && address.Location != nil && supplier.Location != nil {
+		request.DistanceKm = geocoding.DistanceKm(*supplier.Location, *address.Location)
+	}
+	tier, err := ou.loyaltyUseCase.GetTier(order.UserID)
+	if err != nil {
+		return nil, err
+	}
+	request.FreeDelivery = tier.FreeDelivery || order.FulfilmentType != domain.FulfilmentTypeDelivery
+	if order.CouponCode != "" {
+		request.Coupon, err = ou.couponRepository.GetCouponByCode(order.CouponCode)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var pointsValue float32
+	if order.LoyaltyPoints > 0 {
+		discount := ou.loyaltyUseCase.PointsDiscount(order.LoyaltyPoints)
+		pointsValue = discount.Amount
+		request.Discounts = append(request.Discounts, discount)
+	}
+
+	breakdown, err := ou.pricingEngine.Calculate(request)
+	if err != nil {
+		return nil, err
+	}
+	order.CouponDiscount = 0
+	for _, discount := range breakdown.Discounts {
+		if discount.Code == domain.DiscountCodeLoyalty && discount.Amount < pointsValue {
+			return nil, ErrTooManyPoints
+		}
+		if request.Coupon != nil && discount.Code == request.Coupon.Code {
+			order.CouponDiscount += discount.Amount
+		}
+	}
+	if request.Coupon != nil {
+		err = checkCouponDiscount(request.Coupon, breakdown)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return breakdown, nil
+}
+
+// describeItemChanges lists the lines added, removed or with another quantity. Lines are
+// the same when they have the same food, modifiers and note.
+func describeItemChanges(previous []domain.OrderItem, items []domain.OrderItem) []string {
+	changes := make([]string, 0)
+	matched := make([]bool, len(previous))
+	for _, item := range items {
+		found := false
+		for i, old := range previous {
+			if matched[i] || old.FoodID != item.FoodID || old.Note != item.Note || !sameModifiers(old.Modifiers, item.Modifiers) {
+				continue
+			}
+			matched[i] = true
+			found = true
+			if old.Quantity != item.Quantity {
+				changes = append(changes, fmt.Sprintf("%s: quantity %d to %d", item.FoodName, old.Quantity, item.Quantity))
+			}
+			break
+		}
+		if !found {
+			changes = append(changes, fmt.Sprintf("Added %d x %s", item.Quantity, item.FoodName))
+		}
+	}
+	for i, old := range previous {
+		if !matched[i] {
+			changes = append(changes, fmt.Sprintf("Removed %d x %s", old.Quantity, old.FoodName))
+		}
+	}
+	return changes
+}
@@ -0,0 +1,44 @@
+package usecase
+
+import (
+	"foodDelivery/repository"
+	"log"
+	"time"
+)
+
+// OrderScheduler periodically releases scheduled orders to the supplier queue
+// once their preparation has to start.
+type OrderScheduler struct {
+	orderRepository repository.OrderRepository
+	interval        time.Duration
+	stop            func()
+}
+
+func NewOrderScheduler(orderRepository repository.OrderRepository, interval time.Duration) *OrderScheduler {
+	return &OrderScheduler{
+		orderRepository: orderRepository,
+		interval:        interval,
+	}
+}
+
+// Start runs the scheduler in the background until Stop is called.
+func (sc *OrderScheduler) Start() {
+	sc.stop = runEvery(sc.interval, sc.releaseDueOrders)
+}
+
+func (sc *OrderScheduler) Stop() {
+	if sc.stop != nil {
+		sc.stop()
+	}
+}
+
+func (sc *OrderScheduler) releaseDueOrders() {
+	orderIDs, err := sc.orderRepository.ReleaseScheduledOrders(time.Now())
+	if err != nil {
+		log.Printf("Failed to release scheduled orders: %v", err)
+		return
+	}
+	if len(orderIDs) > 0 {
+		log.Printf("Released %d scheduled orders: %v", len(orderIDs), orderIDs)
+	}
+}
@@ -1,35 +1,127 @@
 package usecase
 
 import (
+	"errors"
+	"fmt"
 	"foodDelivery/domain"
+	"foodDelivery/geocoding"
 	"foodDelivery/repository"
+	"strings"
+	"time"
 )
 
+var (
+	ErrDeliveryTimeTooSoon = errors.New("requested delivery time is earlier than the supplier can deliver")
+	ErrDeliveryTimeTooFar  = errors.New("orders can be scheduled at most 7 days ahead")
+	ErrBelowMinimumOrder   = errors.New("order subtotal is below the supplier minimum")
+	ErrTooManyItems        = errors.New("order has more items than the supplier accepts")
+	ErrFoodQuantityLimit   = errors.New("food quantity is above the supplier limit")
+
+	ErrInvalidFulfilmentType = errors.New("fulfilment type must be delivery, pickup or dine_in")
+	ErrTableNumberRequired   = errors.New("dine-in orders need a table number of at most 10 characters")
+)
+
+// maxScheduleAhead is how far in the future an order can be scheduled.
+const maxScheduleAhead = 7 * 24 * time.Hour
+
+const maxTableNumber = 10
+
 type OrderUseCase interface {
 	SubmitOrder(order *domain.Order) error
 	GetUserOrders(userId int64) (*[]domain.Order, error)
-	GetOrderWithItems(orderID int64) (*domain.Order, error)
+	GetOrderWithItems(userID int64, orderID int64) (*domain.Order, error)
+	GetSupplierOrders(supplierID int64, status string) ([]*domain.Order, error)
+	QuoteOrder(order *domain.Order) (*domain.PriceBreakdown, error)
+	ModifyOrder(userID int64, orderID int64, modification *domain.OrderModification) (*domain.Order, error)
+	GetOrderHistory(userID int64, orderID int64) ([]*domain.OrderHistoryEntry, error)
 }
 
 type orderUseCase struct {
-	orderRepository repository.OrderRepository
+	orderRepository    repository.OrderRepository
+	supplierRepository repository.SupplierRepository
+	scheduleRepository repository.SupplierScheduleRepository
+	modifierRepository repository.ModifierRepository
+	addressRepository  repository.AddressRepository
+	zoneRepository     repository.DeliveryZoneRepository
+	couponRepository   repository.CouponRepository
+	loyaltyUseCase     LoyaltyUseCase
+	pricingEngine      PricingEngine
+	paymentUseCase     PaymentUseCase
 }
 
-func NewOrderUseCase(orderRepository repository.OrderRepository) OrderUseCase {
+func NewOrderUseCase(orderRepository repository.OrderRepository, supplierRepository repository.SupplierRepository,
+	scheduleRepository repository.SupplierScheduleRepository, modifierRepository repository.ModifierRepository,
+	addressRepository repository.AddressRepository, zoneRepository repository.DeliveryZoneRepository,
+	couponRepository repository.CouponRepository, loyaltyUseCase LoyaltyUseCase, pricingEngine PricingEngine,
+	paymentUseCase PaymentUseCase) OrderUseCase {
 	return &orderUseCase{
-		orderRepository: orderRepository,
+		orderRepository:    orderRepository,
+		supplierRepository: supplierRepository,
+		scheduleRepository: scheduleRepository,
+		modifierRepository: modifierRepository,
+		addressRepository:  addressRepository,
+		zoneRepository:     zoneRepository,
+		couponRepository:   couponRepository,
+		loyaltyUseCase:     loyaltyUseCase,
+		pricingEngine:      pricingEngine,
+		paymentUseCase:     paymentUseCase,
 	}
 }
 
 func (ou *orderUseCase) SubmitOrder(order *domain.Order) error {
-	if len(*order.Items) == 0 {
+	if order.Items == nil || len(*order.Items) == 0 {
 		return repository.ErrItemsNotFound
 	}
-	err := ou.orderRepository.SubmitOrder(order)
+	err := checkSubstitutionPreference(order)
 	if err != nil {
 		return err
 	}
-	return nil
+	err = checkFulfilment(order)
+	if err != nil {
+		return err
+	}
+
+	supplier, err := loadSupplierSchedule(ou.supplierRepository, ou.scheduleRepository, order.SupplierID)
+	if err != nil {
+		return err
+	}
+	var address *domain.Address
+	if order.FulfilmentType == domain.FulfilmentTypeDelivery {
+		address, err = ou.matchDeliveryZone(order, supplier)
+		if err != nil {
+			return err
+		}
+	}
+	err = scheduleOrder(order, supplier, time.Now())
+	if err != nil {
+		return err
+	}
+
+	breakdown, err := ou.priceOrder(order, supplier, address)
+	if err != nil {
+		return err
+	}
+	err = checkOrderRules(order, supplier, breakdown.Subtotal)
+	if err != nil {
+		return err
+	}
+	order.Breakdown = breakdown
+	order.Price = breakdown.Total
+	order.WalletAmount = roundMoney(order.WalletAmount)
+	if order.WalletAmount < 0 || order.WalletAmount > order.Price {
+		return ErrInvalidWalletAmount
+	}
+	if cardAmount(order) > 0 && order.PaymentMethod == "" {
+		return ErrPaymentMethodRequired
+	}
+
+	// The order only reaches the supplier once its payment is authorized.
+	order.Status = domain.OrderStatusAwaitingPayment
+	err = ou.orderRepository.SubmitOrder(order)
+	if err != nil {
+		return err
+	}
+	return ou.paymentUseCase.AuthorizeOrder(order)
 }
 
 func (ou *orderUseCase) GetUserOrders(userId int64) (*[]domain.Order, error) {
@@ -40,10 +132,236 @@ func (ou *orderUseCase) GetUserOrders(userId int64) (*[]domain.Order, error) {
 	return orders, nil
 }
 
-func (ou *orderUseCase) GetOrderWithItems(orderID int64) (*domain.Order, error) {
+// GetOrderWithItems returns an order with its lines. Only the customer who placed it sees
+// the delivery pin and the pickup code.
+func (ou *orderUseCase) GetOrderWithItems(userID int64, orderID int64) (*domain.Order, error) {
 	order, err := ou.orderRepository.GetOrderWithItems(orderID)
 	if err != nil {
 		return nil, err
 	}
+	if order.UserID != userID {
+		hideDeliveryPins([]*domain.Order{order})
+	}
 	return order, nil
 }
+
+// QuoteOrder calculates the totals of an order without placing it.
+func (ou *orderUseCase) QuoteOrder(order *domain.Order) (*domain.PriceBreakdown, error) {
+	if order.Items == nil || len(*order.Items) == 0 {
+		return nil, repository.ErrItemsNotFound
+	}
+	err := checkFulfilment(order)
+	if err != nil {
+		return nil, err
+	}
+	supplier, err := ou.supplierRepository.GetSupplierByID(order.SupplierID)
+	if err != nil {
+		return nil, err
+	}
+	// Without an address the quote uses the supplier's default delivery fee.
+	var address *domain.Address
+	if order.FulfilmentType == domain.FulfilmentTypeDelivery && order.AddressID != 0 {
+		address, err = ou.matchDeliveryZone(order, supplier)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ou.priceOrder(order, supplier, address)
+}
+
+// matchDeliveryZone checks that the supplier delivers to the order address.
+func (ou *orderUseCase) matchDeliveryZone(order *domain.Order, supplier *domain.Supplier) (*domain.Address, error) {
+	address, err := ou.addressRepository.GetAddressByID(order.AddressID)
+	if err != nil {
+		return nil, err
+	}
+	err = applyDeliveryZone(ou.zoneRepository, supplier, address)
+	if err != nil {
+		return nil, err
+	}
+	return address, nil
+}
+
+// priceOrder validates the chosen modifiers of every line, the coupon and the loyalty points,
+// if any, and runs the pricing engine with the benefits of the customer's loyalty tier. The
+// address is optional and only used for the delivery distance. Pickup and dine-in orders
+// have no delivery fee.
+func (ou *orderUseCase) priceOrder(order *domain.Order, supplier *domain.Supplier, address *domain.Address) (*domain.PriceBreakdown, error) {
+	err := ou.resolveItems(order)
+	if err != nil {
+		return nil, err
+	}
+	err = validateTips(order.Tips)
+	if err != nil {
+		return nil, err
+	}
+	order.Coupon = nil
+	if order.CouponCode != "" {
+		order.Coupon, err = resolveCoupon(ou.couponRepository, order, time.Now())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	request := &PricingRequest{
+		Supplier: supplier,
+		Items:    order.Items,
+		Tips:     order.Tips,
+		Coupon:   order.Coupon,
+	}
+	if address != nil && address.Location != nil && supplier.Location != nil {
+		request.DistanceKm = geocoding.DistanceKm(*supplier.Location, *address.Location)
+	}
+	if order.UserID != 0 {
+		tier, err := ou.loyaltyUseCase.GetTier(order.UserID)
+		if err != nil {
+			return nil, err
+		}
+		request.FreeDelivery = tier.FreeDelivery
+	}
+	if order.FulfilmentType != domain.FulfilmentTypeDelivery {
+		request.FreeDelivery = true
+	}
+	var pointsValue float32
+	if order.LoyaltyPoints != 0 {
+		discount, err := ou.loyaltyUseCase.RedemptionDiscount(order.UserID, order.LoyaltyPoints)
+		if err != nil {
+			return nil, err
+		}
+		pointsValue = discount.Amount
+		request.Discounts = append(request.Discounts, discount)
+	}
+
+	breakdown, err := ou.pricingEngine.Calculate(request)
+	if err != nil {
+		return nil, err
+	}
+	err = checkTipAmounts(order.Tips, breakdown.Subtotal)
+	if err != nil {
+		return nil, err
+	}
+	// Points are only spent for their full value.
+	for _, discount := range breakdown.Discounts {
+		if discount.Code == domain.DiscountCodeLoyalty && discount.Amount < pointsValue {
+			return nil, ErrTooManyPoints
+		}
+	}
+	if order.Coupon != nil {
+		err = checkCouponDiscount(order.Coupon, breakdown)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return breakdown, nil
+}
+
+// resolveItems checks the quantity and the chosen modifiers of every line of an order.
+func (ou *orderUseCase) resolveItems(order *domain.Order) error {
+	for i := range *order.Items {
+		item := &(*order.Items)[i]
+		if item.Quantity <= 0 {
+			return ErrInvalidQuantity
+		}
+		groups, err := ou.modifierRepository.GetGroupsByFoodID(item.FoodID)
+		if err != nil {
+			return err
+		}
+		err = resolveModifiers(groups, item.Modifiers, item.Note)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ou *orderUseCase) GetSupplierOrders(supplierID int64, status string) ([]*domain.Order, error) {
+	orders, err := ou.orderRepository.GetSupplierOrders(supplierID, status)
+	if err != nil {
+		return nil, err
+	}
+	hideDeliveryPins(orders)
+	return orders, nil
+}
+
+// checkFulfilment makes delivery the default fulfilment type and drops what the type does
+// not use: the address of pickup and dine-in orders and the table of the others.
+func checkFulfilment(order *domain.Order) error {
+	if order.FulfilmentType == "" {
+		order.FulfilmentType = domain.FulfilmentTypeDelivery
+	}
+	order.TableNumber = strings.TrimSpace(order.TableNumber)
+	switch order.FulfilmentType {
+	case domain.FulfilmentTypeDelivery:
+		order.TableNumber = ""
+	case domain.FulfilmentTypePickup:
+		order.AddressID = 0
+		order.TableNumber = ""
+	case domain.FulfilmentTypeDineIn:
+		order.AddressID = 0
+		if order.TableNumber == "" || len(order.TableNumber) > maxTableNumber {
+			return ErrTableNumberRequired
+		}
+	default:
+		return ErrInvalidFulfilmentType
+	}
+	return checkTipRecipients(order, order.Tips)
+}
+
+// checkOrderRules enforces the ordering rules of a supplier. A zero limit means no limit.
+func checkOrderRules(order *domain.Order, supplier *domain.Supplier, subtotal float32) error {
+	if supplier.MinOrderSubtotal > 0 && subtotal < supplier.MinOrderSubtotal {
+		return fmt.Errorf("%w: minimum is %.2f, subtotal is %.2f", ErrBelowMinimumOrder, supplier.MinOrderSubtotal, subtotal)
+	}
+
+	totalItems := 0
+	foodQuantities := make(map[int64]int)
+	for _, item := range *order.Items {
+		totalItems += int(item.Quantity)
+		foodQuantities[item.FoodID] += int(item.Quantity)
+	}
+	if supplier.MaxItems > 0 && totalItems > supplier.MaxItems {
+		return fmt.Errorf("%w: at most %d items, order has %d", ErrTooManyItems, supplier.MaxItems, totalItems)
+	}
+	if supplier.MaxFoodQuantity > 0 {
+		for _, item := range *order.Items {
+			if foodQuantities[item.FoodID] > supplier.MaxFoodQuantity {
+				return fmt.Errorf("%w: at most %d of %s", ErrFoodQuantityLimit, supplier.MaxFoodQuantity, item.FoodName)
+			}
+		}
+	}
+	return nil
+}
+
+// scheduleOrder sets the status of a new order. Immediate orders need the supplier to be open now,
+// scheduled ones need it to be open when preparation has to start for the requested delivery time.
+func scheduleOrder(order *domain.Order, supplier *domain.Supplier, now time.Time) error {
+	if order.RequestedDeliveryAt == nil {
+		if !IsSupplierOpenAt(supplier, now) {
+			return ErrSupplierClosed
+		}
+		order.Status = domain.OrderStatusPending
+		order.ReleaseAt = nil
+		return nil
+	}
+
+	// Pickup and dine-in orders are requested for when they are collected or served.
+	leadTime := supplierLeadTime(supplier)
+	if order.FulfilmentType != domain.FulfilmentTypeDelivery {
+		leadTime = time.Duration(supplier.PrepTime) * time.Minute
+	}
+	deliveryAt := *order.RequestedDeliveryAt
+	releaseAt := deliveryAt.Add(-leadTime)
+	if releaseAt.Before(now) {
+		return ErrDeliveryTimeTooSoon
+	}
+	if deliveryAt.Sub(now) > maxScheduleAhead {
+		return ErrDeliveryTimeTooFar
+	}
+	if !IsSupplierOpenAt(supplier, releaseAt) {
+		return ErrSupplierClosed
+	}
+
+	order.Status = domain.OrderStatusScheduled
+	order.ReleaseAt = &releaseAt
+	return nil
+}
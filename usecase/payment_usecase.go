@@ -0,0 +1,525 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"foodDelivery/domain"
+	"foodDelivery/payment"
+	"foodDelivery/repository"
+	"log"
+)
+
+var (
+	ErrPaymentMethodRequired = errors.New("payment method is required")
+	ErrPaymentDeclined       = errors.New("payment was declined")
+	ErrPaymentFailed         = errors.New("payment could not be processed")
+	ErrInvalidRefund         = errors.New("refund must be positive and at most the captured amount not refunded yet")
+	ErrPaymentNotVoidable    = errors.New("only open payments of orders not picked up yet can be voided")
+	ErrInvalidWalletAmount   = errors.New("wallet amount must be between 0 and the order total")
+)
+
+// cancellableStatuses are the order statuses in which voiding the payment cancels the order.
+var cancellableStatuses = []string{domain.OrderStatusAwaitingPayment, domain.OrderStatusScheduled, domain.OrderStatusPending,
+	domain.OrderStatusReady, domain.OrderStatusAssigned, domain.OrderStatusAccepted}
+
+type PaymentUseCase interface {
+	AuthorizeOrder(order *domain.Order) error
+	CaptureOrder(orderID int64) error
+	ChargeOrder(orderID int64, amount float32) (*domain.Payment, error)
+	CancelOrder(orderID int64) error
+	AuthorizeExtra(orderID int64, amount float32) (*domain.Payment, error)
+	ReleasePayment(paymentID int64) error
+	RefundPayment(paymentID int64, request *domain.RefundRequest) (*domain.Payment, error)
+	VoidPayment(paymentID int64) (*domain.Payment, error)
+	GetOrderPayments(orderID int64) ([]*domain.Payment, error)
+	HandleWebhook(payload []byte, signature string) error
+}
+
+type paymentUseCase struct {
+	paymentRepo repository.PaymentRepository
+	orderRepo   repository.OrderRepository
+	walletRepo  repository.WalletRepository
+	couponRepo  repository.CouponRepository
+	loyalty     LoyaltyUseCase
+	gateway     payment.Gateway
+}
+
+func NewPaymentUseCase(paymentRepo repository.PaymentRepository, orderRepo repository.OrderRepository,
+	walletRepo repository.WalletRepository, couponRepo repository.CouponRepository, loyalty LoyaltyUseCase,
+	gateway payment.Gateway) PaymentUseCase {
+	return &paymentUseCase{
+		paymentRepo: paymentRepo,
+		orderRepo:   orderRepo,
+		walletRepo:  walletRepo,
+		couponRepo:  couponRepo,
+		loyalty:     loyalty,
+		gateway:     gateway,
+	}
+}
+
+// AuthorizeOrder reserves the part of an order placed as awaiting_payment that the wallet
+// does not cover. An authorized order goes on to the supplier, a pending one waits for the
+// gateway webhook and a failed one gets its wallet part back.
+func (pu *paymentUseCase) AuthorizeOrder(order *domain.Order) error {
+	cardAmount := cardAmount(order)
+	if cardAmount <= 0 {
+		err := pu.orderRepo.UpdateOrderStatus(order.ID, 0, []string{domain.OrderStatusAwaitingPayment}, releasedStatus(order))
+		if err != nil {
+			return err
+		}
+		order.Status = releasedStatus(order)
+		return nil
+	}
+
+	record := &domain.Payment{
+		OrderID:  order.ID,
+		Provider: pu.gateway.Name(),
+		Method:   order.PaymentMethod,
+		Status:   domain.PaymentStatusPending,
+		Amount:   cardAmount,
+	}
+	err := pu.paymentRepo.CreatePayment(record)
+	if err != nil {
+		return err
+	}
+
+	result, gatewayErr := pu.gateway.Authorize(payment.AuthorizeRequest{OrderID: order.ID, Amount: record.Amount, Method: record.Method})
+	orderTo := ""
+	switch {
+	case gatewayErr != nil:
+		record.Status = domain.PaymentStatusFailed
+		record.Message = gatewayErr.Error()
+		orderTo = domain.OrderStatusPaymentFailed
+	case result.Status == payment.StatusAuthorized:
+		record.Status = domain.PaymentStatusAuthorized
+		orderTo = releasedStatus(order)
+	case result.Status == payment.StatusPending:
+	default:
+		record.Status = domain.PaymentStatusFailed
+		orderTo = domain.OrderStatusPaymentFailed
+	}
+	if result != nil {
+		record.Reference = result.Reference
+		record.Message = result.Message
+	}
+
+	err = pu.paymentRepo.UpdatePayment(record, []string{domain.OrderStatusAwaitingPayment}, orderTo)
+	if err != nil {
+		return err
+	}
+	if orderTo != "" {
+		order.Status = orderTo
+	}
+	if orderTo == domain.OrderStatusPaymentFailed {
+		err = pu.releaseOrder(order.ID)
+		if err != nil {
+			return err
+		}
+	}
+	if gatewayErr != nil {
+		return fmt.Errorf("%w: %v", ErrPaymentFailed, gatewayErr)
+	}
+	if record.Status == domain.PaymentStatusFailed {
+		return fmt.Errorf("%w: %s", ErrPaymentDeclined, record.Message)
+	}
+	return nil
+}
+
+// CaptureOrder collects the authorized payments of a delivered order, never more than its total.
+func (pu *paymentUseCase) CaptureOrder(orderID int64) error {
+	order, err := pu.orderRepo.GetOrderWithItems(orderID)
+	if err != nil {
+		return err
+	}
+	payments, err := pu.paymentRepo.GetOrderPayments(orderID)
+	if err != nil {
+		return err
+	}
+
+	remaining := cardAmount(order)
+	for _, record := range payments {
+		if record.Status != domain.PaymentStatusAuthorized {
+			remaining -= record.CapturedAmount - record.RefundedAmount
+			continue
+		}
+		amount := record.Amount
+		if amount > remaining {
+			amount = roundMoney(remaining)
+		}
+		if amount <= 0 {
+			// Nothing is left to collect, release the reservation.
+			_, err = pu.void(record, nil, "")
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		result, err := pu.gateway.Capture(record.Reference, amount)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrPaymentFailed, err)
+		}
+		if result.Status != payment.StatusCaptured {
+			return fmt.Errorf("%w: %s", ErrPaymentFailed, result.Message)
+		}
+		record.Status = domain.PaymentStatusCaptured
+		record.CapturedAmount = amount
+		err = pu.paymentRepo.UpdatePayment(record, nil, "")
+		if err != nil {
+			return err
+		}
+		remaining -= amount
+	}
+	return nil
+}
+
+// ChargeOrder collects an extra amount for an order, such as a tip given after delivery,
+// with the payment method the order was paid with.
+func (pu *paymentUseCase) ChargeOrder(orderID int64, amount float32) (*domain.Payment, error) {
+	payments, err := pu.paymentRepo.GetOrderPayments(orderID)
+	if err != nil {
+		return nil, err
+	}
+	if len(payments) == 0 {
+		return nil, repository.ErrPaymentNotFound
+	}
+
+	record := &domain.Payment{
+		OrderID:  orderID,
+		Provider: pu.gateway.Name(),
+		Method:   payments[0].Method,
+		Status:   domain.PaymentStatusPending,
+		Amount:   amount,
+	}
+	err = pu.paymentRepo.CreatePayment(record)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := pu.gateway.Authorize(payment.AuthorizeRequest{OrderID: orderID, Amount: amount, Method: record.Method})
+	if err == nil && result.Status == payment.StatusAuthorized {
+		record.Reference = result.Reference
+		result, err = pu.gateway.Capture(result.Reference, amount)
+		// Release the reservation on the card when it can not be collected.
+		if err != nil || result.Status != payment.StatusCaptured {
+			_, voidErr := pu.gateway.Void(record.Reference)
+			if voidErr != nil {
+				log.Printf("Failed to void authorization %s of order %d: %v", record.Reference, orderID, voidErr)
+			}
+		}
+	} else if err == nil && result.Status == payment.StatusPending {
+		// A charge is only accepted when it goes through now, the open authorization must
+		// not reserve the card later.
+		result.Message = "authorization not confirmed"
+		_, voidErr := pu.gateway.Void(result.Reference)
+		if voidErr != nil {
+			log.Printf("Failed to void authorization %s of order %d: %v", result.Reference, orderID, voidErr)
+		}
+	}
+	switch {
+	case err != nil:
+		record.Status = domain.PaymentStatusFailed
+		record.Message = err.Error()
+	case result.Status == payment.StatusCaptured:
+		record.Status = domain.PaymentStatusCaptured
+		record.CapturedAmount = amount
+	default:
+		record.Status = domain.PaymentStatusFailed
+		record.Message = result.Message
+	}
+	if result != nil {
+		record.Reference = result.Reference
+	}
+
+	updateErr := pu.paymentRepo.UpdatePayment(record, nil, "")
+	if updateErr != nil {
+		return nil, updateErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPaymentFailed, err)
+	}
+	if record.Status == domain.PaymentStatusFailed {
+		return nil, fmt.Errorf("%w: %s", ErrPaymentDeclined, record.Message)
+	}
+	return record, nil
+}
+
+// AuthorizeExtra reserves an additional amount for an order whose total went up before
+// delivery, with the payment method the order was paid with. It is captured with the
+// rest of the order. Only an immediate authorization is accepted.
+func (pu *paymentUseCase) AuthorizeExtra(orderID int64, amount float32) (*domain.Payment, error) {
+	payments, err := pu.paymentRepo.GetOrderPayments(orderID)
+	if err != nil {
+		return nil, err
+	}
+	if len(payments) == 0 {
+		return nil, repository.ErrPaymentNotFound
+	}
+
+	record := &domain.Payment{
+		OrderID:  orderID,
+		Provider: pu.gateway.Name(),
+		Method:   payments[0].Method,
+		Status:   domain.PaymentStatusPending,
+		Amount:   amount,
+	}
+	err = pu.paymentRepo.CreatePayment(record)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := pu.gateway.Authorize(payment.AuthorizeRequest{OrderID: orderID, Amount: amount, Method: record.Method})
+	switch {
+	case err != nil:
+		record.Status = domain.PaymentStatusFailed
+		record.Message = err.Error()
+	case result.Status == payment.StatusAuthorized:
+		record.Status = domain.PaymentStatusAuthorized
+	default:
+		record.Status = domain.PaymentStatusFailed
+		record.Message = result.Message
+		if result.Status == payment.StatusPending {
+			record.Message = "authorization not confirmed"
+			_, _ = pu.gateway.Void(result.Reference)
+		}
+	}
+	if result != nil {
+		record.Reference = result.Reference
+	}
+
+	updateErr := pu.paymentRepo.UpdatePayment(record, nil, "")
+	if updateErr != nil {
+		return nil, updateErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPaymentFailed, err)
+	}
+	if record.Status == domain.PaymentStatusFailed {
+		return nil, fmt.Errorf("%w: %s", ErrPaymentDeclined, record.Message)
+	}
+	return record, nil
+}
+
+// ReleasePayment voids an open payment without changing its order.
+func (pu *paymentUseCase) ReleasePayment(paymentID int64) error {
+	record, err := pu.paymentRepo.GetPaymentByID(paymentID)
+	if err != nil {
+		return err
+	}
+	_, err = pu.void(record, nil, "")
+	return err
+}
+
+// RefundPayment gives back part or all of a captured payment, to the payment method or
+// as credit in the customer's wallet, and takes back the loyalty points of the refunded share.
+func (pu *paymentUseCase) RefundPayment(paymentID int64, request *domain.RefundRequest) (*domain.Payment, error) {
+	record, err := pu.paymentRepo.GetPaymentByID(paymentID)
+	if err != nil {
+		return nil, err
+	}
+	amount := roundMoney(request.Amount)
+	if record.Status != domain.PaymentStatusCaptured || amount <= 0 || amount > record.CapturedAmount-record.RefundedAmount {
+		return nil, ErrInvalidRefund
+	}
+	order, err := pu.orderRepo.GetOrderWithItems(record.OrderID)
+	if err != nil {
+		return nil, err
+	}
+
+	record, err = pu.paymentRepo.RefundPayment(paymentID, amount, request.ToWallet, func(record *domain.Payment) error {
+		result, err := pu.gateway.Refund(record.Reference, amount)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrPaymentFailed, err)
+		}
+		if result.Status != payment.StatusRefunded {
+			return fmt.Errorf("%w: %s", ErrPaymentFailed, result.Message)
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrRefundTooLarge) {
+			return nil, ErrInvalidRefund
+		}
+		return nil, err
+	}
+	// The money is already back with the customer, a failed reversal is left for support.
+	err = pu.loyalty.ReverseOrderPoints(order, amount)
+	if err != nil {
+		log.Printf("Failed to reverse loyalty points of order %d: %v", order.ID, err)
+	}
+	return record, nil
+}
+
+// VoidPayment cancels the order of an open payment when it is not picked up yet and voids
+// its open payments. On a cancelled order it only voids the payment, for the ones that
+// could not be voided when the order was cancelled.
+func (pu *paymentUseCase) VoidPayment(paymentID int64) (*domain.Payment, error) {
+	record, err := pu.paymentRepo.GetPaymentByID(paymentID)
+	if err != nil {
+		return nil, err
+	}
+	order, err := pu.orderRepo.GetOrderWithItems(record.OrderID)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status == domain.OrderStatusCancelled {
+		return pu.void(record, nil, "")
+	}
+	if record.Status != domain.PaymentStatusAuthorized && record.Status != domain.PaymentStatusPending {
+		return nil, ErrPaymentNotVoidable
+	}
+	err = pu.CancelOrder(order.ID)
+	if err != nil {
+		if errors.Is(err, repository.ErrInvalidStatusTransition) {
+			return nil, ErrPaymentNotVoidable
+		}
+		return nil, err
+	}
+	return pu.paymentRepo.GetPaymentByID(paymentID)
+}
+
+// CancelOrder cancels an order that is not picked up yet, gives back what it took and voids
+// its open payments. The order is cancelled first, so that it can not go on to be delivered
+// once its payments are gone.
+func (pu *paymentUseCase) CancelOrder(orderID int64) error {
+	err := pu.orderRepo.UpdateOrderStatus(orderID, 0, cancellableStatuses, domain.OrderStatusCancelled)
+	if err != nil {
+		return err
+	}
+	err = pu.releaseOrder(orderID)
+	if err != nil {
+		return err
+	}
+
+	payments, err := pu.paymentRepo.GetOrderPayments(orderID)
+	if err != nil {
+		return err
+	}
+	for _, record := range payments {
+		if record.Status == domain.PaymentStatusAuthorized || record.Status == domain.PaymentStatusPending {
+			_, err = pu.void(record, nil, "")
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (pu *paymentUseCase) void(record *domain.Payment, orderFrom []string, orderTo string) (*domain.Payment, error) {
+	if record.Status != domain.PaymentStatusAuthorized && record.Status != domain.PaymentStatusPending {
+		return nil, ErrPaymentNotVoidable
+	}
+	if record.Reference != "" {
+		result, err := pu.gateway.Void(record.Reference)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrPaymentFailed, err)
+		}
+		if result.Status != payment.StatusVoided {
+			return nil, fmt.Errorf("%w: %s", ErrPaymentFailed, result.Message)
+		}
+	}
+	record.Status = domain.PaymentStatusVoided
+	err := pu.paymentRepo.UpdatePayment(record, orderFrom, orderTo)
+	if err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+func (pu *paymentUseCase) GetOrderPayments(orderID int64) ([]*domain.Payment, error) {
+	_, err := pu.orderRepo.GetOrderWithItems(orderID)
+	if err != nil {
+		return nil, err
+	}
+	return pu.paymentRepo.GetOrderPayments(orderID)
+}
+
+// HandleWebhook verifies a gateway callback and applies it once. Events that do not fit
+// the current state of the payment, such as a repeated or late one, change nothing.
+// Captured and refunded events carry the total captured or refunded so far.
+func (pu *paymentUseCase) HandleWebhook(payload []byte, signature string) error {
+	event, err := pu.gateway.VerifyWebhook(payload, signature)
+	if err != nil {
+		return err
+	}
+	record, err := pu.paymentRepo.GetPaymentByReference(pu.gateway.Name(), event.Reference)
+	if err != nil {
+		return err
+	}
+
+	changed := true
+	var orderFrom []string
+	orderTo := ""
+	switch {
+	case event.Type == payment.EventAuthorized && record.Status == domain.PaymentStatusPending:
+		order, err := pu.orderRepo.GetOrderWithItems(record.OrderID)
+		if err != nil {
+			return err
+		}
+		record.Status = domain.PaymentStatusAuthorized
+		orderFrom = []string{domain.OrderStatusAwaitingPayment}
+		orderTo = releasedStatus(order)
+	case event.Type == payment.EventFailed && record.Status == domain.PaymentStatusPending:
+		record.Status = domain.PaymentStatusFailed
+		record.Message = event.Message
+		orderFrom = []string{domain.OrderStatusAwaitingPayment}
+		orderTo = domain.OrderStatusPaymentFailed
+	case event.Type == payment.EventCaptured && record.Status == domain.PaymentStatusAuthorized:
+		record.Status = domain.PaymentStatusCaptured
+		record.CapturedAmount = roundMoney(event.Amount)
+	case event.Type == payment.EventRefunded && record.Status == domain.PaymentStatusCaptured &&
+		event.Amount > record.RefundedAmount:
+		record.RefundedAmount = roundMoney(event.Amount)
+		if record.RefundedAmount >= record.CapturedAmount {
+			record.Status = domain.PaymentStatusRefunded
+		}
+	case event.Type == payment.EventVoided &&
+		(record.Status == domain.PaymentStatusAuthorized || record.Status == domain.PaymentStatusPending):
+		record.Status = domain.PaymentStatusVoided
+		orderFrom = cancellableStatuses
+		orderTo = domain.OrderStatusCancelled
+	default:
+		changed = false
+	}
+
+	if !changed {
+		record = nil
+	}
+	processed, err := pu.paymentRepo.RecordEvent(event.ID, record, orderFrom, orderTo)
+	if err != nil {
+		return err
+	}
+	if processed && (orderTo == domain.OrderStatusPaymentFailed || orderTo == domain.OrderStatusCancelled) {
+		return pu.releaseOrder(record.OrderID)
+	}
+	return nil
+}
+
+// releaseOrder gives back what an order that failed or was cancelled took: the wallet
+// part of its payment, its coupon use and its loyalty points.
+func (pu *paymentUseCase) releaseOrder(orderID int64) error {
+	err := pu.walletRepo.ReverseOrderPayment(orderID)
+	if err != nil {
+		return err
+	}
+	err = pu.couponRepo.ReleaseRedemption(orderID)
+	if err != nil {
+		return err
+	}
+	return pu.loyalty.RestoreOrderPoints(orderID)
+}
+
+// cardAmount is the part of the order total paid with the payment method.
+func cardAmount(order *domain.Order) float32 {
+	return roundMoney(order.Price - order.WalletAmount)
+}
+
+// releasedStatus is where an order goes once paid: the supplier queue, or the
+// scheduler for orders with a requested delivery time.
+func releasedStatus(order *domain.Order) string {
+	if order.ReleaseAt != nil {
+		return domain.OrderStatusScheduled
+	}
+	return domain.OrderStatusPending
+}
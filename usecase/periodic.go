@@ -0,0 +1,31 @@
+package usecase
+
+import (
+	"sync"
+	"time"
+)
+
+// runEvery calls fn right away and then at every interval in the background, until the
+// returned stop function is called. Calling stop more than once is safe.
+func runEvery(interval time.Duration, fn func()) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		fn()
+		for {
+			select {
+			case <-ticker.C:
+				fn()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
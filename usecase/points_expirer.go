@@ -0,0 +1,43 @@
+package usecase
+
+import (
+	"foodDelivery/repository"
+	"log"
+	"time"
+)
+
+// PointsExpirer periodically writes off the loyalty points that reached their expiry.
+type PointsExpirer struct {
+	loyaltyRepository repository.LoyaltyRepository
+	interval          time.Duration
+	stop              func()
+}
+
+func NewPointsExpirer(loyaltyRepository repository.LoyaltyRepository, interval time.Duration) *PointsExpirer {
+	return &PointsExpirer{
+		loyaltyRepository: loyaltyRepository,
+		interval:          interval,
+	}
+}
+
+// Start runs the expirer in the background until Stop is called.
+func (pe *PointsExpirer) Start() {
+	pe.stop = runEvery(pe.interval, pe.expirePoints)
+}
+
+func (pe *PointsExpirer) Stop() {
+	if pe.stop != nil {
+		pe.stop()
+	}
+}
+
+func (pe *PointsExpirer) expirePoints() {
+	expired, err := pe.loyaltyRepository.ExpirePoints(time.Now())
+	if err != nil {
+		log.Printf("Failed to expire loyalty points: %v", err)
+		return
+	}
+	if expired > 0 {
+		log.Printf("Expired the points of %d loyalty credits", expired)
+	}
+}
@@ -0,0 +1,198 @@
+package usecase
+
+import (
+	"errors"
+	"foodDelivery/domain"
+	"foodDelivery/repository"
+	"math"
+)
+
+var (
+	ErrInvalidTaxRate = errors.New("tax rate needs a name and a rate between 0 and 1")
+)
+
+// PricingConfig holds the platform wide fees.
+type PricingConfig struct {
+	ServiceFeeRate float32
+	MinServiceFee  float32
+	MaxServiceFee  float32
+}
+
+// PricingRequest is everything the total of an order depends on. FreeDelivery waives
+// the delivery fee whatever the supplier rules are. KeepPrices prices the items at the
+// unit price already on them, for orders changed after they were placed.
+type PricingRequest struct {
+	Supplier     *domain.Supplier
+	Items        *[]domain.OrderItem
+	DistanceKm   float64
+	Discounts    []*domain.Discount
+	Tips         []*domain.Tip
+	Coupon       *domain.Coupon
+	FreeDelivery bool
+	KeepPrices   bool
+}
+
+type PricingEngine interface {
+	Calculate(request *PricingRequest) (*domain.PriceBreakdown, error)
+}
+
+type pricingEngine struct {
+	foodRepo    repository.FoodRepository
+	taxRateRepo repository.TaxRateRepository
+	config      PricingConfig
+}
+
+func NewPricingEngine(foodRepo repository.FoodRepository, taxRateRepo repository.TaxRateRepository, config PricingConfig) PricingEngine {
+	return &pricingEngine{
+		foodRepo:    foodRepo,
+		taxRateRepo: taxRateRepo,
+		config:      config,
+	}
+}
+
+// Calculate prices the items at the current food prices, unless KeepPrices is set,
+// storing the unit price on each item, and builds the breakdown of the order total.
+// Every item must be a food of the supplier of the order.
+func (pe *pricingEngine) Calculate(request *PricingRequest) (*domain.PriceBreakdown, error) {
+	taxRates, err := pe.taxRateRepo.GetAllTaxRates()
+	if err != nil {
+		return nil, err
+	}
+	var defaultRate *domain.TaxRate
+	categoryRates := make(map[int64]*domain.TaxRate)
+	for _, taxRate := range taxRates {
+		if taxRate.CategoryID == 0 {
+			if defaultRate == nil {
+				defaultRate = taxRate
+			}
+			continue
+		}
+		categoryRates[taxRate.CategoryID] = taxRate
+	}
+
+	breakdown := &domain.PriceBreakdown{}
+	var couponBase float32
+	taxes := make(map[int64]*domain.TaxLine)
+	taxOrder := make([]int64, 0)
+	for i := range *request.Items {
+		item := &(*request.Items)[i]
+		food, err := pe.foodRepo.GetFoodByID(item.FoodID)
+		if err != nil {
+			if errors.Is(err, repository.ErrFoodNotFound) {
+				return nil, ErrFoodNotFound
+			}
+			return nil, err
+		}
+		// A food of another supplier is not on this menu, whatever its id.
+		if food.SupplierID != request.Supplier.ID {
+			return nil, ErrFoodNotFound
+		}
+		item.FoodName = food.Name
+		if !request.KeepPrices {
+			item.SinglePrice = float32(food.Price)
+		}
+
+		lineTotal := (item.SinglePrice + modifiersTotal(item.Modifiers)) * float32(item.Quantity)
+		breakdown.Subtotal += lineTotal
+		if request.Coupon != nil && couponCoversCategory(request.Coupon, food.CategoryID) {
+			couponBase += lineTotal
+		}
+
+		taxRate, found := categoryRates[food.CategoryID]
+		if !found {
+			taxRate = defaultRate
+		}
+		if taxRate == nil {
+			continue
+		}
+		line, found := taxes[taxRate.ID]
+		if !found {
+			line = &domain.TaxLine{Name: taxRate.Name, Rate: taxRate.Rate}
+			taxes[taxRate.ID] = line
+			taxOrder = append(taxOrder, taxRate.ID)
+		}
+		line.Base += lineTotal
+	}
+
+	for _, taxRateID := range taxOrder {
+		line := taxes[taxRateID]
+		line.Base = roundMoney(line.Base)
+		line.Amount = roundMoney(line.Base * line.Rate)
+		breakdown.Tax += line.Amount
+		breakdown.Taxes = append(breakdown.Taxes, line)
+	}
+
+	breakdown.Subtotal = roundMoney(breakdown.Subtotal)
+	if !request.FreeDelivery {
+		breakdown.DeliveryFee = deliveryFee(request.Supplier, breakdown.Subtotal, request.DistanceKm)
+	}
+	breakdown.ServiceFee = pe.serviceFee(breakdown.Subtotal)
+
+	// The coupon goes first so that the other discounts, like loyalty points, are what
+	// gets capped. Discounts never take more than the subtotal off.
+	discounts := make([]*domain.Discount, 0, len(request.Discounts)+1)
+	if request.Coupon != nil {
+		discounts = append(discounts, couponDiscount(request.Coupon, roundMoney(couponBase)))
+	}
+	discounts = append(discounts, request.Discounts...)
+	for _, discount := range discounts {
+		amount := discount.Amount
+		if breakdown.Discount+amount > breakdown.Subtotal {
+			amount = breakdown.Subtotal - breakdown.Discount
+		}
+		discount.Amount = roundMoney(amount)
+		breakdown.Discount += discount.Amount
+		breakdown.Discounts = append(breakdown.Discounts, discount)
+	}
+
+	for _, tip := range request.Tips {
+		tip.Amount = tipAmount(tip, breakdown.Subtotal)
+		breakdown.Tip += tip.Amount
+	}
+
+	breakdown.Tax = roundMoney(breakdown.Tax)
+	breakdown.Discount = roundMoney(breakdown.Discount)
+	breakdown.Tip = roundMoney(breakdown.Tip)
+	breakdown.Total = roundMoney(breakdown.Subtotal + breakdown.DeliveryFee + breakdown.ServiceFee + breakdown.Tax -
+		breakdown.Discount + breakdown.Tip)
+	return breakdown, nil
+}
+
+func (pe *pricingEngine) serviceFee(subtotal float32) float32 {
+	fee := subtotal * pe.config.ServiceFeeRate
+	if fee < pe.config.MinServiceFee {
+		fee = pe.config.MinServiceFee
+	}
+	if pe.config.MaxServiceFee > 0 && fee > pe.config.MaxServiceFee {
+		fee = pe.config.MaxServiceFee
+	}
+	return roundMoney(fee)
+}
+
+// deliveryFee applies the fee rules of a supplier: the fee of the matched delivery zone,
+// a flat fee or a base fee plus a price per kilometre, waived when the subtotal reaches
+// the free delivery threshold.
+func deliveryFee(supplier *domain.Supplier, subtotal float32, distanceKm float64) float32 {
+	if supplier.FreeDeliveryAbove > 0 && subtotal >= supplier.FreeDeliveryAbove {
+		return 0
+	}
+	if supplier.DeliveryZone != nil {
+		return roundMoney(supplier.DeliveryZone.DeliveryFee)
+	}
+	fee := supplier.DeliveryFee
+	if supplier.DeliveryFeeType == domain.DeliveryFeeDistance {
+		fee += supplier.DeliveryFeePerKm * float32(distanceKm)
+	}
+	return roundMoney(fee)
+}
+
+func roundMoney(amount float32) float32 {
+	return float32(math.Round(float64(amount)*100) / 100)
+}
+
+func validateTaxRate(taxRate *domain.TaxRate) error {
+	if taxRate.Name == "" || taxRate.Rate < 0 || taxRate.Rate > 1 {
+		return ErrInvalidTaxRate
+	}
+	return nil
+}
@@ -0,0 +1,289 @@
+package usecase
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"foodDelivery/domain"
+	"foodDelivery/geocoding"
+	"foodDelivery/repository"
+	"math/big"
+	"strings"
+	"time"
+)
+
+var (
+	ErrUnknownReferralCode = errors.New("referral code is not valid")
+)
+
+// sameAddressKm is how close two addresses have to be to count as the same place.
+const sameAddressKm = 0.05
+
+// ReferralConfig holds the rewards of the referral program. Rewards are wallet credit
+// or a single use coupon of the same value valid for CouponValidity. A zero reward is
+// not given.
+type ReferralConfig struct {
+	RewardType     string
+	ReferrerReward float32
+	ReferredReward float32
+	CouponValidity time.Duration
+}
+
+type ReferralUseCase interface {
+	GetReferrer(code string) (*domain.User, error)
+	AcceptReferral(referrer *domain.User, user *domain.User) (*domain.Referral, error)
+	GetSummary(userID int64) (*domain.ReferralSummary, error)
+	RewardOrder(orderID int64) error
+}
+
+type referralUseCase struct {
+	referralRepo repository.ReferralRepository
+	orderRepo    repository.OrderRepository
+	addressRepo  repository.AddressRepository
+	config       ReferralConfig
+}
+
+func NewReferralUseCase(referralRepo repository.ReferralRepository, orderRepo repository.OrderRepository,
+	addressRepo repository.AddressRepository, config ReferralConfig) ReferralUseCase {
+	return &referralUseCase{
+		referralRepo: referralRepo,
+		orderRepo:    orderRepo,
+		addressRepo:  addressRepo,
+		config:       config,
+	}
+}
+
+// GetReferrer returns the owner of an invite code.
+func (ru *referralUseCase) GetReferrer(code string) (*domain.User, error) {
+	referrer, err := ru.referralRepo.GetReferrerByCode(strings.ToUpper(strings.TrimSpace(code)))
+	if err != nil {
+		if errors.Is(err, repository.ErrReferralCodeNotFound) {
+			return nil, ErrUnknownReferralCode
+		}
+		return nil, err
+	}
+	return referrer, nil
+}
+
+// AcceptReferral records that a new user signed up with the code of the referrer. Sign ups
+// from the device or with the phone number of the referrer are recorded as rejected.
+func (ru *referralUseCase) AcceptReferral(referrer *domain.User, user *domain.User) (*domain.Referral, error) {
+	referral := &domain.Referral{
+		ReferrerID:   referrer.ID,
+		ReferredID:   user.ID,
+		ReferredName: user.Name,
+		DeviceID:     user.DeviceID,
+		Status:       domain.ReferralStatusPending,
+	}
+	switch {
+	case user.DeviceID != "" && user.DeviceID == referrer.DeviceID:
+		referral.Reason = "signed up on the device of the referrer"
+	case samePhone(user.Phone, referrer.Phone):
+		referral.Reason = "same phone number as the referrer"
+	}
+	if referral.Reason != "" {
+		now := time.Now()
+		referral.Status = domain.ReferralStatusRejected
+		referral.CompletedAt = &now
+	}
+
+	err := ru.referralRepo.CreateReferral(referral)
+	if err != nil {
+		return nil, err
+	}
+	return referral, nil
+}
+
+// GetSummary returns the invite code of a user with the referrals on both sides. Each side
+// only sees its own reward coupon.
+func (ru *referralUseCase) GetSummary(userID int64) (*domain.ReferralSummary, error) {
+	code, err := ru.referralRepo.GetReferralCode(userID)
+	if err != nil {
+		return nil, err
+	}
+	referrals, err := ru.referralRepo.GetUserReferrals(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, referral := range referrals {
+		referral.ReferredCoupon = ""
+	}
+
+	summary := &domain.ReferralSummary{Code: code, Referrals: referrals}
+	referredBy, err := ru.referralRepo.GetReferralByReferredID(userID)
+	if err != nil && !errors.Is(err, repository.ErrReferralNotFound) {
+		return nil, err
+	}
+	if referredBy != nil {
+		referredBy.ReferrerCoupon = ""
+		summary.ReferredBy = referredBy
+	}
+	return summary, nil
+}
+
+// RewardOrder completes the pending referral of a customer once their first order is
+// delivered. The referral is rejected when the order goes to an address of the referrer.
+// When the rewards cannot be given the referral stays pending, so a later call retries.
+func (ru *referralUseCase) RewardOrder(orderID int64) error {
+	order, err := ru.orderRepo.GetOrderWithItems(orderID)
+	if err != nil {
+		return err
+	}
+	if order.Status != domain.OrderStatusDelivered {
+		return nil
+	}
+	referral, err := ru.referralRepo.GetReferralByReferredID(order.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrReferralNotFound) {
+			return nil
+		}
+		return err
+	}
+	if referral.Status != domain.ReferralStatusPending {
+		return nil
+	}
+
+	referral.OrderID = order.ID
+	sameAddress, err := ru.deliversToReferrer(order, referral.ReferrerID)
+	if err != nil {
+		return err
+	}
+	rewards := make([]*domain.ReferralReward, 0, 2)
+	if sameAddress {
+		referral.Status = domain.ReferralStatusRejected
+		referral.Reason = "first order delivered to an address of the referrer"
+	} else {
+		referral.Status = domain.ReferralStatusRewarded
+		referrerReward, err := ru.newReward(referral, referral.ReferrerID, ru.config.ReferrerReward)
+		if err != nil {
+			return err
+		}
+		referredReward, err := ru.newReward(referral, referral.ReferredID, ru.config.ReferredReward)
+		if err != nil {
+			return err
+		}
+		if referrerReward != nil {
+			rewards = append(rewards, referrerReward)
+			referral.ReferrerCoupon = rewardCode(referrerReward)
+		}
+		if referredReward != nil {
+			rewards = append(rewards, referredReward)
+			referral.ReferredCoupon = rewardCode(referredReward)
+		}
+	}
+
+	err = ru.referralRepo.CompleteReferral(referral, rewards)
+	if err != nil {
+		if errors.Is(err, repository.ErrReferralCompleted) {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// newReward builds the reward of one side of a referral, or none when the reward is zero.
+// A reward coupon can only be used by the user it is given to.
+func (ru *referralUseCase) newReward(referral *domain.Referral, userID int64, amount float32) (*domain.ReferralReward, error) {
+	if amount <= 0 {
+		return nil, nil
+	}
+	reward := &domain.ReferralReward{UserID: userID}
+	if ru.config.RewardType == domain.ReferralRewardCoupon {
+		code, err := ru.rewardCouponCode()
+		if err != nil {
+			return nil, err
+		}
+		endsAt := time.Now().Add(ru.config.CouponValidity)
+		reward.Coupon = &domain.Coupon{
+			Code:         code,
+			Description:  "Referral reward",
+			Type:         domain.CouponTypeFixed,
+			Value:        amount,
+			UsageLimit:   1,
+			PerUserLimit: 1,
+			EndsAt:       &endsAt,
+			Active:       true,
+			UserID:       userID,
+		}
+		err = validateCoupon(reward.Coupon)
+		if err != nil {
+			return nil, err
+		}
+		return reward, nil
+	}
+	reward.Credit = &domain.WalletTransaction{
+		Type:        domain.WalletTransactionPromotion,
+		Amount:      roundMoney(amount),
+		Description: "Referral reward",
+		Reference:   fmt.Sprintf("referral-%d", referral.ID),
+	}
+	return reward, nil
+}
+
+// rewardCode returns the coupon code of a reward, or none for wallet credit.
+func rewardCode(reward *domain.ReferralReward) string {
+	if reward.Coupon == nil {
+		return ""
+	}
+	return reward.Coupon.Code
+}
+
+// deliversToReferrer tells whether the order address is one of the addresses of the referrer.
+func (ru *referralUseCase) deliversToReferrer(order *domain.Order, referrerID int64) (bool, error) {
+	address, err := ru.addressRepo.GetAddressByID(order.AddressID)
+	if err != nil {
+		if errors.Is(err, repository.ErrAddressNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	referrerAddresses, err := ru.addressRepo.GetUsersAddresses(referrerID)
+	if err != nil {
+		return false, err
+	}
+	for _, referrerAddress := range referrerAddresses {
+		if sameAddress(address, referrerAddress) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// rewardCouponCode returns a random code for a reward coupon.
+func (ru *referralUseCase) rewardCouponCode() (string, error) {
+	code := make([]byte, 10)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, big.NewInt(36))
+		if err != nil {
+			return "", err
+		}
+		code[i] = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"[n.Int64()]
+	}
+	return "REF-" + string(code), nil
+}
+
+func sameAddress(a, b *domain.Address) bool {
+	if a.Location != nil && b.Location != nil && geocoding.DistanceKm(*a.Location, *b.Location) < sameAddressKm {
+		return true
+	}
+	if a.Street != "" && strings.EqualFold(strings.TrimSpace(a.Street), strings.TrimSpace(b.Street)) &&
+		strings.EqualFold(strings.TrimSpace(a.City), strings.TrimSpace(b.City)) {
+		return true
+	}
+	return a.Address != "" && strings.EqualFold(strings.TrimSpace(a.Address), strings.TrimSpace(b.Address))
+}
+
+// samePhone compares two phone numbers on their digits only.
+func samePhone(a, b string) bool {
+	digits := func(phone string) string {
+		return strings.Map(func(r rune) rune {
+			if r >= '0' && r <= '9' {
+				return r
+			}
+			return -1
+		}, phone)
+	}
+	a, b = digits(a), digits(b)
+	return a != "" && a == b
+}
@@ -0,0 +1,205 @@
+package usecase
+
+import (
+	"errors"
+	"foodDelivery/domain"
+	"foodDelivery/repository"
+	"time"
+)
+
+var (
+	ErrInvalidReorderMode = errors.New("reorder mode must be auto, place or cart")
+	ErrNothingToReorder   = errors.New("none of the items of this order are available anymore")
+	ErrCartNotEmpty       = errors.New("cart is not empty, set replace_cart to replace it")
+)
+
+type ReorderUseCase interface {
+	Reorder(userID int64, orderID int64, request *domain.ReorderRequest) (*domain.ReorderResult, error)
+}
+
+type reorderUseCase struct {
+	orderRepo    repository.OrderRepository
+	foodRepo     repository.FoodRepository
+	modifierRepo repository.ModifierRepository
+	orderUseCase OrderUseCase
+	cartUseCase  CartUseCase
+}
+
+func NewReorderUseCase(orderRepo repository.OrderRepository, foodRepo repository.FoodRepository,
+	modifierRepo repository.ModifierRepository, orderUseCase OrderUseCase, cartUseCase CartUseCase) ReorderUseCase {
+	return &reorderUseCase{
+		orderRepo:    orderRepo,
+		foodRepo:     foodRepo,
+		modifierRepo: modifierRepo,
+		orderUseCase: orderUseCase,
+		cartUseCase:  cartUseCase,
+	}
+}
+
+func (ru *reorderUseCase) Reorder(userID int64, orderID int64, request *domain.ReorderRequest) (*domain.ReorderResult, error) {
+	if request.Mode == "" {
+		request.Mode = domain.ReorderModeAuto
+	}
+	if request.Mode != domain.ReorderModeAuto && request.Mode != domain.ReorderModePlace && request.Mode != domain.ReorderModeCart {
+		return nil, ErrInvalidReorderMode
+	}
+
+	previous, err := ru.orderRepo.GetOrderWithItems(orderID)
+	if err != nil {
+		return nil, err
+	}
+	if previous.UserID != userID {
+		return nil, repository.ErrOrderNotFound
+	}
+
+	items, changes, err := ru.repriceItems(previous)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, ErrNothingToReorder
+	}
+
+	result := &domain.ReorderResult{Changes: changes}
+	if request.Mode == domain.ReorderModePlace || (request.Mode == domain.ReorderModeAuto && len(changes) == 0) {
+		// The order is fulfilled like the previous one unless asked otherwise. The table of
+		// a dine-in order is never reused.
+		fulfilmentType := request.FulfilmentType
+		if fulfilmentType == "" {
+			fulfilmentType = previous.FulfilmentType
+		}
+		addressID := request.AddressID
+		if addressID == 0 {
+			addressID = previous.AddressID
+		}
+		order := &domain.Order{
+			UserID:                 userID,
+			SupplierID:             previous.SupplierID,
+			FulfilmentType:         fulfilmentType,
+			AddressID:              addressID,
+			TableNumber:            request.TableNumber,
+			RequestedDeliveryAt:    request.RequestedDeliveryAt,
+			PaymentMethod:          request.PaymentMethod,
+			WalletAmount:           request.WalletAmount,
+			LoyaltyPoints:          request.LoyaltyPoints,
+			CouponCode:             request.CouponCode,
+			SubstitutionPreference: request.SubstitutionPreference,
+			Items:                  &items,
+		}
+		err = ru.orderUseCase.SubmitOrder(order)
+		if err != nil {
+			return nil, err
+		}
+		result.Order = order
+		return result, nil
+	}
+
+	// Something changed or the caller asked for it: hand back a prefilled cart to review.
+	// What the customer already had in the cart is only replaced when asked.
+	cart, err := ru.cartUseCase.GetCart(userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(cart.Items) > 0 {
+		if !request.ReplaceCart {
+			return nil, ErrCartNotEmpty
+		}
+		result.DiscardedItems = cart.Items
+		err = ru.cartUseCase.ClearCart(userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, item := range items {
+		result.Cart, err = ru.cartUseCase.AddItem(userID, &domain.CartItem{
+			FoodID:    item.FoodID,
+			Quantity:  item.Quantity,
+			Note:      item.Note,
+			Modifiers: item.Modifiers,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// repriceItems rebuilds the items of a previous order at today's prices and stock,
+// returning the items that can still be ordered and what changed on the way.
+func (ru *reorderUseCase) repriceItems(previous *domain.Order) ([]domain.OrderItem, []*domain.ReorderChange, error) {
+	now := time.Now()
+	items := make([]domain.OrderItem, 0)
+	changes := make([]*domain.ReorderChange, 0)
+	if previous.Items == nil {
+		return items, changes, nil
+	}
+
+	for _, old := range *previous.Items {
+		change := &domain.ReorderChange{
+			FoodID:      old.FoodID,
+			FoodName:    old.FoodName,
+			OldPrice:    old.SinglePrice + modifiersTotal(old.Modifiers),
+			OldQuantity: old.Quantity,
+		}
+
+		food, err := ru.foodRepo.GetFoodByID(old.FoodID)
+		if err != nil {
+			if errors.Is(err, repository.ErrFoodNotFound) {
+				change.Change = domain.ReorderChangeRemoved
+				changes = append(changes, change)
+				continue
+			}
+			return nil, nil, err
+		}
+
+		// Options removed from the menu since the previous order have no option ID anymore.
+		modifiers := make([]*domain.OrderItemModifier, 0, len(old.Modifiers))
+		for _, modifier := range old.Modifiers {
+			modifiers = append(modifiers, &domain.OrderItemModifier{ModifierOptionID: modifier.ModifierOptionID})
+		}
+		groups, err := ru.modifierRepo.GetGroupsByFoodID(old.FoodID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if resolveModifiers(groups, modifiers, old.Note) != nil {
+			change.Change = domain.ReorderChangeOptionsChanged
+			changes = append(changes, change)
+			continue
+		}
+		change.NewPrice = float32(food.Price) + modifiersTotal(modifiers)
+
+		sold, err := ru.orderRepo.GetFoodDailySales(old.FoodID, now)
+		if err != nil {
+			return nil, nil, err
+		}
+		remaining := int(food.DailyQuantity) - sold
+		quantity := old.Quantity
+		if remaining <= 0 {
+			change.Change = domain.ReorderChangeUnavailable
+			changes = append(changes, change)
+			continue
+		}
+		if int(quantity) > remaining {
+			quantity = int8(remaining)
+		}
+		change.NewQuantity = quantity
+		if quantity != old.Quantity {
+			reduced := *change
+			reduced.Change = domain.ReorderChangeQuantityReduced
+			changes = append(changes, &reduced)
+		}
+		if change.NewPrice != change.OldPrice {
+			change.Change = domain.ReorderChangePriceChanged
+			changes = append(changes, change)
+		}
+		items = append(items, domain.OrderItem{
+			FoodID:      old.FoodID,
+			FoodName:    food.Name,
+			Quantity:    quantity,
+			SinglePrice: float32(food.Price),
+			Note:        old.Note,
+			Modifiers:   modifiers,
+		})
+	}
+	return items, changes, nil
+}
@@ -0,0 +1,148 @@
+package usecase
+
+import (
+	"errors"
+	"foodDelivery/domain"
+	"foodDelivery/repository"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	ErrOrderNotDelivered = errors.New("only delivered orders can be reviewed")
+	ErrInvalidRating     = errors.New("ratings must be between 1 and 5")
+	ErrInvalidFoodReview = errors.New("each food of the order can be rated once")
+	ErrCommentTooLong    = errors.New("review text can be at most 1000 characters")
+	ErrInvalidReply      = errors.New("reply must be between 1 and 1000 characters")
+	ErrInvalidSort       = errors.New("sort must be empty or rating")
+)
+
+// maxReviewText is the longest comment or reply, in characters.
+const maxReviewText = 1000
+
+type ReviewUseCase interface {
+	CreateReview(userID int64, orderID int64, review *domain.Review) error
+	GetOrderReview(orderID int64) (*domain.Review, error)
+	GetSupplierReviews(supplierID int64) ([]*domain.Review, error)
+	GetFoodReviews(foodID int64) ([]*domain.Review, error)
+	ReplyToReview(supplierID int64, reviewID int64, reply string) error
+}
+
+type reviewUseCase struct {
+	reviewRepo repository.ReviewRepository
+	orderRepo  repository.OrderRepository
+}
+
+func NewReviewUseCase(reviewRepo repository.ReviewRepository, orderRepo repository.OrderRepository) ReviewUseCase {
+	return &reviewUseCase{
+		reviewRepo: reviewRepo,
+		orderRepo:  orderRepo,
+	}
+}
+
+// CreateReview rates the supplier, the courier and the foods of a delivered order of the user.
+func (ru *reviewUseCase) CreateReview(userID int64, orderID int64, review *domain.Review) error {
+	order, err := ru.orderRepo.GetOrderWithItems(orderID)
+	if err != nil {
+		return err
+	}
+	if order.UserID != userID {
+		return repository.ErrOrderNotFound
+	}
+	if order.Status != domain.OrderStatusDelivered {
+		return ErrOrderNotDelivered
+	}
+
+	if !validRating(review.SupplierRating) {
+		return ErrInvalidRating
+	}
+	if review.CourierRating != 0 && (!validRating(review.CourierRating) || order.CourierID == 0) {
+		return ErrInvalidRating
+	}
+	if utf8.RuneCountInString(review.Comment) > maxReviewText {
+		return ErrCommentTooLong
+	}
+
+	orderedFoods := make(map[int64]bool)
+	for _, item := range *order.Items {
+		orderedFoods[item.FoodID] = true
+	}
+	ratedFoods := make(map[int64]bool)
+	for _, food := range review.Foods {
+		if !orderedFoods[food.FoodID] || ratedFoods[food.FoodID] {
+			return ErrInvalidFoodReview
+		}
+		if !validRating(food.Rating) {
+			return ErrInvalidRating
+		}
+		if utf8.RuneCountInString(food.Comment) > maxReviewText {
+			return ErrCommentTooLong
+		}
+		ratedFoods[food.FoodID] = true
+	}
+	if review.Foods == nil {
+		review.Foods = make([]*domain.FoodReview, 0)
+	}
+
+	review.OrderID = order.ID
+	review.UserID = order.UserID
+	review.SupplierID = order.SupplierID
+	review.CourierID = 0
+	if review.CourierRating != 0 {
+		review.CourierID = order.CourierID
+	}
+	review.Reply = ""
+	review.RepliedAt = nil
+	return ru.reviewRepo.CreateReview(review)
+}
+
+func (ru *reviewUseCase) GetOrderReview(orderID int64) (*domain.Review, error) {
+	return ru.reviewRepo.GetReviewByOrderID(orderID)
+}
+
+func (ru *reviewUseCase) GetSupplierReviews(supplierID int64) ([]*domain.Review, error) {
+	return ru.reviewRepo.GetSupplierReviews(supplierID)
+}
+
+func (ru *reviewUseCase) GetFoodReviews(foodID int64) ([]*domain.Review, error) {
+	return ru.reviewRepo.GetFoodReviews(foodID)
+}
+
+func (ru *reviewUseCase) ReplyToReview(supplierID int64, reviewID int64, reply string) error {
+	if strings.TrimSpace(reply) == "" || utf8.RuneCountInString(reply) > maxReviewText {
+		return ErrInvalidReply
+	}
+	return ru.reviewRepo.ReplyToReview(supplierID, reviewID, reply)
+}
+
+func validRating(rating int) bool {
+	return rating >= 1 && rating <= 5
+}
+
+func validateSort(sortBy string) error {
+	if sortBy != "" && sortBy != domain.SortByRating {
+		return ErrInvalidSort
+	}
+	return nil
+}
+
+// sortSuppliersByRating puts the best rated suppliers first, more reviews winning a tie.
+func sortSuppliersByRating(suppliers []*domain.Supplier) {
+	sort.SliceStable(suppliers, func(i, j int) bool {
+		if suppliers[i].RatingAverage != suppliers[j].RatingAverage {
+			return suppliers[i].RatingAverage > suppliers[j].RatingAverage
+		}
+		return suppliers[i].RatingCount > suppliers[j].RatingCount
+	})
+}
+
+// sortFoodsByRating puts the best rated foods first, more reviews winning a tie.
+func sortFoodsByRating(foods []*domain.Food) {
+	sort.SliceStable(foods, func(i, j int) bool {
+		if foods[i].RatingAverage != foods[j].RatingAverage {
+			return foods[i].RatingAverage > foods[j].RatingAverage
+		}
+		return foods[i].RatingCount > foods[j].RatingCount
+	})
+}
@@ -0,0 +1,131 @@
+package usecase
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"foodDelivery/document"
+	"foodDelivery/domain"
+	"strconv"
+)
+
+var (
+	ErrInvalidStatementFormat = errors.New("statement format must be csv or pdf")
+)
+
+const (
+	StatementFormatCSV = "csv"
+	StatementFormatPDF = "pdf"
+)
+
+// RenderStatement returns the statement of a settlement as a file with its content type.
+func (su *settlementUseCase) RenderStatement(settlementID int64, format string) ([]byte, string, error) {
+	if format != StatementFormatCSV && format != StatementFormatPDF {
+		return nil, "", ErrInvalidStatementFormat
+	}
+	settlement, err := su.settlementRepo.GetSettlementByID(settlementID)
+	if err != nil {
+		return nil, "", err
+	}
+	if format == StatementFormatCSV {
+		content, err := statementCSV(settlement)
+		return content, "text/csv", err
+	}
+	return statementPDF(settlement), "application/pdf", nil
+}
+
+// statementCSV writes one row per order followed by the adjustments and the totals, in
+// the columns of the order rows.
+func statementCSV(settlement *domain.Settlement) ([]byte, error) {
+	var out bytes.Buffer
+	writer := csv.NewWriter(&out)
+	rows := [][]string{
+		{"settlement", strconv.FormatInt(settlement.ID, 10)},
+		{"supplier", settlement.SupplierName},
+		{"period", settlement.PeriodFrom, settlement.PeriodTo},
+		{"status", settlement.Status, settlement.PayoutReference},
+		{},
+		{"order_id", "delivered_at", "sales", "commission_rate", "commission", "refunds", "tips", "net"},
+	}
+	for _, line := range settlement.Lines {
+		rows = append(rows, []string{
+			strconv.FormatInt(line.OrderID, 10),
+			line.DeliveredAt.UTC().Format("2006-01-02 15:04"),
+			money(line.Sales),
+			strconv.FormatFloat(float64(line.CommissionRate), 'f', 4, 32),
+			money(line.Commission),
+			money(line.Refunds),
+			money(line.Tips),
+			money(line.Net),
+		})
+	}
+	rows = append(rows, []string{}, []string{"adjustment", "effective_on", "amount"})
+	for _, adjustment := range settlement.AdjustmentItems {
+		rows = append(rows, []string{adjustment.Description, adjustment.EffectiveOn, money(adjustment.Amount)})
+	}
+	rows = append(rows, []string{},
+		[]string{"orders", "sales", "commission", "refunds", "tips", "adjustments", "payout"},
+		[]string{strconv.Itoa(settlement.Orders), money(settlement.Sales), money(settlement.Commission),
+			money(settlement.Refunds), money(settlement.Tips), money(settlement.Adjustments), money(settlement.Payout)},
+	)
+
+	err := writer.WriteAll(rows)
+	if err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func statementPDF(settlement *domain.Settlement) []byte {
+	pdf := document.NewPDF()
+	pdf.Title(fmt.Sprintf("Settlement statement #%d", settlement.ID))
+	pdf.Text(settlement.SupplierName)
+	pdf.Text(fmt.Sprintf("Period: %s to %s", settlement.PeriodFrom, settlement.PeriodTo))
+	status := "Payout status: " + settlement.Status
+	if settlement.PayoutReference != "" {
+		status += " (" + settlement.PayoutReference + ")"
+	}
+	pdf.Text(status)
+
+	pdf.Space()
+	pdf.Heading("Orders")
+	pdf.Mono(fmt.Sprintf("%-8s %-16s %10s %6s %10s %10s %8s %10s", "Order", "Delivered", "Sales", "Rate",
+		"Commission", "Refunds", "Tips", "Net"))
+	for _, line := range settlement.Lines {
+		pdf.Mono(fmt.Sprintf("%-8d %-16s %10s %5.1f%% %10s %10s %8s %10s", line.OrderID,
+			line.DeliveredAt.UTC().Format("2006-01-02 15:04"), money(line.Sales), line.CommissionRate*100,
+			money(line.Commission), money(line.Refunds), money(line.Tips), money(line.Net)))
+	}
+
+	if len(settlement.AdjustmentItems) > 0 {
+		pdf.Space()
+		pdf.Heading("Adjustments")
+		for _, adjustment := range settlement.AdjustmentItems {
+			pdf.Mono(fmt.Sprintf("%-10s %-50.50s %10s", adjustment.EffectiveOn, adjustment.Description, money(adjustment.Amount)))
+		}
+	}
+
+	pdf.Space()
+	pdf.Heading("Summary")
+	summary := []struct {
+		label  string
+		amount float32
+	}{
+		{"Sales", settlement.Sales},
+		{"Commission", -settlement.Commission},
+		{"Refunds", -settlement.Refunds},
+		{"Tips", settlement.Tips},
+		{"Adjustments", settlement.Adjustments},
+	}
+	pdf.Mono(fmt.Sprintf("%-20s %10d", "Orders", settlement.Orders))
+	for _, row := range summary {
+		pdf.Mono(fmt.Sprintf("%-20s %10s", row.label, money(row.amount)))
+	}
+	pdf.Heading(fmt.Sprintf("Payout: %s", money(settlement.Payout)))
+	return pdf.Bytes()
+}
+
+func money(amount float32) string {
+	return strconv.FormatFloat(float64(amount), 'f', 2, 32)
+}
@@ -0,0 +1,227 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"foodDelivery/domain"
+	"foodDelivery/repository"
+	"log"
+	"time"
+)
+
+var (
+	ErrInvalidCommissionRate = errors.New("commission rate needs a rate between 0 and 1 and a valid date range in YYYY-MM-DD format")
+	ErrInvalidAdjustment     = errors.New("adjustment needs a non-zero amount, a description and a date in YYYY-MM-DD format")
+	ErrInvalidPayoutStatus   = errors.New("payout status must be processing, paid or failed")
+)
+
+// payoutTransitions are the statuses a settlement can move to its payout status from.
+var payoutTransitions = map[string][]string{
+	domain.SettlementStatusProcessing: {domain.SettlementStatusPending, domain.SettlementStatusFailed},
+	domain.SettlementStatusPaid: {domain.SettlementStatusPending, domain.SettlementStatusProcessing,
+		domain.SettlementStatusFailed},
+	domain.SettlementStatusFailed: {domain.SettlementStatusPending, domain.SettlementStatusProcessing},
+}
+
+// SettlementConfig holds the commission applied to suppliers without a rate for the date
+// of an order.
+type SettlementConfig struct {
+	DefaultCommissionRate float32
+}
+
+type SettlementUseCase interface {
+	GetCommissionRates(supplierID int64) ([]*domain.CommissionRate, error)
+	CreateCommissionRate(rate *domain.CommissionRate) error
+	DeleteCommissionRate(supplierID int64, rateID int64) error
+	GetAdjustments(supplierID int64) ([]*domain.SettlementAdjustment, error)
+	CreateAdjustment(adjustment *domain.SettlementAdjustment) error
+	GenerateSettlements(from time.Time, to time.Time) ([]*domain.Settlement, error)
+	GetSettlements(supplierID int64, status string) ([]*domain.Settlement, error)
+	GetSettlement(settlementID int64) (*domain.Settlement, error)
+	UpdatePayout(settlementID int64, update *domain.PayoutUpdate) (*domain.Settlement, error)
+	RenderStatement(settlementID int64, format string) ([]byte, string, error)
+}
+
+type settlementUseCase struct {
+	settlementRepo repository.SettlementRepository
+	supplierRepo   repository.SupplierRepository
+	config         SettlementConfig
+}
+
+func NewSettlementUseCase(settlementRepo repository.SettlementRepository, supplierRepo repository.SupplierRepository,
+	config SettlementConfig) SettlementUseCase {
+	return &settlementUseCase{
+		settlementRepo: settlementRepo,
+		supplierRepo:   supplierRepo,
+		config:         config,
+	}
+}
+
+func (su *settlementUseCase) GetCommissionRates(supplierID int64) ([]*domain.CommissionRate, error) {
+	_, err := su.supplierRepo.GetSupplierByID(supplierID)
+	if err != nil {
+		return nil, err
+	}
+	return su.settlementRepo.GetCommissionRates(supplierID)
+}
+
+func (su *settlementUseCase) CreateCommissionRate(rate *domain.CommissionRate) error {
+	if rate.Rate < 0 || rate.Rate > 1 {
+		return ErrInvalidCommissionRate
+	}
+	startsOn, err := time.Parse("2006-01-02", rate.StartsOn)
+	if err != nil {
+		return ErrInvalidCommissionRate
+	}
+	if rate.EndsOn != "" {
+		endsOn, err := time.Parse("2006-01-02", rate.EndsOn)
+		if err != nil || endsOn.Before(startsOn) {
+			return ErrInvalidCommissionRate
+		}
+	}
+	return su.settlementRepo.CreateCommissionRate(rate)
+}
+
+func (su *settlementUseCase) DeleteCommissionRate(supplierID int64, rateID int64) error {
+	return su.settlementRepo.DeleteCommissionRate(supplierID, rateID)
+}
+
+func (su *settlementUseCase) GetAdjustments(supplierID int64) ([]*domain.SettlementAdjustment, error) {
+	_, err := su.supplierRepo.GetSupplierByID(supplierID)
+	if err != nil {
+		return nil, err
+	}
+	return su.settlementRepo.GetAdjustments(supplierID)
+}
+
+func (su *settlementUseCase) CreateAdjustment(adjustment *domain.SettlementAdjustment) error {
+	adjustment.Amount = roundMoney(adjustment.Amount)
+	if adjustment.Amount == 0 || adjustment.Description == "" {
+		return ErrInvalidAdjustment
+	}
+	if _, err := time.Parse("2006-01-02", adjustment.EffectiveOn); err != nil {
+		return ErrInvalidAdjustment
+	}
+	return su.settlementRepo.CreateAdjustment(adjustment)
+}
+
+// GenerateSettlements settles, for every supplier, the orders delivered and the adjustments
+// effective in the period that no earlier settlement covered. Refunds of orders settled
+// before are carried in as adjustments. The period runs from the start of from to the
+// start of to.
+func (su *settlementUseCase) GenerateSettlements(from time.Time, to time.Time) ([]*domain.Settlement, error) {
+	if from.IsZero() || to.IsZero() || !from.Before(to) || to.After(time.Now().AddDate(0, 0, 1)) {
+		return nil, ErrInvalidPeriod
+	}
+	err := su.settlementRepo.CreateRefundAdjustments(from)
+	if err != nil {
+		return nil, err
+	}
+	supplierIDs, err := su.settlementRepo.GetUnsettledSupplierIDs(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	settlements := make([]*domain.Settlement, 0, len(supplierIDs))
+	for _, supplierID := range supplierIDs {
+		settlement, err := su.settle(supplierID, from, to)
+		if err != nil {
+			// A concurrent run settled this supplier, the others still get theirs.
+			if errors.Is(err, repository.ErrAlreadySettled) {
+				log.Printf("Skipped settlement of supplier %d: %v", supplierID, err)
+				continue
+			}
+			return settlements, err
+		}
+		settlements = append(settlements, settlement)
+	}
+	return settlements, nil
+}
+
+func (su *settlementUseCase) GetSettlements(supplierID int64, status string) ([]*domain.Settlement, error) {
+	return su.settlementRepo.GetSettlements(supplierID, status)
+}
+
+func (su *settlementUseCase) GetSettlement(settlementID int64) (*domain.Settlement, error) {
+	return su.settlementRepo.GetSettlementByID(settlementID)
+}
+
+// UpdatePayout records the progress of the payout of a settlement. Paid settlements are final.
+func (su *settlementUseCase) UpdatePayout(settlementID int64, update *domain.PayoutUpdate) (*domain.Settlement, error) {
+	from, found := payoutTransitions[update.Status]
+	if !found {
+		return nil, ErrInvalidPayoutStatus
+	}
+	err := su.settlementRepo.UpdatePayout(settlementID, from, update)
+	if err != nil {
+		return nil, err
+	}
+	return su.settlementRepo.GetSettlementByID(settlementID)
+}
+
+func (su *settlementUseCase) settle(supplierID int64, from time.Time, to time.Time) (*domain.Settlement, error) {
+	lines, err := su.settlementRepo.GetUnsettledLines(supplierID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	adjustments, err := su.settlementRepo.GetUnsettledAdjustments(supplierID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	rates, err := su.settlementRepo.GetCommissionRates(supplierID)
+	if err != nil {
+		return nil, err
+	}
+
+	settlement := &domain.Settlement{
+		SupplierID:      supplierID,
+		PeriodFrom:      from.Format("2006-01-02"),
+		PeriodTo:        to.AddDate(0, 0, -1).Format("2006-01-02"),
+		Orders:          len(lines),
+		Status:          domain.SettlementStatusPending,
+		Lines:           lines,
+		AdjustmentItems: adjustments,
+	}
+	for _, line := range lines {
+		line.CommissionRate = su.commissionRate(rates, line.DeliveredAt)
+		line.Commission = roundMoney(line.Sales * line.CommissionRate)
+		// Refunds can cover delivery fees and courier tips too, the supplier only gives
+		// back what it was paid for the order.
+		if line.Refunds > line.Sales+line.Tips {
+			line.Refunds = line.Sales + line.Tips
+		}
+		line.Net = roundMoney(line.Sales - line.Commission - line.Refunds + line.Tips)
+
+		settlement.Sales += line.Sales
+		settlement.Commission += line.Commission
+		settlement.Refunds += line.Refunds
+		settlement.Tips += line.Tips
+	}
+	for _, adjustment := range adjustments {
+		settlement.Adjustments += adjustment.Amount
+	}
+	settlement.Sales = roundMoney(settlement.Sales)
+	settlement.Commission = roundMoney(settlement.Commission)
+	settlement.Refunds = roundMoney(settlement.Refunds)
+	settlement.Tips = roundMoney(settlement.Tips)
+	settlement.Adjustments = roundMoney(settlement.Adjustments)
+	settlement.Payout = roundMoney(settlement.Sales - settlement.Commission - settlement.Refunds + settlement.Tips +
+		settlement.Adjustments)
+
+	err = su.settlementRepo.CreateSettlement(settlement)
+	if err != nil {
+		return nil, fmt.Errorf("supplier %d: %w", supplierID, err)
+	}
+	return su.settlementRepo.GetSettlementByID(settlement.ID)
+}
+
+// commissionRate returns the rate of the supplier in effect on the day of an order.
+func (su *settlementUseCase) commissionRate(rates []*domain.CommissionRate, deliveredAt time.Time) float32 {
+	day := deliveredAt.UTC().Format("2006-01-02")
+	for _, rate := range rates {
+		if rate.StartsOn <= day && (rate.EndsOn == "" || day <= rate.EndsOn) {
+			return rate.Rate
+		}
+	}
+	return su.config.DefaultCommissionRate
+}
@@ -0,0 +1,252 @@
+package usecase
+
+import (
+	"errors"
+	"foodDelivery/domain"
+	"foodDelivery/repository"
+	"sort"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrInvalidOpeningHours = errors.New("opening hours need a weekday between 0 and 6 and HH:MM times")
+	ErrInvalidTimeZone     = errors.New("unknown supplier time zone")
+	ErrInvalidHolidayDate  = errors.New("holiday date must be in YYYY-MM-DD format")
+	ErrSupplierClosed      = errors.New("supplier is closed")
+)
+
+const (
+	// scheduleLookahead is how many days ahead the next opening is searched for.
+	scheduleLookahead = 60
+	// defaultDeliveryMinutes is used when a supplier's delivery_time has no number in it.
+	defaultDeliveryMinutes = 30
+)
+
+type openRange struct {
+	from time.Time
+	to   time.Time
+}
+
+// parseClock converts "HH:MM" into minutes since midnight. "24:00" is
+// accepted so a day can be closed at midnight.
+func parseClock(value string) (int, error) {
+	if len(value) != 5 || value[2] != ':' {
+		return 0, ErrInvalidOpeningHours
+	}
+	hour, hourErr := strconv.Atoi(value[:2])
+	minute, minuteErr := strconv.Atoi(value[3:])
+	if hourErr != nil || minuteErr != nil || hour < 0 || minute < 0 || minute > 59 || hour > 24 || (hour == 24 && minute != 0) {
+		return 0, ErrInvalidOpeningHours
+	}
+	return hour*60 + minute, nil
+}
+
+// deliveryMinutes reads the delivery duration out of the free text delivery_time
+// ("30", "30-45 min", ...), taking the largest number so estimates stay on the safe side.
+func deliveryMinutes(supplier *domain.Supplier) int {
+	if supplier.DeliveryZone != nil && supplier.DeliveryZone.DeliveryTime > 0 {
+		return supplier.DeliveryZone.DeliveryTime
+	}
+	minutes, current, inNumber := 0, 0, false
+	for _, r := range supplier.DeliveryTime + " " {
+		if r >= '0' && r <= '9' {
+			current = current*10 + int(r-'0')
+			inNumber = true
+			continue
+		}
+		if inNumber && current > minutes {
+			minutes = current
+		}
+		current, inNumber = 0, false
+	}
+	if minutes == 0 {
+		return defaultDeliveryMinutes
+	}
+	return minutes
+}
+
+// supplierLeadTime is how long before the delivery an order has to reach the supplier.
+func supplierLeadTime(supplier *domain.Supplier) time.Duration {
+	return time.Duration(supplier.PrepTime+deliveryMinutes(supplier)) * time.Minute
+}
+
+func supplierLocation(supplier *domain.Supplier) *time.Location {
+	if supplier.TimeZone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(supplier.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// weeklyIntervals returns the weekly schedule of a supplier. Suppliers that
+// only have the legacy opening_hour/closing_hour pair get it on every day and
+// suppliers without any hours are treated as open around the clock.
+func weeklyIntervals(supplier *domain.Supplier) []*domain.OpeningInterval {
+	if len(supplier.OpeningHours) > 0 {
+		return supplier.OpeningHours
+	}
+
+	opensAt, closesAt := "00:00", "24:00"
+	_, openErr := parseClock(supplier.OpeningHour)
+	_, closeErr := parseClock(supplier.ClosingHour)
+	if openErr == nil && closeErr == nil {
+		opensAt, closesAt = supplier.OpeningHour, supplier.ClosingHour
+	}
+
+	intervals := make([]*domain.OpeningInterval, 0, 7)
+	for weekday := 0; weekday < 7; weekday++ {
+		intervals = append(intervals, &domain.OpeningInterval{Weekday: weekday, OpensAt: opensAt, ClosesAt: closesAt})
+	}
+	return intervals
+}
+
+// openRanges lists the concrete opening ranges starting from the day before
+// `from` up to `days` days after it, skipping holiday closures.
+func openRanges(supplier *domain.Supplier, from time.Time, days int) []openRange {
+	loc := supplierLocation(supplier)
+	local := from.In(loc)
+
+	holidays := make(map[string]bool)
+	for _, holiday := range supplier.Holidays {
+		holidays[holiday.Date] = true
+	}
+
+	intervals := weeklyIntervals(supplier)
+	ranges := make([]openRange, 0)
+	for offset := -1; offset <= days; offset++ {
+		day := time.Date(local.Year(), local.Month(), local.Day()+offset, 0, 0, 0, 0, loc)
+		if holidays[day.Format("2006-01-02")] {
+			continue
+		}
+
+		for _, interval := range intervals {
+			if interval.Weekday != int(day.Weekday()) {
+				continue
+			}
+			opens, err := parseClock(interval.OpensAt)
+			if err != nil {
+				continue
+			}
+			closes, err := parseClock(interval.ClosesAt)
+			if err != nil {
+				continue
+			}
+
+			closingDay := day.Day()
+			if closes <= opens {
+				// Overnight interval, it closes on the following day.
+				closingDay++
+			}
+			ranges = append(ranges, openRange{
+				from: time.Date(day.Year(), day.Month(), day.Day(), 0, opens, 0, 0, loc),
+				to:   time.Date(day.Year(), day.Month(), closingDay, 0, closes, 0, 0, loc),
+			})
+		}
+	}
+
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].from.Before(ranges[j].from)
+	})
+	return ranges
+}
+
+// IsSupplierOpenAt reports whether the supplier accepts orders at the given moment.
+func IsSupplierOpenAt(supplier *domain.Supplier, at time.Time) bool {
+	for _, r := range openRanges(supplier, at, 1) {
+		if !at.Before(r.from) && at.Before(r.to) {
+			return true
+		}
+	}
+	return false
+}
+
+// NextSupplierOpening returns the next moment after `at` at which the supplier opens,
+// or nil when no opening is scheduled in the lookahead window.
+func NextSupplierOpening(supplier *domain.Supplier, at time.Time) *time.Time {
+	for _, r := range openRanges(supplier, at, scheduleLookahead) {
+		if r.from.After(at) {
+			next := r.from
+			return &next
+		}
+	}
+	return nil
+}
+
+// applyOpeningStatus fills the computed is_open and next_opening_at fields of a supplier.
+func applyOpeningStatus(supplier *domain.Supplier, now time.Time) {
+	supplier.IsOpen = IsSupplierOpenAt(supplier, now)
+	supplier.NextOpeningAt = nil
+	if !supplier.IsOpen {
+		supplier.NextOpeningAt = NextSupplierOpening(supplier, now)
+	}
+}
+
+func validateSchedule(supplier *domain.Supplier) error {
+	if supplier.TimeZone == "" {
+		supplier.TimeZone = "UTC"
+	}
+	if _, err := time.LoadLocation(supplier.TimeZone); err != nil {
+		return ErrInvalidTimeZone
+	}
+
+	for _, interval := range supplier.OpeningHours {
+		if interval.Weekday < 0 || interval.Weekday > 6 {
+			return ErrInvalidOpeningHours
+		}
+		if _, err := parseClock(interval.OpensAt); err != nil {
+			return err
+		}
+		if _, err := parseClock(interval.ClosesAt); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// loadSupplierSchedule fetches a supplier together with its opening hours and holidays.
+func loadSupplierSchedule(supplierRepo repository.SupplierRepository, scheduleRepo repository.SupplierScheduleRepository,
+	supplierID int64) (*domain.Supplier, error) {
+	supplier, err := supplierRepo.GetSupplierByID(supplierID)
+	if err != nil {
+		return nil, err
+	}
+	err = attachSchedule(scheduleRepo, supplier)
+	if err != nil {
+		return nil, err
+	}
+	return supplier, nil
+}
+
+func attachSchedule(scheduleRepo repository.SupplierScheduleRepository, supplier *domain.Supplier) error {
+	return attachSchedules(scheduleRepo, []*domain.Supplier{supplier})
+}
+
+// attachSchedules loads the opening hours and holidays of a list of suppliers with one
+// query each, however many suppliers there are.
+func attachSchedules(scheduleRepo repository.SupplierScheduleRepository, suppliers []*domain.Supplier) error {
+	if len(suppliers) == 0 {
+		return nil
+	}
+	supplierIDs := make([]int64, 0, len(suppliers))
+	for _, supplier := range suppliers {
+		supplierIDs = append(supplierIDs, supplier.ID)
+	}
+	openingHours, err := scheduleRepo.GetOpeningHoursBySupplierIDs(supplierIDs)
+	if err != nil {
+		return err
+	}
+	holidays, err := scheduleRepo.GetHolidaysBySupplierIDs(supplierIDs)
+	if err != nil {
+		return err
+	}
+	for _, supplier := range suppliers {
+		supplier.OpeningHours = openingHours[supplier.ID]
+		supplier.Holidays = holidays[supplier.ID]
+	}
+	return nil
+}
@@ -1,8 +1,27 @@
 package usecase
 
 import (
+	"errors"
 	"foodDelivery/domain"
 	"foodDelivery/repository"
+	"math"
+	"time"
+)
+
+var (
+	ErrInvalidDeliveryFee = errors.New("delivery fee type must be flat or distance and fees can not be negative")
+	ErrInvalidOrderRules  = errors.New("minimum subtotal and order limits can not be negative")
+	ErrInvalidLocation    = errors.New("latitude must be between -90 and 90 and longitude between -180 and 180")
+	ErrInvalidNearbyQuery = errors.New("radius must be positive and at most 50 km, page and limit must be positive")
+)
+
+const (
+	// courierSpeedKmh is the average speed used to estimate travel times.
+	courierSpeedKmh       = 20
+	defaultNearbyRadiusKm = 5
+	maxNearbyRadiusKm     = 50
+	defaultPageLimit      = 20
+	maxPageLimit          = 100
 )
 
 type SupplierUseCase interface {
@@ -10,21 +29,38 @@ type SupplierUseCase interface {
 	CreateSupplier(supplier *domain.Supplier) error
 	UpdateSupplier(supplier *domain.Supplier) error
 	DeleteSupplier(supplierID int64) error
-	GetAllSuppliers() ([]*domain.Supplier, error)
+	GetAllSuppliers(filter domain.SupplierFilter) ([]*domain.Supplier, error)
+	GetNearbySuppliers(query domain.NearbyQuery) (*domain.SupplierPage, error)
+	GetSupplierHolidays(supplierID int64) ([]*domain.Holiday, error)
+	CreateSupplierHoliday(holiday *domain.Holiday) error
+	DeleteSupplierHoliday(supplierID int64, holidayID int64) error
 }
 
 type supplierUseCase struct {
 	supplierRepository repository.SupplierRepository
+	scheduleRepository repository.SupplierScheduleRepository
+	zoneRepository     repository.DeliveryZoneRepository
+	addressRepository  repository.AddressRepository
 }
 
-func NewSupplierUseCase(supplierRepository repository.SupplierRepository) SupplierUseCase {
+func NewSupplierUseCase(supplierRepository repository.SupplierRepository,
+	scheduleRepository repository.SupplierScheduleRepository, zoneRepository repository.DeliveryZoneRepository,
+	addressRepository repository.AddressRepository) SupplierUseCase {
 	return &supplierUseCase{
 		supplierRepository: supplierRepository,
+		scheduleRepository: scheduleRepository,
+		zoneRepository:     zoneRepository,
+		addressRepository:  addressRepository,
 	}
 }
 
 func (su *supplierUseCase) GetSupplierById(supplierID int64) (*domain.Supplier, error) {
-	supplier, err := su.supplierRepository.GetSupplierByID(supplierID)
+	supplier, err := loadSupplierSchedule(su.supplierRepository, su.scheduleRepository, supplierID)
+	if err != nil {
+		return nil, err
+	}
+	applyOpeningStatus(supplier, time.Now())
+	supplier.DeliveryZones, err = su.zoneRepository.GetZonesBySupplierID(supplierID)
 	if err != nil {
 		return nil, err
 	}
@@ -32,19 +68,19 @@ func (su *supplierUseCase) GetSupplierById(supplierID int64) (*domain.Supplier,
 }
 
 func (su *supplierUseCase) CreateSupplier(supplier *domain.Supplier) error {
-	err := su.supplierRepository.CreateSupplier(supplier)
+	err := validateSupplier(supplier)
 	if err != nil {
 		return err
 	}
-	return nil
+	return su.supplierRepository.CreateSupplier(supplier)
 }
 
 func (su *supplierUseCase) UpdateSupplier(supplier *domain.Supplier) error {
-	err := su.supplierRepository.UpdateSupplier(supplier)
+	err := validateSupplier(supplier)
 	if err != nil {
 		return err
 	}
-	return nil
+	return su.supplierRepository.UpdateSupplier(supplier)
 }
 
 func (su *supplierUseCase) DeleteSupplier(supplierID int64) error {
@@ -55,10 +91,149 @@ func (su *supplierUseCase) DeleteSupplier(supplierID int64) error {
 	return nil
 }
 
-func (su *supplierUseCase) GetAllSuppliers() ([]*domain.Supplier, error) {
-	supplier, err := su.supplierRepository.GetAllSuppliers()
+func (su *supplierUseCase) GetAllSuppliers(filter domain.SupplierFilter) ([]*domain.Supplier, error) {
+	err := validateSort(filter.Sort)
 	if err != nil {
 		return nil, err
 	}
-	return supplier, nil
+	suppliers, err := su.supplierRepository.GetAllSuppliers()
+	if err != nil {
+		return nil, err
+	}
+
+	var address *domain.Address
+	if filter.AddressID != 0 {
+		address, err = su.addressRepository.GetAddressByID(filter.AddressID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = attachSchedules(su.scheduleRepository, suppliers)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	result := make([]*domain.Supplier, 0, len(suppliers))
+	for _, supplier := range suppliers {
+		applyOpeningStatus(supplier, now)
+		if filter.OpenNow && !supplier.IsOpen {
+			continue
+		}
+		supplier.DeliveryZones, err = su.zoneRepository.GetZonesBySupplierID(supplier.ID)
+		if err != nil {
+			return nil, err
+		}
+		if address != nil {
+			err = applyDeliveryZone(su.zoneRepository, supplier, address)
+			if errors.Is(err, ErrAddressOutsideZone) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		result = append(result, supplier)
+	}
+	if filter.Sort == domain.SortByRating {
+		sortSuppliersByRating(result)
+	}
+	return result, nil
+}
+
+// GetNearbySuppliers ranks the suppliers around a point by estimated delivery time and distance.
+func (su *supplierUseCase) GetNearbySuppliers(query domain.NearbyQuery) (*domain.SupplierPage, error) {
+	if !validLocation(query.Point) {
+		return nil, ErrInvalidLocation
+	}
+	if query.RadiusKm == 0 {
+		query.RadiusKm = defaultNearbyRadiusKm
+	}
+	if query.Page == 0 {
+		query.Page = 1
+	}
+	if query.Limit == 0 {
+		query.Limit = defaultPageLimit
+	}
+	if query.RadiusKm < 0 || query.RadiusKm > maxNearbyRadiusKm || query.Page < 0 || query.Limit < 0 || query.Limit > maxPageLimit {
+		return nil, ErrInvalidNearbyQuery
+	}
+
+	suppliers, total, err := su.supplierRepository.GetNearbySuppliers(query, courierSpeedKmh)
+	if err != nil {
+		return nil, err
+	}
+
+	err = attachSchedules(su.scheduleRepository, suppliers)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for _, supplier := range suppliers {
+		applyOpeningStatus(supplier, now)
+		supplier.DistanceKm = roundKm(supplier.DistanceKm)
+		supplier.EstimatedMinutes = supplier.PrepTime + travelMinutes(supplier.DistanceKm)
+	}
+
+	return &domain.SupplierPage{
+		Suppliers: suppliers,
+		Total:     total,
+		Page:      query.Page,
+		Limit:     query.Limit,
+	}, nil
+}
+
+// travelMinutes is the time a courier needs for the distance, rounded up.
+func travelMinutes(distanceKm float64) int {
+	return int(math.Ceil(distanceKm / courierSpeedKmh * 60))
+}
+
+func roundKm(distanceKm float64) float64 {
+	return math.Round(distanceKm*100) / 100
+}
+
+func validLocation(point domain.GeoPoint) bool {
+	return point.Lat >= -90 && point.Lat <= 90 && point.Lng >= -180 && point.Lng <= 180
+}
+
+func (su *supplierUseCase) GetSupplierHolidays(supplierID int64) ([]*domain.Holiday, error) {
+	return su.scheduleRepository.GetHolidaysBySupplierID(supplierID)
+}
+
+func (su *supplierUseCase) CreateSupplierHoliday(holiday *domain.Holiday) error {
+	if _, err := time.Parse("2006-01-02", holiday.Date); err != nil {
+		return ErrInvalidHolidayDate
+	}
+	_, err := su.supplierRepository.GetSupplierByID(holiday.SupplierID)
+	if err != nil {
+		return err
+	}
+	return su.scheduleRepository.CreateHoliday(holiday)
+}
+
+func (su *supplierUseCase) DeleteSupplierHoliday(supplierID int64, holidayID int64) error {
+	return su.scheduleRepository.DeleteHoliday(supplierID, holidayID)
+}
+
+func validateSupplier(supplier *domain.Supplier) error {
+	if supplier.DeliveryFeeType == "" {
+		supplier.DeliveryFeeType = domain.DeliveryFeeFlat
+	}
+	if supplier.DeliveryFeeType != domain.DeliveryFeeFlat && supplier.DeliveryFeeType != domain.DeliveryFeeDistance {
+		return ErrInvalidDeliveryFee
+	}
+	if supplier.DeliveryFee < 0 || supplier.DeliveryFeePerKm < 0 || supplier.FreeDeliveryAbove < 0 {
+		return ErrInvalidDeliveryFee
+	}
+	if supplier.MinOrderSubtotal < 0 || supplier.MaxItems < 0 || supplier.MaxFoodQuantity < 0 {
+		return ErrInvalidOrderRules
+	}
+	if supplier.Location != nil && !validLocation(*supplier.Location) {
+		return ErrInvalidLocation
+	}
+	err := validateDeliveryZones(supplier.DeliveryZones)
+	if err != nil {
+		return err
+	}
+	return validateSchedule(supplier)
 }
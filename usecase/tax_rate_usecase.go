@@ -0,0 +1,47 @@
+package usecase
+
+import (
+	"foodDelivery/domain"
+	"foodDelivery/repository"
+)
+
+type TaxRateUseCase interface {
+	GetAllTaxRates() ([]*domain.TaxRate, error)
+	CreateTaxRate(taxRate *domain.TaxRate) error
+	UpdateTaxRate(taxRate *domain.TaxRate) error
+	DeleteTaxRate(taxRateID int64) error
+}
+
+type taxRateUseCase struct {
+	taxRateRepository repository.TaxRateRepository
+}
+
+func NewTaxRateUseCase(taxRateRepository repository.TaxRateRepository) TaxRateUseCase {
+	return &taxRateUseCase{
+		taxRateRepository: taxRateRepository,
+	}
+}
+
+func (tu *taxRateUseCase) GetAllTaxRates() ([]*domain.TaxRate, error) {
+	return tu.taxRateRepository.GetAllTaxRates()
+}
+
+func (tu *taxRateUseCase) CreateTaxRate(taxRate *domain.TaxRate) error {
+	err := validateTaxRate(taxRate)
+	if err != nil {
+		return err
+	}
+	return tu.taxRateRepository.CreateTaxRate(taxRate)
+}
+
+func (tu *taxRateUseCase) UpdateTaxRate(taxRate *domain.TaxRate) error {
+	err := validateTaxRate(taxRate)
+	if err != nil {
+		return err
+	}
+	return tu.taxRateRepository.UpdateTaxRate(taxRate)
+}
+
+func (tu *taxRateUseCase) DeleteTaxRate(taxRateID int64) error {
+	return tu.taxRateRepository.DeleteTaxRate(taxRateID)
+}
@@ -0,0 +1,209 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"foodDelivery/domain"
+	"foodDelivery/repository"
+	"log"
+	"time"
+)
+
+var (
+	ErrInvalidTip    = errors.New("tip needs a courier or supplier recipient, a fixed or percentage type and a positive value")
+	ErrTipNotAllowed = errors.New("tips can be given at checkout or within 24 hours after delivery")
+	ErrInvalidPeriod = errors.New("period start must be before its end")
+)
+
+const (
+	// tipWindow is how long after delivery an order can still be tipped.
+	tipWindow = 24 * time.Hour
+	// maxTipPercentage caps tips at this percentage of the subtotal, fixed ones included,
+	// a typo should not tip three times the order.
+	maxTipPercentage = 100
+	// defaultEarningsPeriod is reported when no period start is given.
+	defaultEarningsPeriod = 30 * 24 * time.Hour
+)
+
+type TipUseCase interface {
+	AddTips(userID int64, orderID int64, tips []*domain.Tip) (*domain.Order, error)
+	GetCourierEarnings(userID int64, from time.Time, to time.Time) (*domain.Earnings, error)
+	GetSupplierEarnings(supplierID int64, from time.Time, to time.Time) (*domain.Earnings, error)
+}
+
+type tipUseCase struct {
+	tipRepo      repository.TipRepository
+	orderRepo    repository.OrderRepository
+	courierRepo  repository.CourierRepository
+	supplierRepo repository.SupplierRepository
+	payments     PaymentUseCase
+}
+
+func NewTipUseCase(tipRepo repository.TipRepository, orderRepo repository.OrderRepository,
+	courierRepo repository.CourierRepository, supplierRepo repository.SupplierRepository, payments PaymentUseCase) TipUseCase {
+	return &tipUseCase{
+		tipRepo:      tipRepo,
+		orderRepo:    orderRepo,
+		courierRepo:  courierRepo,
+		supplierRepo: supplierRepo,
+		payments:     payments,
+	}
+}
+
+// AddTips tips the courier or the supplier of a delivered order of the user. Each of them
+// can be tipped once per order, whether at checkout or afterwards.
+func (tu *tipUseCase) AddTips(userID int64, orderID int64, tips []*domain.Tip) (*domain.Order, error) {
+	if len(tips) == 0 {
+		return nil, ErrInvalidTip
+	}
+	err := validateTips(tips)
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := tu.orderRepo.GetOrderWithItems(orderID)
+	if err != nil {
+		return nil, err
+	}
+	if order.UserID != userID {
+		return nil, repository.ErrOrderNotFound
+	}
+	err = checkTipRecipients(order, tips)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status != domain.OrderStatusDelivered || order.DeliveredAt == nil ||
+		time.Since(*order.DeliveredAt) > tipWindow {
+		return nil, ErrTipNotAllowed
+	}
+	var amount float32
+	for _, tip := range tips {
+		for _, existing := range order.Tips {
+			if existing.Recipient == tip.Recipient {
+				return nil, repository.ErrTipExists
+			}
+		}
+		tip.Amount = tipAmount(tip, order.Breakdown.Subtotal)
+		amount += tip.Amount
+	}
+	err = checkTipAmounts(tips, order.Breakdown.Subtotal)
+	if err != nil {
+		return nil, err
+	}
+
+	// The order payment is already captured, the tips are paid on their own: from the wallet
+	// for orders paid with the wallet only, or charged to the card. They are stored first, so
+	// that a concurrent request tipping the same recipient fails before anything is charged.
+	amount = roundMoney(amount)
+	fromWallet := cardAmount(order) <= 0
+	err = tu.tipRepo.AddTips(order, tips, fromWallet)
+	if err != nil {
+		return nil, err
+	}
+	if !fromWallet {
+		_, err = tu.payments.ChargeOrder(orderID, amount)
+		if err != nil {
+			removeErr := tu.tipRepo.RemoveTips(orderID, tips)
+			if removeErr != nil {
+				log.Printf("Failed to remove the unpaid tips of order %d: %v", orderID, removeErr)
+			}
+			return nil, err
+		}
+	}
+	return tu.orderRepo.GetOrderWithItems(orderID)
+}
+
+func (tu *tipUseCase) GetCourierEarnings(userID int64, from time.Time, to time.Time) (*domain.Earnings, error) {
+	courier, err := tu.courierRepo.GetCourierByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	from, to, err = earningsPeriod(from, to)
+	if err != nil {
+		return nil, err
+	}
+	return tu.tipRepo.GetCourierEarnings(courier.ID, from, to)
+}
+
+func (tu *tipUseCase) GetSupplierEarnings(supplierID int64, from time.Time, to time.Time) (*domain.Earnings, error) {
+	_, err := tu.supplierRepo.GetSupplierByID(supplierID)
+	if err != nil {
+		return nil, err
+	}
+	from, to, err = earningsPeriod(from, to)
+	if err != nil {
+		return nil, err
+	}
+	return tu.tipRepo.GetSupplierEarnings(supplierID, from, to)
+}
+
+// earningsPeriod defaults a missing end to now and a missing start to 30 days before the end.
+func earningsPeriod(from time.Time, to time.Time) (time.Time, time.Time, error) {
+	if to.IsZero() {
+		to = time.Now()
+	}
+	if from.IsZero() {
+		from = to.Add(-defaultEarningsPeriod)
+	}
+	if !from.Before(to) {
+		return from, to, ErrInvalidPeriod
+	}
+	return from, to, nil
+}
+
+// validateTips checks the tips of an order, each recipient being tipped at most once.
+func validateTips(tips []*domain.Tip) error {
+	recipients := make(map[string]bool)
+	for _, tip := range tips {
+		if tip.Recipient != domain.TipRecipientCourier && tip.Recipient != domain.TipRecipientSupplier {
+			return ErrInvalidTip
+		}
+		if recipients[tip.Recipient] || tip.Value <= 0 {
+			return ErrInvalidTip
+		}
+		switch tip.Type {
+		case domain.TipTypeFixed:
+		case domain.TipTypePercentage:
+			if tip.Value > maxTipPercentage {
+				return ErrInvalidTip
+			}
+		default:
+			return ErrInvalidTip
+		}
+		recipients[tip.Recipient] = true
+	}
+	return nil
+}
+
+// checkTipAmounts rejects fixed tips above the cap of percentage tips. The amounts must
+// already be computed.
+func checkTipAmounts(tips []*domain.Tip, subtotal float32) error {
+	limit := roundMoney(subtotal * maxTipPercentage / 100)
+	for _, tip := range tips {
+		if tip.Amount > limit {
+			return fmt.Errorf("%w: a tip can be at most %d%% of the subtotal, %.2f", ErrInvalidTip, maxTipPercentage, limit)
+		}
+	}
+	return nil
+}
+
+// checkTipRecipients rejects courier tips on orders no courier brings.
+func checkTipRecipients(order *domain.Order, tips []*domain.Tip) error {
+	if order.FulfilmentType == domain.FulfilmentTypeDelivery {
+		return nil
+	}
+	for _, tip := range tips {
+		if tip.Recipient == domain.TipRecipientCourier {
+			return fmt.Errorf("%w: %s orders have no courier", ErrInvalidTip, order.FulfilmentType)
+		}
+	}
+	return nil
+}
+
+// tipAmount turns a tip into money, a percentage tip being taken of the subtotal.
+func tipAmount(tip *domain.Tip, subtotal float32) float32 {
+	if tip.Type == domain.TipTypePercentage {
+		return roundMoney(subtotal * tip.Value / 100)
+	}
+	return roundMoney(tip.Value)
+}
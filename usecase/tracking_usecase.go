@@ -0,0 +1,133 @@
+package usecase
+
+import (
+	"errors"
+	"foodDelivery/domain"
+	"foodDelivery/geocoding"
+	"foodDelivery/repository"
+	"time"
+)
+
+var (
+	ErrPingTooOld = errors.New("location ping is too old")
+)
+
+// maxPingAge is how old a ping can be when it reaches the server, pings queued
+// by the app for longer than this are useless for tracking.
+const maxPingAge = 5 * time.Minute
+
+type TrackingUseCase interface {
+	RecordLocation(userID int64, ping *domain.LocationPing) error
+	GetOrderTracking(orderID int64) (*domain.OrderTracking, error)
+}
+
+type trackingUseCase struct {
+	orderRepo    repository.OrderRepository
+	courierRepo  repository.CourierRepository
+	supplierRepo repository.SupplierRepository
+	addressRepo  repository.AddressRepository
+	tracker      *LocationTracker
+}
+
+func NewTrackingUseCase(orderRepo repository.OrderRepository, courierRepo repository.CourierRepository,
+	supplierRepo repository.SupplierRepository, addressRepo repository.AddressRepository, tracker *LocationTracker) TrackingUseCase {
+	return &trackingUseCase{
+		orderRepo:    orderRepo,
+		courierRepo:  courierRepo,
+		supplierRepo: supplierRepo,
+		addressRepo:  addressRepo,
+		tracker:      tracker,
+	}
+}
+
+func (tu *trackingUseCase) RecordLocation(userID int64, ping *domain.LocationPing) error {
+	point := domain.GeoPoint{Lat: ping.Lat, Lng: ping.Lng}
+	if !validLocation(point) {
+		return ErrInvalidLocation
+	}
+	now := time.Now()
+	if ping.RecordedAt.IsZero() || ping.RecordedAt.After(now) {
+		ping.RecordedAt = now
+	}
+	if now.Sub(ping.RecordedAt) > maxPingAge {
+		return ErrPingTooOld
+	}
+
+	courier, err := tu.courierRepo.GetCourierByUserID(userID)
+	if err != nil {
+		return err
+	}
+	if !courier.Active {
+		return ErrCourierInactive
+	}
+	// Only deliveries on their way are tracked in memory, the position of a courier
+	// waiting for an order is only needed for the assignment.
+	orderIDs, err := tu.orderRepo.GetCourierOrderIDs(courier.ID, activeDeliveryStatuses)
+	if err != nil {
+		return err
+	}
+	if len(orderIDs) == 0 {
+		tu.tracker.Record(courier.ID, nil, point, ping.RecordedAt)
+		return tu.courierRepo.UpdateCourierLocation(courier.ID, point, ping.RecordedAt)
+	}
+	tu.tracker.Record(courier.ID, orderIDs, point, ping.RecordedAt)
+	return nil
+}
+
+// GetOrderTracking returns the courier position of an order and the estimated arrival.
+// Before pick up the estimate covers the way through the supplier.
+func (tu *trackingUseCase) GetOrderTracking(orderID int64) (*domain.OrderTracking, error) {
+	order, err := tu.orderRepo.GetOrderWithItems(orderID)
+	if err != nil {
+		return nil, err
+	}
+	tracking := &domain.OrderTracking{
+		OrderID:   order.ID,
+		Status:    order.Status,
+		CourierID: order.CourierID,
+	}
+	if order.CourierID == 0 || order.Status == domain.OrderStatusDelivered || order.Status == domain.OrderStatusCancelled {
+		return tracking, nil
+	}
+
+	courier, err := tu.courierRepo.GetCourierByID(order.CourierID)
+	if err != nil {
+		return nil, err
+	}
+	tracking.CourierName = courier.Name
+	location, locatedAt, found := tu.tracker.Latest(order.ID)
+	if !found {
+		location, locatedAt = courier.Location, courier.LocatedAt
+	}
+	tracking.Location = location
+	tracking.LocationAt = locatedAt
+
+	address, err := tu.addressRepo.GetAddressByID(order.AddressID)
+	if err != nil {
+		return nil, err
+	}
+	if location == nil || address.Location == nil {
+		return tracking, nil
+	}
+
+	distance := 0.0
+	from := *location
+	if order.Status != domain.OrderStatusPickedUp {
+		supplier, err := tu.supplierRepo.GetSupplierByID(order.SupplierID)
+		if err != nil {
+			return nil, err
+		}
+		if supplier.Location == nil {
+			return tracking, nil
+		}
+		distance += geocoding.DistanceKm(from, *supplier.Location)
+		from = *supplier.Location
+	}
+	distance += geocoding.DistanceKm(from, *address.Location)
+
+	tracking.DistanceKm = roundKm(distance)
+	tracking.EtaMinutes = travelMinutes(distance)
+	arrival := time.Now().Add(time.Duration(tracking.EtaMinutes) * time.Minute)
+	tracking.EstimatedArrival = &arrival
+	return tracking, nil
+}
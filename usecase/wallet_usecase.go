@@ -0,0 +1,150 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"foodDelivery/domain"
+	"foodDelivery/payment"
+	"foodDelivery/repository"
+	"log"
+)
+
+var (
+	ErrInvalidTopUp        = errors.New("top up needs a payment method and an amount between 0 and 500")
+	ErrInvalidWalletCredit = errors.New("credit must be a refund or a promotion with a positive amount")
+)
+
+// maxTopUp is the largest amount added to a wallet at once.
+const maxTopUp = 500
+
+type WalletUseCase interface {
+	GetWallet(userID int64) (*domain.Wallet, error)
+	GetTransactions(userID int64) ([]*domain.WalletTransaction, error)
+	TopUp(userID int64, request *domain.TopUpRequest) (*domain.WalletTransaction, error)
+	CreditWallet(userID int64, credit *domain.WalletCredit) (*domain.WalletTransaction, error)
+}
+
+type walletUseCase struct {
+	walletRepo repository.WalletRepository
+	userRepo   repository.UserRepository
+	orderRepo  repository.OrderRepository
+	loyalty    LoyaltyUseCase
+	gateway    payment.Gateway
+}
+
+func NewWalletUseCase(walletRepo repository.WalletRepository, userRepo repository.UserRepository,
+	orderRepo repository.OrderRepository, loyalty LoyaltyUseCase, gateway payment.Gateway) WalletUseCase {
+	return &walletUseCase{
+		walletRepo: walletRepo,
+		userRepo:   userRepo,
+		orderRepo:  orderRepo,
+		loyalty:    loyalty,
+		gateway:    gateway,
+	}
+}
+
+func (wu *walletUseCase) GetWallet(userID int64) (*domain.Wallet, error) {
+	balance, err := wu.walletRepo.GetBalance(userID)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.Wallet{UserID: userID, Balance: balance}, nil
+}
+
+func (wu *walletUseCase) GetTransactions(userID int64) ([]*domain.WalletTransaction, error) {
+	return wu.walletRepo.GetTransactions(userID)
+}
+
+// TopUp charges the payment method and credits the wallet with the amount. A charge that
+// can not be credited is refunded, so the customer never pays for balance they did not get.
+func (wu *walletUseCase) TopUp(userID int64, request *domain.TopUpRequest) (*domain.WalletTransaction, error) {
+	amount := roundMoney(request.Amount)
+	if amount <= 0 || amount > maxTopUp || request.PaymentMethod == "" {
+		return nil, ErrInvalidTopUp
+	}
+
+	result, err := wu.gateway.Authorize(payment.AuthorizeRequest{Amount: amount, Method: request.PaymentMethod})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPaymentFailed, err)
+	}
+	if result.Status != payment.StatusAuthorized {
+		if result.Status == payment.StatusPending {
+			wu.voidTopUp(userID, result.Reference)
+		}
+		return nil, fmt.Errorf("%w: %s", ErrPaymentDeclined, result.Message)
+	}
+	reference := result.Reference
+	result, err = wu.gateway.Capture(reference, amount)
+	if err != nil || result.Status != payment.StatusCaptured {
+		wu.voidTopUp(userID, reference)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrPaymentFailed, err)
+		}
+		return nil, fmt.Errorf("%w: %s", ErrPaymentFailed, result.Message)
+	}
+
+	transaction := &domain.WalletTransaction{
+		Type:        domain.WalletTransactionTopUp,
+		Amount:      amount,
+		Description: "Wallet top up",
+		Reference:   result.Reference,
+	}
+	err = wu.walletRepo.Credit(userID, transaction)
+	if err != nil {
+		_, refundErr := wu.gateway.Refund(result.Reference, amount)
+		if refundErr != nil {
+			log.Printf("Failed to refund top up %s of user %d that was not credited: %v", result.Reference, userID, refundErr)
+		}
+		return nil, err
+	}
+	return transaction, nil
+}
+
+// voidTopUp releases the authorization of a top up that did not go through.
+func (wu *walletUseCase) voidTopUp(userID int64, reference string) {
+	_, err := wu.gateway.Void(reference)
+	if err != nil {
+		log.Printf("Failed to void top up %s of user %d: %v", reference, userID, err)
+	}
+}
+
+// CreditWallet adds a refund or goodwill credit to the wallet of a user. Refunds of an
+// order take back the loyalty points of the refunded share.
+func (wu *walletUseCase) CreditWallet(userID int64, credit *domain.WalletCredit) (*domain.WalletTransaction, error) {
+	amount := roundMoney(credit.Amount)
+	if amount <= 0 || (credit.Type != domain.WalletTransactionRefund && credit.Type != domain.WalletTransactionPromotion) {
+		return nil, ErrInvalidWalletCredit
+	}
+	_, err := wu.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	var order *domain.Order
+	if credit.OrderID != 0 {
+		order, err = wu.orderRepo.GetOrderWithItems(credit.OrderID)
+		if err != nil {
+			return nil, err
+		}
+		if order.UserID != userID {
+			return nil, repository.ErrOrderNotFound
+		}
+	}
+
+	transaction := &domain.WalletTransaction{
+		Type:        credit.Type,
+		Amount:      amount,
+		Description: credit.Description,
+		OrderID:     credit.OrderID,
+	}
+	err = wu.walletRepo.Credit(userID, transaction)
+	if err != nil {
+		return nil, err
+	}
+	if order != nil && credit.Type == domain.WalletTransactionRefund {
+		err = wu.loyalty.ReverseOrderPoints(order, amount)
+		if err != nil {
+			log.Printf("Failed to reverse loyalty points of order %d: %v", order.ID, err)
+		}
+	}
+	return transaction, nil
+}